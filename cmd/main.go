@@ -1,3 +1,14 @@
+// Sistema de ventas de consola. Se configura con variables de entorno:
+//
+//	SUPERVISOR_PIN      clave del supervisor que autoriza los ajustes de una
+//	                    toma de inventario y las ventas que superan el límite
+//	                    de crédito de un cliente. Sin ella esas operaciones
+//	                    no pueden autorizarse.
+//	LOTES_VENCIDOS      "bloquear" impide vender lotes vencidos; por defecto
+//	                    se avisa y se pide confirmación.
+//	BASE_CURRENCY       moneda local (por defecto ARS).
+//	LAYAWAY_GRACE_DAYS  días de gracia por defecto para cancelar un apartado
+//	                    con cuotas impagas (por defecto 7).
 package main
 
 
@@ -7,8 +18,10 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 	"sales-system/internal/database"
 	"sales-system/internal/handlers"
+	"sales-system/internal/models"
 	"sales-system/internal/repository"
 	"sales-system/internal/utils"
 )
@@ -23,6 +36,21 @@ func main() {
 	productRepo := repository.NewProductRepo(database.DB)
 	saleRepo := repository.NewSaleRepo(database.DB)
 	cashRepo := repository.NewCashDeliveryRepo(database.DB)
+	purchaseRepo := repository.NewPurchaseOrderRepo(database.DB)
+	categoryRepo := repository.NewCategoryRepo(database.DB)
+	stocktakeRepo := repository.NewStocktakeRepo(database.DB)
+	locationRepo := repository.NewLocationRepo(database.DB)
+	lotRepo := repository.NewLotRepo(database.DB)
+	priceRepo := repository.NewPriceRepo(database.DB)
+	quoteRepo := repository.NewQuoteRepo(database.DB)
+	layawayRepo := repository.NewLayawayRepo(database.DB)
+	customerRepo := repository.NewCustomerRepo(database.DB)
+	loyaltyRepo := repository.NewLoyaltyRepo(database.DB)
+	giftCardRepo := repository.NewGiftCardRepo(database.DB)
+	rateRepo := repository.NewExchangeRateRepo(database.DB)
+	sellerRepo := repository.NewSellerRepo(database.DB)
+	targetRepo := repository.NewTargetRepo(database.DB)
+	shipmentRepo := repository.NewShipmentRepo(database.DB)
 
 	var choice int
 	reader := bufio.NewReader(os.Stdin)
@@ -30,7 +58,25 @@ func main() {
 	for {
 		// Limpiar la pantalla para una mejor experiencia de usuario.
 		utils.ClearScreen()
-		showMainMenu()
+		// Los cambios de precio programados entran en vigencia al llegar su fecha.
+		if _, err := priceRepo.ApplyScheduledChanges(time.Now()); err != nil {
+			fmt.Println("Error al aplicar los cambios de precio programados:", err)
+		}
+		// Los presupuestos abiertos vencen al pasar su fecha de validez.
+		if _, err := quoteRepo.ExpireQuotes(time.Now()); err != nil {
+			fmt.Println("Error al vencer los presupuestos:", err)
+		}
+		// Los apartados con cuotas impagas pasados los días de gracia se cancelan.
+		handlers.CancelOverdueLayaways(layawayRepo, time.Now())
+		// Los puntos no canjeados vencen al cumplirse su vigencia.
+		if _, err := loyaltyRepo.ExpirePoints(time.Now()); err != nil {
+			fmt.Println("Error al vencer los puntos:", err)
+		}
+		lowStock, _ := productRepo.GetLowStockProducts()
+		now := time.Now()
+		month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		targets, _ := handlers.LoadTargetProgress(targetRepo, locationRepo, categoryRepo, sellerRepo, month, now)
+		showMainMenu(lowStock, targets)
 		
 		// Leer la opción del usuario del menú principal.
 		choiceStr, _ := reader.ReadString('\n')
@@ -39,14 +85,20 @@ func main() {
 		// Usar un switch para dirigir el flujo del programa según la elección del usuario.
 		switch choice {
 		case 1:
-			handleSalesMenu(saleRepo, productRepo)
+			handleSalesMenu(saleRepo, productRepo, locationRepo, lotRepo, quoteRepo, layawayRepo, customerRepo, loyaltyRepo, giftCardRepo, rateRepo, sellerRepo, shipmentRepo)
 		case 2:
-			handleProductsMenu(productRepo)
+			handleProductsMenu(productRepo, saleRepo, purchaseRepo, categoryRepo, stocktakeRepo, locationRepo, lotRepo, priceRepo)
 		case 3:
 			handlers.RegisterCashDelivery(cashRepo)
 		case 4:
-			handlers.GenerateReport(saleRepo, cashRepo, productRepo)
+			handlers.GenerateReport(saleRepo, cashRepo, categoryRepo, locationRepo, giftCardRepo, shipmentRepo)
 		case 5:
+			handleCustomersMenu(customerRepo, saleRepo, loyaltyRepo, categoryRepo)
+		case 6:
+			handleSellersMenu(sellerRepo, categoryRepo)
+		case 7:
+			handleTargetsMenu(targetRepo, locationRepo, categoryRepo, sellerRepo)
+		case 8:
 			fmt.Println("Saliendo del sistema...")
 			return
 		default:
@@ -57,19 +109,37 @@ func main() {
 	}
 }
 
-// showMainMenu muestra el menú principal en la consola.
-func showMainMenu() {
+// showMainMenu muestra el menú principal en la consola, con un aviso si hay
+// productos con stock bajo y el avance de las metas de ventas del mes.
+func showMainMenu(lowStock []models.Product, targets []models.TargetProgress) {
+	if len(lowStock) > 0 {
+		fmt.Println("\n**************************************************")
+		fmt.Printf("  ATENCIÓN: %d producto(s) con stock bajo\n", len(lowStock))
+		for _, p := range lowStock {
+			fmt.Printf("  - %s (stock: %s, mínimo: %s)\n", p.Name, p.FormatQuantity(p.Quantity), p.FormatQuantity(p.MinStock))
+		}
+		fmt.Println("**************************************************")
+	}
+	if len(targets) > 0 {
+		fmt.Printf("\n--- Metas de %s ---\n", targets[0].Month.Format("01/2006"))
+		for _, t := range targets {
+			fmt.Printf("  %-10s %-20s %s %5.1f%% (proyección: %.1f%%)\n", t.Scope, t.Name, utils.ProgressBar(t.Percent(), 20), t.Percent(), t.ProjectedPercent())
+		}
+	}
 	fmt.Println("\n--- Menú Principal ---")
 	fmt.Println("1. VENTAS")
 	fmt.Println("2. PRODUCTOS")
 	fmt.Println("3. Entregas de dinero")
 	fmt.Println("4. Reporte de Ventas")
-	fmt.Println("5. Salir")
+	fmt.Println("5. CLIENTES")
+	fmt.Println("6. VENDEDORES")
+	fmt.Println("7. METAS DE VENTAS")
+	fmt.Println("8. Salir")
 	fmt.Print("Seleccione una opción: ")
 }
 
 // handleSalesMenu maneja el submenú de ventas.
-func handleSalesMenu(saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo) {
+func handleSalesMenu(saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo, lotRepo *repository.LotRepo, quoteRepo *repository.QuoteRepo, layawayRepo *repository.LayawayRepo, customerRepo *repository.CustomerRepo, loyaltyRepo *repository.LoyaltyRepo, giftCardRepo *repository.GiftCardRepo, rateRepo *repository.ExchangeRateRepo, sellerRepo *repository.SellerRepo, shipmentRepo *repository.ShipmentRepo) {
 	reader := bufio.NewReader(os.Stdin)
 	for {
 		utils.ClearScreen()
@@ -78,7 +148,13 @@ func handleSalesMenu(saleRepo *repository.SaleRepo, productRepo *repository.Prod
 		fmt.Println("2. Mostrar Ventas")
 		fmt.Println("3. Editar Venta")
 		fmt.Println("4. Eliminar Venta")
-		fmt.Println("5. Volver al Menú Principal")
+		fmt.Println("5. Venta Rápida (Modo Escáner)")
+		fmt.Println("6. Presupuestos")
+		fmt.Println("7. Apartados y Cuotas")
+		fmt.Println("8. Tarjetas de Regalo y Devoluciones")
+		fmt.Println("9. Cotizaciones de Moneda")
+		fmt.Println("10. Envíos a Domicilio")
+		fmt.Println("11. Volver al Menú Principal")
 		fmt.Print("Seleccione una opción: ")
 
 		choiceStr, _ := reader.ReadString('\n')
@@ -86,13 +162,285 @@ func handleSalesMenu(saleRepo *repository.SaleRepo, productRepo *repository.Prod
 
 		switch choice {
 		case 1:
-			handlers.RegisterSale(saleRepo, productRepo)
+			handlers.RegisterSale(saleRepo, productRepo, locationRepo, lotRepo, customerRepo, loyaltyRepo, giftCardRepo, rateRepo, sellerRepo, shipmentRepo)
 		case 2:
-			handlers.ShowSales(saleRepo, productRepo)
+			handlers.ShowSales(saleRepo, productRepo, locationRepo, lotRepo)
 		case 3:
-			handlers.EditSale(saleRepo, productRepo)
+			handlers.EditSale(saleRepo, productRepo, locationRepo, lotRepo, customerRepo, loyaltyRepo, sellerRepo)
 		case 4:
 			handlers.DeleteSale(saleRepo)
+		case 5:
+			handlers.ScanSale(saleRepo, productRepo, locationRepo, lotRepo, customerRepo, loyaltyRepo, giftCardRepo, rateRepo, sellerRepo, shipmentRepo)
+		case 6:
+			handleQuotesMenu(quoteRepo, saleRepo, productRepo, locationRepo, lotRepo, customerRepo, sellerRepo)
+			continue
+		case 7:
+			handleLayawaysMenu(layawayRepo, saleRepo, productRepo, locationRepo, lotRepo, sellerRepo)
+			continue
+		case 8:
+			handleGiftCardsMenu(giftCardRepo, saleRepo, productRepo, loyaltyRepo)
+			continue
+		case 9:
+			handleExchangeRatesMenu(rateRepo)
+			continue
+		case 10:
+			handleShipmentsMenu(shipmentRepo)
+			continue
+		case 11:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
+// handleQuotesMenu maneja el submenú de presupuestos.
+func handleQuotesMenu(quoteRepo *repository.QuoteRepo, saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo, lotRepo *repository.LotRepo, customerRepo *repository.CustomerRepo, sellerRepo *repository.SellerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Presupuestos ---")
+		fmt.Println("1. Nuevo Presupuesto")
+		fmt.Println("2. Mostrar Presupuestos")
+		fmt.Println("3. Convertir Presupuesto en Venta")
+		fmt.Println("4. Volver al Menú de Ventas")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.CreateQuote(quoteRepo, productRepo, locationRepo)
+		case 2:
+			handlers.ShowQuotes(quoteRepo)
+		case 3:
+			handlers.ConvertQuoteToSale(quoteRepo, saleRepo, productRepo, locationRepo, lotRepo, customerRepo, sellerRepo)
+		case 4:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
+// handleLayawaysMenu maneja el submenú de apartados y planes de cuotas.
+func handleLayawaysMenu(layawayRepo *repository.LayawayRepo, saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo, lotRepo *repository.LotRepo, sellerRepo *repository.SellerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Apartados y Cuotas ---")
+		fmt.Println("1. Nuevo Apartado")
+		fmt.Println("2. Mostrar Apartados")
+		fmt.Println("3. Registrar Pago")
+		fmt.Println("4. Cuotas Vencidas y Próximas")
+		fmt.Println("5. Volver al Menú de Ventas")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.RegisterLayaway(layawayRepo, productRepo, locationRepo, lotRepo, sellerRepo)
+		case 2:
+			handlers.ShowLayaways(layawayRepo)
+		case 3:
+			handlers.RegisterLayawayPayment(layawayRepo)
+		case 4:
+			handlers.ShowInstallmentReport(layawayRepo)
+		case 5:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
+// handleGiftCardsMenu maneja el submenú de tarjetas de regalo, crédito en
+// tienda y devoluciones.
+func handleGiftCardsMenu(giftCardRepo *repository.GiftCardRepo, saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo, loyaltyRepo *repository.LoyaltyRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Tarjetas de Regalo y Devoluciones ---")
+		fmt.Println("1. Vender o Recargar Tarjeta de Regalo")
+		fmt.Println("2. Consultar Saldo")
+		fmt.Println("3. Devolución con Crédito en Tienda")
+		fmt.Println("4. Saldos Pendientes a una Fecha")
+		fmt.Println("5. Volver al Menú de Ventas")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.SellGiftCard(giftCardRepo)
+		case 2:
+			handlers.ShowGiftCardBalance(giftCardRepo)
+		case 3:
+			handlers.ReturnSale(saleRepo, productRepo)
+		case 4:
+			handlers.ShowGiftCardLiability(giftCardRepo)
+		case 5:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
+// handleExchangeRatesMenu maneja el submenú de cotizaciones de moneda extranjera.
+func handleExchangeRatesMenu(rateRepo *repository.ExchangeRateRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Cotizaciones ---")
+		fmt.Println("1. Cargar Cotización")
+		fmt.Println("2. Importar Cotizaciones (CSV)")
+		fmt.Println("3. Mostrar Cotizaciones")
+		fmt.Println("4. Volver al Menú de Ventas")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.RegisterExchangeRate(rateRepo)
+		case 2:
+			handlers.ImportExchangeRates(rateRepo)
+		case 3:
+			handlers.ShowExchangeRates(rateRepo)
+		case 4:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
+// handleCustomersMenu maneja el submenú de clientes y sus cuentas.
+func handleCustomersMenu(customerRepo *repository.CustomerRepo, saleRepo *repository.SaleRepo, loyaltyRepo *repository.LoyaltyRepo, categoryRepo *repository.CategoryRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Clientes ---")
+		fmt.Println("1. Registrar Cliente")
+		fmt.Println("2. Mostrar Clientes y Saldos")
+		fmt.Println("3. Editar Cliente")
+		fmt.Println("4. Registrar Pago o Nota de Crédito")
+		fmt.Println("5. Estado de Cuenta")
+		fmt.Println("6. Estados de Cuenta de Clientes con Saldo (PDF)")
+		fmt.Println("7. Historial de Compras")
+		fmt.Println("8. Análisis RFM (segmentos)")
+		fmt.Println("9. Programa de Puntos (reglas)")
+		fmt.Println("10. Movimientos de Puntos de un Cliente")
+		fmt.Println("11. Volver al Menú Principal")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.RegisterCustomer(customerRepo)
+		case 2:
+			handlers.ShowCustomers(customerRepo, loyaltyRepo)
+		case 3:
+			handlers.EditCustomer(customerRepo)
+		case 4:
+			handlers.RegisterCustomerPayment(customerRepo)
+		case 5:
+			handlers.ShowCustomerStatement(customerRepo)
+		case 6:
+			handlers.ExportAllStatements(customerRepo)
+		case 7:
+			handlers.ShowCustomerHistory(saleRepo)
+		case 8:
+			handlers.ShowRFMReport(saleRepo)
+		case 9:
+			handlers.ConfigureLoyalty(loyaltyRepo, categoryRepo)
+		case 10:
+			handlers.ShowLoyaltyLedger(customerRepo, loyaltyRepo)
+		case 11:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
+// handleShipmentsMenu maneja el submenú de envíos a domicilio.
+func handleShipmentsMenu(shipmentRepo *repository.ShipmentRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Envíos ---")
+		fmt.Println("1. Mostrar Envíos Pendientes")
+		fmt.Println("2. Actualizar Estado de Envío")
+		fmt.Println("3. Hoja de Ruta del Día (PDF)")
+		fmt.Println("4. Volver al Menú de Ventas")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.ShowShipments(shipmentRepo)
+		case 2:
+			handlers.UpdateShipmentStatus(shipmentRepo)
+		case 3:
+			handlers.ShowDispatchList(shipmentRepo)
+		case 4:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
+// handleSellersMenu maneja el submenú de vendedores y comisiones.
+func handleSellersMenu(sellerRepo *repository.SellerRepo, categoryRepo *repository.CategoryRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Vendedores ---")
+		fmt.Println("1. Registrar Vendedor")
+		fmt.Println("2. Mostrar Vendedores")
+		fmt.Println("3. Reglas de Comisión")
+		fmt.Println("4. Reporte Mensual de Comisiones")
+		fmt.Println("5. Volver al Menú Principal")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.RegisterSeller(sellerRepo)
+		case 2:
+			handlers.ShowSellers(sellerRepo)
+		case 3:
+			handlers.ConfigureCommission(sellerRepo, categoryRepo)
+		case 4:
+			handlers.ShowCommissionReport(sellerRepo, categoryRepo)
 		case 5:
 			return
 		default:
@@ -103,8 +451,37 @@ func handleSalesMenu(saleRepo *repository.SaleRepo, productRepo *repository.Prod
 	}
 }
 
+// handleTargetsMenu maneja el submenú de metas de ventas.
+func handleTargetsMenu(targetRepo *repository.TargetRepo, locationRepo *repository.LocationRepo, categoryRepo *repository.CategoryRepo, sellerRepo *repository.SellerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Metas de Ventas ---")
+		fmt.Println("1. Definir Meta")
+		fmt.Println("2. Progreso de Metas")
+		fmt.Println("3. Volver al Menú Principal")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.SetSalesTarget(targetRepo, locationRepo, categoryRepo, sellerRepo)
+		case 2:
+			handlers.ShowTargetProgress(targetRepo, locationRepo, categoryRepo, sellerRepo)
+		case 3:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
 // handleProductsMenu maneja el submenú de productos.
-func handleProductsMenu(productRepo *repository.ProductRepo) {
+func handleProductsMenu(productRepo *repository.ProductRepo, saleRepo *repository.SaleRepo, purchaseRepo *repository.PurchaseOrderRepo, categoryRepo *repository.CategoryRepo, stocktakeRepo *repository.StocktakeRepo, locationRepo *repository.LocationRepo, lotRepo *repository.LotRepo, priceRepo *repository.PriceRepo) {
 	reader := bufio.NewReader(os.Stdin)
 	for {
 		utils.ClearScreen()
@@ -113,7 +490,249 @@ func handleProductsMenu(productRepo *repository.ProductRepo) {
 		fmt.Println("2. Mostrar productos")
 		fmt.Println("3. Editar Producto")
 		fmt.Println("4. Eliminar Producto")
-		fmt.Println("5. Volver al Menú Principal")
+		fmt.Println("5. Entrada de Mercadería")
+		fmt.Println("6. Sugerencia de Reposición")
+		fmt.Println("7. Órdenes de Compra")
+		fmt.Println("8. Categorías")
+		fmt.Println("9. Imprimir Etiquetas")
+		fmt.Println("10. Variantes (talle, color)")
+		fmt.Println("11. Kits y Combos")
+		fmt.Println("12. Toma de Inventario")
+		fmt.Println("13. Ubicaciones y Transferencias")
+		fmt.Println("14. Lotes por Vencer")
+		fmt.Println("15. Precios")
+		fmt.Println("16. Productos Archivados")
+		fmt.Println("17. Volver al Menú Principal")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.RegisterProduct(productRepo, categoryRepo)
+		case 2:
+			handlers.ListProducts(productRepo, categoryRepo)
+		case 3:
+			handlers.EditProduct(productRepo, categoryRepo)
+		case 4:
+			handlers.DeleteProduct(productRepo, categoryRepo)
+		case 5:
+			handlers.RegisterStockReceipt(productRepo, locationRepo)
+		case 6:
+			handlers.ShowReorderSuggestions(productRepo, saleRepo, purchaseRepo)
+		case 7:
+			handlers.ShowPurchaseOrders(purchaseRepo, productRepo, locationRepo)
+		case 8:
+			handleCategoriesMenu(categoryRepo)
+			continue
+		case 9:
+			handlers.PrintLabels(productRepo, purchaseRepo)
+		case 10:
+			handleVariantsMenu(productRepo)
+			continue
+		case 11:
+			handleKitsMenu(productRepo, locationRepo)
+			continue
+		case 12:
+			handleStocktakeMenu(stocktakeRepo, productRepo, locationRepo)
+			continue
+		case 13:
+			handleLocationsMenu(locationRepo, productRepo)
+			continue
+		case 14:
+			handlers.ShowExpiringLots(lotRepo, productRepo, locationRepo)
+		case 15:
+			handlePricesMenu(priceRepo, productRepo, categoryRepo)
+			continue
+		case 16:
+			handlers.ShowArchivedProducts(productRepo)
+		case 17:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
+// handleCategoriesMenu maneja el submenú de categorías de productos.
+func handleCategoriesMenu(categoryRepo *repository.CategoryRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Categorías ---")
+		fmt.Println("1. Registrar Categoría")
+		fmt.Println("2. Mostrar Categorías")
+		fmt.Println("3. Eliminar Categoría")
+		fmt.Println("4. Volver al Menú de Productos")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.RegisterCategory(categoryRepo)
+		case 2:
+			handlers.ShowCategories(categoryRepo)
+		case 3:
+			handlers.DeleteCategory(categoryRepo)
+		case 4:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
+// handleVariantsMenu maneja el submenú de variantes de productos.
+func handleVariantsMenu(productRepo *repository.ProductRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Variantes ---")
+		fmt.Println("1. Generar Variantes")
+		fmt.Println("2. Matriz de Stock por Variante")
+		fmt.Println("3. Volver al Menú de Productos")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.GenerateVariants(productRepo)
+		case 2:
+			handlers.ShowVariantMatrix(productRepo)
+		case 3:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
+// handleKitsMenu maneja el submenú de kits (productos armados con otros productos).
+func handleKitsMenu(productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Kits ---")
+		fmt.Println("1. Definir Componentes de un Kit")
+		fmt.Println("2. Kits y Disponibilidad")
+		fmt.Println("3. Volver al Menú de Productos")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.DefineKit(productRepo)
+		case 2:
+			handlers.ShowKits(productRepo, locationRepo)
+		case 3:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
+// handleStocktakeMenu maneja el submenú de tomas de inventario físico.
+func handleStocktakeMenu(stocktakeRepo *repository.StocktakeRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Toma de Inventario ---")
+		fmt.Println("1. Nueva Toma de Inventario")
+		fmt.Println("2. Cargar Conteo")
+		fmt.Println("3. Informe de Diferencias")
+		fmt.Println("4. Aplicar Ajustes (Supervisor)")
+		fmt.Println("5. Cancelar Toma")
+		fmt.Println("6. Volver al Menú de Productos")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.StartStocktake(stocktakeRepo, locationRepo)
+		case 2:
+			handlers.CountStocktake(stocktakeRepo, productRepo, locationRepo)
+		case 3:
+			handlers.ShowStocktakeVariance(stocktakeRepo, productRepo, locationRepo)
+		case 4:
+			handlers.ApplyStocktake(stocktakeRepo, productRepo, locationRepo)
+		case 5:
+			handlers.CancelStocktake(stocktakeRepo, locationRepo)
+		case 6:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
+// handleLocationsMenu maneja el submenú de ubicaciones y transferencias de stock.
+func handleLocationsMenu(locationRepo *repository.LocationRepo, productRepo *repository.ProductRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Ubicaciones ---")
+		fmt.Println("1. Registrar Ubicación")
+		fmt.Println("2. Stock por Ubicación")
+		fmt.Println("3. Nueva Transferencia")
+		fmt.Println("4. Transferencias (ver / recibir)")
+		fmt.Println("5. Volver al Menú de Productos")
+		fmt.Print("Seleccione una opción: ")
+
+		choiceStr, _ := reader.ReadString('\n')
+		choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
+
+		switch choice {
+		case 1:
+			handlers.RegisterLocation(locationRepo)
+		case 2:
+			handlers.ShowStockByLocation(productRepo, locationRepo)
+		case 3:
+			handlers.CreateTransfer(locationRepo, productRepo)
+		case 4:
+			handlers.ShowTransfers(locationRepo, productRepo)
+		case 5:
+			return
+		default:
+			fmt.Println("Opción no válida.")
+		}
+		fmt.Print("Presione Enter para continuar...")
+		reader.ReadString('\n')
+	}
+}
+
+// handlePricesMenu maneja el submenú de precios: historial, cambios
+// programados y actualizaciones masivas.
+func handlePricesMenu(priceRepo *repository.PriceRepo, productRepo *repository.ProductRepo, categoryRepo *repository.CategoryRepo) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		utils.ClearScreen()
+		fmt.Println("\n--- Menú de Precios ---")
+		fmt.Println("1. Historial de Precios")
+		fmt.Println("2. Programar Cambio de Precio")
+		fmt.Println("3. Cambios Programados (ver / cancelar)")
+		fmt.Println("4. Actualización Masiva por Porcentaje")
+		fmt.Println("5. Informe de Cambios de Precio")
+		fmt.Println("6. Volver al Menú de Productos")
 		fmt.Print("Seleccione una opción: ")
 
 		choiceStr, _ := reader.ReadString('\n')
@@ -121,14 +740,16 @@ func handleProductsMenu(productRepo *repository.ProductRepo) {
 
 		switch choice {
 		case 1:
-			handlers.RegisterProduct(productRepo)
+			handlers.ShowPriceHistory(priceRepo, productRepo)
 		case 2:
-			handlers.ShowProducts(productRepo)
+			handlers.SchedulePriceChange(priceRepo, productRepo)
 		case 3:
-			handlers.EditProduct(productRepo)
+			handlers.ShowScheduledPriceChanges(priceRepo, productRepo)
 		case 4:
-			handlers.DeleteProduct(productRepo)
+			handlers.BulkUpdatePrices(priceRepo, productRepo, categoryRepo)
 		case 5:
+			handlers.ShowPriceChangeReport(priceRepo, productRepo)
+		case 6:
 			return
 		default:
 			fmt.Println("Opción no válida.")
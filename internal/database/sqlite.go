@@ -16,6 +16,7 @@ func InitDB(dbPath string) {
 	}
 
 	createTables()
+	migrateTables()
 }
 
 func createTables() {
@@ -23,7 +24,7 @@ func createTables() {
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		date TEXT,
 		name TEXT,
-		quantity INTEGER,
+		quantity REAL,
 		price REAL
 	);`)
 	if err != nil {
@@ -35,7 +36,7 @@ func createTables() {
 		date TEXT,
 		client TEXT,
 		product_id INTEGER,
-		quantity INTEGER,
+		quantity REAL,
 		price REAL,
 		total REAL,
 		status TEXT
@@ -54,4 +55,604 @@ func createTables() {
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS stock_layers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER,
+		date TEXT,
+		quantity REAL,
+		remaining REAL,
+		unit_cost REAL
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS stock_movements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date TEXT,
+		product_id INTEGER,
+		type TEXT,
+		quantity REAL,
+		unit_cost REAL,
+		reference TEXT
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS purchase_orders (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date TEXT,
+		supplier TEXT,
+		status TEXT
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS purchase_order_items (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		order_id INTEGER,
+		product_id INTEGER,
+		quantity REAL,
+		unit_cost REAL
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS categories (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT,
+		parent_id INTEGER DEFAULT 0
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS locations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS location_stock (
+		product_id INTEGER,
+		location_id INTEGER,
+		quantity REAL DEFAULT 0,
+		PRIMARY KEY (product_id, location_id)
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS transfers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date TEXT,
+		from_location_id INTEGER,
+		to_location_id INTEGER,
+		status TEXT,
+		received_date TEXT DEFAULT ''
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS transfer_items (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		transfer_id INTEGER,
+		product_id INTEGER,
+		quantity REAL
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS lots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER,
+		location_id INTEGER,
+		number TEXT,
+		expiry TEXT,
+		quantity REAL DEFAULT 0,
+		UNIQUE(product_id, location_id, number)
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS sale_lots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		sale_id INTEGER,
+		lot_id INTEGER,
+		quantity REAL
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS stocktakes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date TEXT,
+		status TEXT,
+		supervisor TEXT DEFAULT '',
+		applied_date TEXT DEFAULT ''
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS stocktake_counts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		stocktake_id INTEGER,
+		product_id INTEGER,
+		expected REAL,
+		counted REAL,
+		unit_cost REAL,
+		UNIQUE(stocktake_id, product_id)
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS price_changes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER,
+		old_price REAL,
+		new_price REAL,
+		effective_date TEXT,
+		status TEXT,
+		reason TEXT DEFAULT ''
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS kit_components (
+		kit_id INTEGER,
+		component_id INTEGER,
+		quantity REAL,
+		PRIMARY KEY (kit_id, component_id)
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS sale_components (
+		sale_id INTEGER,
+		component_id INTEGER,
+		per_kit REAL,
+		quantity REAL,
+		price REAL,
+		cost REAL,
+		PRIMARY KEY (sale_id, component_id)
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS quotes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date TEXT,
+		client TEXT,
+		valid_until TEXT,
+		status TEXT,
+		location_id INTEGER DEFAULT 1
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS quote_lines (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		quote_id INTEGER,
+		product_id INTEGER,
+		product_name TEXT,
+		quantity REAL,
+		price REAL,
+		discount REAL DEFAULT 0,
+		sale_id INTEGER DEFAULT 0
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS layaways (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		sale_id INTEGER,
+		date TEXT,
+		client TEXT,
+		product_name TEXT,
+		total REAL,
+		status TEXT,
+		grace_days INTEGER,
+		cancelled_date TEXT DEFAULT ''
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS layaway_installments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		layaway_id INTEGER,
+		number INTEGER,
+		due_date TEXT,
+		amount REAL,
+		paid REAL DEFAULT 0
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS layaway_payments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		layaway_id INTEGER,
+		date TEXT,
+		amount REAL
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS customers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE COLLATE NOCASE,
+		phone TEXT DEFAULT '',
+		credit_limit REAL DEFAULT 0,
+		payment_terms INTEGER DEFAULT 30
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS customer_payments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date TEXT,
+		client TEXT,
+		amount REAL,
+		type TEXT,
+		note TEXT DEFAULT ''
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS loyalty_settings (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		amount_per_point REAL DEFAULT 0,
+		point_value REAL DEFAULT 1,
+		expiry_days INTEGER DEFAULT 365
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS loyalty_excluded_categories (
+		category_id INTEGER PRIMARY KEY
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS loyalty_bonus_days (
+		weekday INTEGER PRIMARY KEY,
+		multiplier REAL
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS loyalty_points (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		customer_id INTEGER,
+		date TEXT,
+		points INTEGER,
+		type TEXT,
+		sale_id INTEGER DEFAULT 0,
+		expires_at TEXT DEFAULT '',
+		remaining INTEGER DEFAULT 0
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS credit_overrides (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date TEXT,
+		client TEXT,
+		supervisor TEXT,
+		amount REAL,
+		reason TEXT
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS gift_cards (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		code TEXT UNIQUE COLLATE NOCASE,
+		kind TEXT,
+		client TEXT DEFAULT '',
+		issued_at TEXT
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS gift_card_movements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		card_id INTEGER,
+		date TEXT,
+		amount REAL,
+		type TEXT,
+		sale_id INTEGER DEFAULT 0,
+		note TEXT DEFAULT ''
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS exchange_rates (
+		date TEXT,
+		currency TEXT,
+		rate REAL,
+		PRIMARY KEY (currency, date)
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS sellers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE COLLATE NOCASE,
+		active INTEGER DEFAULT 1
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS commission_plans (
+		seller_id INTEGER PRIMARY KEY,
+		basis TEXT,
+		percent REAL DEFAULT 0
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS commission_tiers (
+		seller_id INTEGER,
+		threshold REAL,
+		percent REAL,
+		PRIMARY KEY (seller_id, threshold)
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS commission_category_rates (
+		seller_id INTEGER,
+		category_id INTEGER,
+		percent REAL,
+		PRIMARY KEY (seller_id, category_id)
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS sales_targets (
+		period TEXT,
+		scope TEXT,
+		ref_id INTEGER,
+		amount REAL,
+		PRIMARY KEY (period, scope, ref_id)
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS shipments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		client TEXT,
+		address TEXT,
+		zone TEXT,
+		scheduled_date TEXT,
+		courier TEXT,
+		fee REAL DEFAULT 0,
+		status TEXT,
+		note TEXT DEFAULT ''
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS shipment_sales (
+		shipment_id INTEGER,
+		sale_id INTEGER,
+		PRIMARY KEY (shipment_id, sale_id)
+	);`)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// migrateTables agrega a las tablas existentes las columnas que se
+// incorporaron después de su creación, para no perder datos de bases antiguas.
+func migrateTables() {
+	addColumn("products", "cost", "REAL DEFAULT 0")
+	addColumn("products", "cost_method", "TEXT DEFAULT 'Promedio'")
+	salesCostAdded := addColumn("sales", "cost", "REAL DEFAULT 0")
+	addColumn("products", "min_stock", "REAL DEFAULT 0")
+	addColumn("products", "reorder_qty", "REAL DEFAULT 0")
+	addColumn("products", "category_id", "INTEGER DEFAULT 0")
+	addColumn("products", "brand", "TEXT DEFAULT ''")
+	addColumn("products", "tags", "TEXT DEFAULT ''")
+	addColumn("products", "sku", "TEXT DEFAULT ''")
+	addColumn("products", "barcode", "TEXT DEFAULT ''")
+	addColumn("products", "parent_id", "INTEGER DEFAULT 0")
+	addColumn("products", "attributes", "TEXT DEFAULT ''")
+	addColumn("products", "unit", "TEXT DEFAULT 'unidad'")
+	addColumn("products", "decimals", "INTEGER DEFAULT 0")
+	addColumn("products", "purchase_unit", "TEXT DEFAULT ''")
+	addColumn("products", "purchase_factor", "REAL DEFAULT 1")
+	addColumn("sales", "location_id", "INTEGER DEFAULT 1")
+	addColumn("stock_movements", "location_id", "INTEGER DEFAULT 1")
+	addColumn("stocktakes", "location_id", "INTEGER DEFAULT 1")
+	addColumn("products", "track_lots", "INTEGER DEFAULT 0")
+	addColumn("transfer_items", "lot_number", "TEXT DEFAULT ''")
+	addColumn("transfer_items", "lot_expiry", "TEXT DEFAULT ''")
+	addColumn("products", "is_kit", "INTEGER DEFAULT 0")
+	addColumn("sales", "product_name", "TEXT DEFAULT ''")
+	addColumn("sales", "product_sku", "TEXT DEFAULT ''")
+	addColumn("products", "archived", "INTEGER DEFAULT 0")
+	addColumn("sales", "payment_id", "INTEGER DEFAULT 0")
+	addColumn("sales", "currency", "TEXT DEFAULT ''")
+	addColumn("sales", "currency_amount", "REAL DEFAULT 0")
+	addColumn("sales", "exchange_rate", "REAL DEFAULT 0")
+	addColumn("sales", "seller_id", "INTEGER DEFAULT 0")
+	addColumn("layaways", "completed_date", "TEXT DEFAULT ''")
+	addColumn("products", "price_override", "INTEGER DEFAULT 0")
+
+	// El SKU y el código de barras son únicos, pero pueden quedar vacíos. El
+	// SKU se busca sin distinguir mayúsculas, así que tampoco las distingue
+	// al controlar que no se repita.
+	_, err := DB.Exec("DROP INDEX IF EXISTS idx_products_sku")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku_nocase ON products(sku COLLATE NOCASE) WHERE sku <> ''")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_products_barcode ON products(barcode) WHERE barcode <> ''")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Índices para los reportes y las búsquedas sobre historiales grandes.
+	indexes := []string{
+		"CREATE INDEX IF NOT EXISTS idx_sales_date ON sales(date)",
+		"CREATE INDEX IF NOT EXISTS idx_sales_product ON sales(product_id)",
+		"CREATE INDEX IF NOT EXISTS idx_sales_client ON sales(client)",
+		"CREATE INDEX IF NOT EXISTS idx_cash_deliveries_date ON cash_deliveries(date)",
+		"CREATE INDEX IF NOT EXISTS idx_sale_lots_sale ON sale_lots(sale_id)",
+		"CREATE INDEX IF NOT EXISTS idx_stock_movements_product ON stock_movements(product_id)",
+		"CREATE INDEX IF NOT EXISTS idx_gift_card_movements_card ON gift_card_movements(card_id)",
+		"CREATE INDEX IF NOT EXISTS idx_sales_seller ON sales(seller_id)",
+		"CREATE INDEX IF NOT EXISTS idx_shipments_date ON shipments(scheduled_date)",
+		"CREATE INDEX IF NOT EXISTS idx_shipment_sales_sale ON shipment_sales(sale_id)",
+	}
+	for _, index := range indexes {
+		if _, err := DB.Exec(index); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// La ubicación principal (ID 1) recibe el stock de los productos que
+	// todavía no tienen stock por ubicación.
+	_, err = DB.Exec("INSERT INTO locations (id, name) SELECT 1, 'Principal' WHERE NOT EXISTS (SELECT 1 FROM locations WHERE id = 1)")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = DB.Exec("INSERT INTO location_stock (product_id, location_id, quantity) SELECT id, 1, quantity FROM products WHERE id NOT IN (SELECT product_id FROM location_stock)")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// El stock total del producto se deriva del stock por ubicación más lo que
+	// está en tránsito; se corrige en las bases donde se llevaban por separado.
+	_, err = DB.Exec(`UPDATE products SET quantity = COALESCE((SELECT SUM(ls.quantity) FROM location_stock ls WHERE ls.product_id = products.id), 0)
+		+ COALESCE((SELECT SUM(i.quantity) FROM transfer_items i JOIN transfers t ON t.id = i.transfer_id WHERE t.status = 'En tránsito' AND i.product_id = products.id), 0)`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Las ventas registradas antes de guardar el costo se valúan al costo del
+	// producto al momento de agregar la columna, para que no figuren con 100%
+	// de margen. Se hace una sola vez: después el costo queda congelado.
+	if salesCostAdded {
+		_, err = DB.Exec(`UPDATE sales SET cost = quantity * COALESCE((SELECT cost FROM products WHERE id = sales.product_id), 0)
+			WHERE product_id <> 0`)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Las variantes que siguen el precio del padre siempre tienen su mismo
+	// precio; las que tienen otro precio lo fijaron a mano.
+	_, err = DB.Exec("UPDATE products SET price_override = 1 WHERE parent_id <> 0 AND price_override = 0 AND price <> (SELECT pp.price FROM products pp WHERE pp.id = products.parent_id)")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Los envíos registrados antes de vincular todas las ventas del ticket
+	// quedan vinculados a la venta con la que se crearon. Las bases de esa
+	// época conservan la columna shipments.sale_id, que ya no se escribe ni
+	// se lee: las ventas de un envío están solo en shipment_sales.
+	if hasColumn("shipments", "sale_id") {
+		_, err = DB.Exec("INSERT INTO shipment_sales (shipment_id, sale_id) SELECT id, sale_id FROM shipments WHERE sale_id IS NOT NULL AND id NOT IN (SELECT shipment_id FROM shipment_sales)")
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Los apartados completados antes de guardar la fecha en que se terminaron
+	// de pagar toman la de su último pago.
+	_, err = DB.Exec("UPDATE layaways SET completed_date = COALESCE((SELECT MAX(date) FROM layaway_payments p WHERE p.layaway_id = layaways.id), date) WHERE status = 'Completado' AND completed_date = ''")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Las ventas anteriores a la copia de los datos del producto la toman del
+	// producto actual; si el producto ya fue eliminado queda su ID.
+	_, err = DB.Exec("UPDATE sales SET product_name = COALESCE((SELECT name FROM products WHERE id = sales.product_id), 'Producto #' || product_id), product_sku = COALESCE((SELECT sku FROM products WHERE id = sales.product_id), '') WHERE product_name = ''")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Los productos sin historial de precios arrancan con su precio actual,
+	// vigente desde la fecha de alta.
+	_, err = DB.Exec("INSERT INTO price_changes (product_id, old_price, new_price, effective_date, status, reason) SELECT id, 0, price, substr(date, 1, 10), 'Aplicado', 'Precio inicial' FROM products WHERE id NOT IN (SELECT product_id FROM price_changes)")
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// addColumn agrega una columna a una tabla solo si todavía no existe e
+// indica si la agregó, para completar los datos de las filas existentes una
+// sola vez.
+func addColumn(table, column, definition string) bool {
+	if hasColumn(table, column) {
+		return false
+	}
+	_, err := DB.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return true
+}
+
+// hasColumn indica si la tabla tiene la columna.
+func hasColumn(table, column string) bool {
+	rows, err := DB.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			log.Fatal(err)
+		}
+		if name == column {
+			return true
+		}
+	}
+	return false
 }
\ No newline at end of file
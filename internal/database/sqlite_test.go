@@ -0,0 +1,69 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitDBKeepsFrozenSaleCost(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	InitDB(path)
+	if _, err := DB.Exec("INSERT INTO products (id, name, quantity, price, cost) VALUES (1, 'Queso', 0, 50, 0)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DB.Exec("INSERT INTO sales (id, product_id, quantity, price, total, cost) VALUES (1, 1, 2, 50, 100, 0)"); err != nil {
+		t.Fatal(err)
+	}
+	// La venta se hizo cuando el producto no tenía costo; que después lo
+	// tenga no cambia el costo congelado en la venta.
+	if _, err := DB.Exec("UPDATE products SET cost = 35 WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	DB.Close()
+
+	InitDB(path)
+	defer DB.Close()
+	var cost float64
+	if err := DB.QueryRow("SELECT cost FROM sales WHERE id = 1").Scan(&cost); err != nil {
+		t.Fatal(err)
+	}
+	if cost != 0 {
+		t.Errorf("costo de la venta %g tras reabrir la base, se esperaba 0", cost)
+	}
+}
+
+func TestInitDBLinksLegacyShipments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	legacy, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Así era la tabla cuando cada envío tenía una sola venta.
+	for _, query := range []string{
+		"CREATE TABLE shipments (id INTEGER PRIMARY KEY AUTOINCREMENT, sale_id INTEGER UNIQUE, client TEXT, address TEXT, zone TEXT, scheduled_date TEXT, courier TEXT, fee REAL DEFAULT 0, status TEXT, note TEXT DEFAULT '')",
+		"INSERT INTO shipments (id, sale_id, client) VALUES (1, 7, 'Cliente'), (2, 9, 'Cliente')",
+	} {
+		if _, err := legacy.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	legacy.Close()
+
+	InitDB(path)
+	defer DB.Close()
+	var links int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM shipment_sales WHERE (shipment_id, sale_id) IN (VALUES (1, 7), (2, 9))").Scan(&links); err != nil {
+		t.Fatal(err)
+	}
+	if links != 2 {
+		t.Errorf("%d envíos vinculados a su venta, se esperaban 2", links)
+	}
+	// Los envíos nuevos ya no escriben la columna, así que su UNIQUE no los
+	// restringe.
+	for range 2 {
+		if _, err := DB.Exec("INSERT INTO shipments (client) VALUES ('Cliente')"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
\ No newline at end of file
@@ -6,7 +6,6 @@ import (
 	"os"
 	"sales-system/internal/models"
 	"sales-system/internal/repository"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -35,7 +34,7 @@ func RegisterCashDelivery(cashRepo *repository.CashDeliveryRepo) {
 
 	fmt.Print("Monto: ")
 	amountStr, _ := reader.ReadString('\n')
-	amount, err := strconv.ParseFloat(strings.TrimSpace(amountStr), 64)
+	amount, err := parseAmount(amountStr)
 	if err != nil {
 		fmt.Println("Monto inválido. Operación cancelada.")
 		return
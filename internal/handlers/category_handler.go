@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strconv"
+	"strings"
+)
+
+// ShowCategories muestra el árbol de categorías con sus subcategorías indentadas.
+func ShowCategories(categoryRepo *repository.CategoryRepo) {
+	categories, err := categoryRepo.GetAllCategories()
+	if err != nil {
+		fmt.Println("Error al obtener categorías:", err)
+		return
+	}
+
+	fmt.Println("\n--- Categorías ---")
+	if len(categories) == 0 {
+		fmt.Println("No hay categorías registradas.")
+		return
+	}
+
+	known := make(map[int]bool, len(categories))
+	for _, c := range categories {
+		known[c.ID] = true
+	}
+	// Las categorías cuyo padre ya no existe se muestran como raíz.
+	for _, c := range categories {
+		if c.ParentID == 0 || !known[c.ParentID] {
+			printCategoryTree(categories, c, 0)
+		}
+	}
+}
+
+func printCategoryTree(categories []models.Category, c models.Category, depth int) {
+	fmt.Printf("%-5d %s%s\n", c.ID, strings.Repeat("    ", depth), c.Name)
+	for _, child := range categories {
+		if child.ParentID == c.ID && child.ID != c.ID {
+			printCategoryTree(categories, child, depth+1)
+		}
+	}
+}
+
+// RegisterCategory maneja el alta de una categoría o subcategoría.
+func RegisterCategory(categoryRepo *repository.CategoryRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Registrar Categoría ---")
+	ShowCategories(categoryRepo)
+
+	fmt.Print("\nNombre de la Categoría: ")
+	name, _ := reader.ReadString('\n')
+	name = strings.TrimSpace(name)
+	if name == "" {
+		fmt.Println("El nombre no puede estar vacío. Operación cancelada.")
+		return
+	}
+
+	fmt.Print("ID de la categoría padre (Enter = categoría principal): ")
+	parentStr, _ := reader.ReadString('\n')
+	parentID := 0
+	if strings.TrimSpace(parentStr) != "" {
+		id, err := strconv.Atoi(strings.TrimSpace(parentStr))
+		if err != nil {
+			fmt.Println("ID inválido. Operación cancelada.")
+			return
+		}
+		paths, err := categoryRepo.GetCategoryPaths()
+		if err != nil {
+			fmt.Println("Error al obtener las categorías:", err)
+			return
+		}
+		if _, ok := paths[id]; !ok {
+			fmt.Println("Categoría padre no encontrada. Operación cancelada.")
+			return
+		}
+		parentID = id
+	}
+
+	_, err := categoryRepo.CreateCategory(models.Category{Name: name, ParentID: parentID})
+	if err != nil {
+		fmt.Println("Error al registrar la categoría:", err)
+		return
+	}
+	fmt.Println("Categoría registrada con éxito.")
+}
+
+// DeleteCategory maneja la eliminación de una categoría.
+func DeleteCategory(categoryRepo *repository.CategoryRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Eliminar Categoría ---")
+	ShowCategories(categoryRepo)
+
+	fmt.Print("\nIngrese el ID de la categoría a eliminar: ")
+	idStr, _ := reader.ReadString('\n')
+	id, err := strconv.Atoi(strings.TrimSpace(idStr))
+	if err != nil {
+		fmt.Println("ID inválido.")
+		return
+	}
+
+	fmt.Print("Sus subcategorías pasarán a la categoría superior y sus productos quedarán sin categoría. ¿Continuar? (s/n): ")
+	confirmation, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(confirmation)) != "s" {
+		fmt.Println("Operación cancelada.")
+		return
+	}
+
+	if err := categoryRepo.DeleteCategory(id); err != nil {
+		fmt.Println("Error al eliminar la categoría:", err)
+		return
+	}
+	fmt.Println("Categoría eliminada con éxito.")
+}
+
+// readCategory muestra el árbol de categorías y pide al usuario que elija una.
+// Si deja el campo en blanco se conserva la categoría actual.
+func readCategory(reader *bufio.Reader, categoryRepo *repository.CategoryRepo, current int) int {
+	ShowCategories(categoryRepo)
+	fmt.Printf("ID de la Categoría (actual: %d, 0 = sin categoría): ", current)
+	idStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(idStr) == "" {
+		return current
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(idStr))
+	if err != nil {
+		fmt.Println("ID de categoría inválido. Se mantiene la actual.")
+		return current
+	}
+	return id
+}
\ No newline at end of file
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strings"
+	"time"
+)
+
+// baseCurrency devuelve el código de la moneda local, configurable con la
+// variable de entorno BASE_CURRENCY.
+func baseCurrency() string {
+	if code := strings.TrimSpace(os.Getenv("BASE_CURRENCY")); code != "" {
+		return strings.ToUpper(code)
+	}
+	return "ARS"
+}
+
+// parseRate interpreta una cotización aceptando coma o punto decimal.
+func parseRate(s string) (float64, error) {
+	rate, err := parseAmount(s)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("cotización inválida: %q", strings.TrimSpace(s))
+	}
+	return rate, nil
+}
+
+// parseRateDate acepta fechas DD/MM/YYYY o YYYY-MM-DD.
+func parseRateDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if date, err := time.Parse("02/01/2006", s); err == nil {
+		return date, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// RegisterExchangeRate carga a mano la cotización de una moneda para un día.
+func RegisterExchangeRate(rateRepo *repository.ExchangeRateRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Cargar Cotización ---")
+	fmt.Print("Fecha (DD/MM/YYYY, Enter = hoy): ")
+	dateStr, _ := reader.ReadString('\n')
+	date := time.Now()
+	if strings.TrimSpace(dateStr) != "" {
+		parsed, err := parseRateDate(dateStr)
+		if err != nil {
+			fmt.Println("Formato de fecha inválido. Operación cancelada.")
+			return
+		}
+		date = parsed
+	}
+
+	fmt.Print("Moneda (ej. USD): ")
+	currency, _ := reader.ReadString('\n')
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" || currency == baseCurrency() {
+		fmt.Println("Moneda inválida. Operación cancelada.")
+		return
+	}
+
+	fmt.Printf("Cotización (%s por 1 %s): ", baseCurrency(), currency)
+	rateStr, _ := reader.ReadString('\n')
+	rate, err := parseRate(rateStr)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if err := rateRepo.SetRates([]models.ExchangeRate{{Date: date, Currency: currency, Rate: rate}}); err != nil {
+		fmt.Println("Error al guardar la cotización:", err)
+		return
+	}
+	fmt.Printf("Cotización de %s del %s guardada: %.2f\n", currency, date.Format("02/01/2006"), rate)
+}
+
+// ImportExchangeRates carga cotizaciones desde un archivo CSV con las
+// columnas fecha, moneda y cotización. Acepta coma o punto y coma como
+// separador y una primera fila de encabezado.
+func ImportExchangeRates(rateRepo *repository.ExchangeRateRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Importar Cotizaciones (CSV) ---")
+	fmt.Print("Ruta del archivo: ")
+	path, _ := reader.ReadString('\n')
+	data, err := os.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		fmt.Println("Error al abrir el archivo:", err)
+		return
+	}
+
+	r := csv.NewReader(strings.NewReader(string(data)))
+	firstLine, _, _ := strings.Cut(string(data), "\n")
+	if strings.Contains(firstLine, ";") {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var rates []models.ExchangeRate
+	skipped := 0
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			fmt.Printf("Error al leer el archivo en la línea %d: %v\n", line, err)
+			return
+		}
+		if len(record) < 3 {
+			fmt.Printf("  Línea %d: faltan columnas, se omite.\n", line)
+			skipped++
+			continue
+		}
+		date, err := parseRateDate(record[0])
+		if err != nil {
+			// La primera fila puede ser el encabezado.
+			if line > 1 {
+				fmt.Printf("  Línea %d: fecha inválida %q, se omite.\n", line, record[0])
+				skipped++
+			}
+			continue
+		}
+		currency := strings.ToUpper(strings.TrimSpace(record[1]))
+		rate, err := parseRate(record[2])
+		if currency == "" || err != nil {
+			fmt.Printf("  Línea %d: moneda o cotización inválida, se omite.\n", line)
+			skipped++
+			continue
+		}
+		rates = append(rates, models.ExchangeRate{Date: date, Currency: currency, Rate: rate})
+	}
+
+	if len(rates) == 0 {
+		fmt.Println("El archivo no tiene cotizaciones válidas.")
+		return
+	}
+	if err := rateRepo.SetRates(rates); err != nil {
+		fmt.Println("Error al guardar las cotizaciones:", err)
+		return
+	}
+	fmt.Printf("Se importaron %d cotizaciones (%d líneas omitidas).\n", len(rates), skipped)
+}
+
+// ShowExchangeRates muestra las últimas cotizaciones cargadas.
+func ShowExchangeRates(rateRepo *repository.ExchangeRateRepo) {
+	rates, err := rateRepo.GetRecentRates(30)
+	if err != nil {
+		fmt.Println("Error al obtener las cotizaciones:", err)
+		return
+	}
+
+	fmt.Println("\n--- Cotizaciones ---")
+	if len(rates) == 0 {
+		fmt.Println("No hay cotizaciones cargadas.")
+		return
+	}
+	fmt.Printf("%-12s | %-8s | %-12s\n", "Fecha", "Moneda", "Cotización")
+	fmt.Println("--------------------------------------")
+	for _, r := range rates {
+		fmt.Printf("%-12s | %-8s | %12.2f\n", r.Date.Format("02/01/2006"), r.Currency, r.Rate)
+	}
+}
+
+// readSaleCurrency pregunta en qué moneda se cobra la venta. Devuelve nil si
+// se cobra en moneda local. Si no hay cotización cargada para la moneda, se
+// puede cargar la del día en el momento.
+func readSaleCurrency(reader *bufio.Reader, rateRepo *repository.ExchangeRateRepo, date time.Time) *models.ExchangeRate {
+	fmt.Printf("Moneda de cobro (Enter = %s, o código como USD): ", baseCurrency())
+	currency, _ := reader.ReadString('\n')
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" || currency == baseCurrency() {
+		return nil
+	}
+
+	rate, err := rateRepo.GetRate(currency, date)
+	if err == nil {
+		fmt.Printf("Cotización %s del %s: %.2f\n", rate.Currency, rate.Date.Format("02/01/2006"), rate.Rate)
+		return rate
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		fmt.Println("Error al obtener la cotización:", err)
+		return nil
+	}
+
+	fmt.Printf("No hay cotización de %s. Cotización del día (Enter = cobrar en %s): ", currency, baseCurrency())
+	rateStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(rateStr) == "" {
+		return nil
+	}
+	value, err := parseRate(rateStr)
+	if err != nil {
+		fmt.Println(err, "- se cobra en", baseCurrency())
+		return nil
+	}
+	newRate := models.ExchangeRate{Date: date, Currency: currency, Rate: value}
+	if err := rateRepo.SetRates([]models.ExchangeRate{newRate}); err != nil {
+		fmt.Println("Error al guardar la cotización:", err)
+		return nil
+	}
+	return &newRate
+}
\ No newline at end of file
@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RegisterCustomer da de alta un cliente con su límite de crédito y sus días
+// de pago.
+func RegisterCustomer(customerRepo *repository.CustomerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Registrar Cliente ---")
+	fmt.Print("Nombre (tal como se carga en las ventas): ")
+	name, _ := reader.ReadString('\n')
+	name = strings.TrimSpace(name)
+	if name == "" {
+		fmt.Println("El nombre es obligatorio.")
+		return
+	}
+	if _, err := customerRepo.GetCustomerByName(name); err == nil {
+		fmt.Println("Ya existe un cliente con ese nombre.")
+		return
+	}
+
+	customer := models.Customer{Name: name, PaymentTerms: 30}
+	readCustomerAccount(reader, &customer)
+
+	id, err := customerRepo.CreateCustomer(customer)
+	if err != nil {
+		fmt.Println("Error al registrar el cliente:", err)
+		return
+	}
+	fmt.Printf("Cliente registrado con éxito. ID: %d\n", id)
+}
+
+// EditCustomer modifica el teléfono, el límite de crédito y los días de pago
+// de un cliente.
+func EditCustomer(customerRepo *repository.CustomerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Editar Cliente ---")
+	customer := readCustomer(reader, customerRepo, "ID o nombre del cliente: ")
+	if customer == nil {
+		return
+	}
+
+	fmt.Println("Deje los campos en blanco para mantener el valor actual.")
+	readCustomerAccount(reader, customer)
+
+	if err := customerRepo.UpdateCustomer(*customer); err != nil {
+		fmt.Println("Error al actualizar el cliente:", err)
+		return
+	}
+	fmt.Println("Cliente actualizado con éxito.")
+}
+
+// readCustomerAccount pide los datos de cuenta del cliente. Un campo en
+// blanco conserva el valor actual.
+func readCustomerAccount(reader *bufio.Reader, c *models.Customer) {
+	fmt.Printf("Teléfono (actual: %s): ", c.Phone)
+	phone, _ := reader.ReadString('\n')
+	if strings.TrimSpace(phone) != "" {
+		c.Phone = strings.TrimSpace(phone)
+	}
+
+	fmt.Printf("Límite de crédito, 0 = solo contado (actual: %.2f): ", c.CreditLimit)
+	limitStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(limitStr) != "" {
+		if limit, err := parseAmount(limitStr); err == nil && limit >= 0 {
+			c.CreditLimit = limit
+		} else {
+			fmt.Println("Límite inválido, se mantiene el actual.")
+		}
+	}
+
+	fmt.Printf("Días de pago (actual: %d): ", c.PaymentTerms)
+	termsStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(termsStr) != "" {
+		if terms, err := strconv.Atoi(strings.TrimSpace(termsStr)); err == nil && terms >= 0 {
+			c.PaymentTerms = terms
+		} else {
+			fmt.Println("Días de pago inválidos, se mantienen los actuales.")
+		}
+	}
+}
+
+// ShowCustomers lista los clientes con su saldo, lo vencido y el crédito
+// disponible, y muestra las autorizaciones de crédito de uno.
+func ShowCustomers(customerRepo *repository.CustomerRepo, loyaltyRepo *repository.LoyaltyRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	customers, err := customerRepo.GetAllCustomers()
+	if err != nil {
+		fmt.Println("Error al obtener los clientes:", err)
+		return
+	}
+
+	now := time.Now()
+	fmt.Println("\n--- Clientes ---")
+	fmt.Printf("%-5s | %-20s | %-12s | %-10s | %-6s | %-10s | %-10s | %-10s | %-8s\n", "ID", "Nombre", "Teléfono", "Límite", "Días", "Saldo", "Vencido", "Disponible", "Puntos")
+	fmt.Println("----------------------------------------------------------------------------------------------------------------")
+	for _, c := range customers {
+		status, err := customerRepo.GetCreditStatus(c, now)
+		if err != nil {
+			fmt.Println("Error al calcular el saldo:", err)
+			return
+		}
+		points, err := loyaltyRepo.GetBalance(c.ID)
+		if err != nil {
+			fmt.Println("Error al obtener los puntos:", err)
+			return
+		}
+		fmt.Printf("%-5d | %-20s | %-12s | %-10.2f | %-6d | %-10.2f | %-10.2f | %-10.2f | %-8d\n", c.ID, c.Name, c.Phone, c.CreditLimit, c.PaymentTerms, status.Balance, status.Overdue, c.Available(status), points)
+	}
+
+	customer := readCustomer(reader, customerRepo, "\nID o nombre del cliente para ver las autorizaciones de crédito (o Enter para volver): ")
+	if customer == nil {
+		return
+	}
+	overrides, err := customerRepo.GetCreditOverrides(customer.Name)
+	if err != nil {
+		fmt.Println("Error al obtener las autorizaciones:", err)
+		return
+	}
+	if len(overrides) == 0 {
+		fmt.Println("El cliente no tiene ventas a crédito autorizadas por un supervisor.")
+		return
+	}
+	fmt.Printf("\n%-12s | %-15s | %-10s | %-40s\n", "Fecha", "Supervisor", "Importe", "Motivo")
+	fmt.Println("-----------------------------------------------------------------------------------")
+	for _, o := range overrides {
+		fmt.Printf("%-12s | %-15s | %-10.2f | %-40s\n", o.Date.Format("02/01/2006"), o.Supervisor, o.Amount, o.Reason)
+	}
+}
+
+// readCustomer pide un cliente por ID o por nombre. Devuelve nil si se deja
+// en blanco o no existe.
+func readCustomer(reader *bufio.Reader, customerRepo *repository.CustomerRepo, prompt string) *models.Customer {
+	fmt.Print(prompt)
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil
+	}
+	var customer *models.Customer
+	var err error
+	if id, convErr := strconv.Atoi(input); convErr == nil {
+		customer, err = customerRepo.GetCustomerByID(id)
+	} else {
+		customer, err = customerRepo.GetCustomerByName(input)
+	}
+	if err != nil {
+		fmt.Println("Cliente no encontrado.")
+		return nil
+	}
+	return customer
+}
+
+// checkCredit controla una venta pendiente de pago contra la cuenta del
+// cliente. Si supera el límite de crédito o el cliente tiene ventas vencidas,
+// la venta solo sigue con la autorización de un supervisor, que queda
+// registrada. Un cliente sin cuenta registrada tiene límite de crédito cero.
+func checkCredit(reader *bufio.Reader, customerRepo *repository.CustomerRepo, client string, amount float64, now time.Time) bool {
+	customer, err := customerRepo.GetCustomerByName(client)
+	if errors.Is(err, sql.ErrNoRows) {
+		customer, err = &models.Customer{Name: client}, nil
+	}
+	if err != nil {
+		fmt.Println("Error al obtener el cliente:", err)
+		return false
+	}
+	status, err := customerRepo.GetCreditStatus(*customer, now)
+	if err != nil {
+		fmt.Println("Error al calcular el saldo del cliente:", err)
+		return false
+	}
+
+	var reasons []string
+	if customer.ID == 0 {
+		reasons = append(reasons, "no tiene cuenta de cliente registrada")
+	} else if status.Balance+amount > customer.CreditLimit+0.005 {
+		reasons = append(reasons, fmt.Sprintf("supera el límite de crédito (saldo %.2f + %.2f > límite %.2f)", status.Balance, amount, customer.CreditLimit))
+	}
+	if status.OverdueCount > 0 {
+		reasons = append(reasons, fmt.Sprintf("tiene %d venta(s) vencida(s) por %.2f", status.OverdueCount, status.Overdue))
+	}
+	if len(reasons) == 0 {
+		return true
+	}
+
+	fmt.Printf("\nCrédito bloqueado para %s: %s.\n", customer.Name, strings.Join(reasons, "; "))
+	supervisor, ok := confirmSupervisor(reader, "vender a crédito a este cliente")
+	if !ok {
+		return false
+	}
+	override := models.CreditOverride{
+		Date:       now,
+		Client:     customer.Name,
+		Supervisor: supervisor,
+		Amount:     amount,
+		Reason:     strings.Join(reasons, "; "),
+	}
+	if err := customerRepo.RecordCreditOverride(override); err != nil {
+		fmt.Println("Error al registrar la autorización:", err)
+		return false
+	}
+	return true
+}
+
+// RegisterCustomerPayment registra un pago a cuenta o una nota de crédito de
+// un cliente. El importe cancela sus ventas pendientes más antiguas.
+func RegisterCustomerPayment(customerRepo *repository.CustomerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Registrar Pago o Nota de Crédito ---")
+	customer := readCustomer(reader, customerRepo, "ID o nombre del cliente: ")
+	if customer == nil {
+		return
+	}
+	now := time.Now()
+	status, err := customerRepo.GetCreditStatus(*customer, now)
+	if err != nil {
+		fmt.Println("Error al calcular el saldo del cliente:", err)
+		return
+	}
+	fmt.Printf("Saldo de %s: %.2f (vencido: %.2f)\n", customer.Name, status.Balance, status.Overdue)
+
+	fmt.Print("Tipo (1. Pago, 2. Nota de crédito): ")
+	typeStr, _ := reader.ReadString('\n')
+	payment := models.CustomerPayment{Date: now, Client: customer.Name, Type: models.PaymentTypePayment}
+	if strings.TrimSpace(typeStr) == "2" {
+		payment.Type = models.PaymentTypeCredit
+	}
+
+	fmt.Print("Importe: ")
+	amountStr, _ := reader.ReadString('\n')
+	payment.Amount, err = parseAmount(amountStr)
+	if err != nil || payment.Amount <= 0 {
+		fmt.Println("Importe inválido.")
+		return
+	}
+	fmt.Print("Detalle (opcional): ")
+	note, _ := reader.ReadString('\n')
+	payment.Note = strings.TrimSpace(note)
+
+	settled, err := customerRepo.RegisterPayment(payment)
+	if err != nil {
+		fmt.Println("Error al registrar el pago:", err)
+		return
+	}
+	fmt.Printf("%s registrado. Ventas canceladas: %d. Nuevo saldo: %.2f\n", payment.Type, settled, status.Balance-payment.Amount)
+}
+
+// ShowCustomerStatement genera el estado de cuenta de un cliente en PDF.
+func ShowCustomerStatement(customerRepo *repository.CustomerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Estado de Cuenta ---")
+	customer := readCustomer(reader, customerRepo, "ID o nombre del cliente: ")
+	if customer == nil {
+		return
+	}
+	start, end := readStatementPeriod(reader)
+
+	statement, err := customerRepo.GetStatement(*customer, start, end)
+	if err != nil {
+		fmt.Println("Error al armar el estado de cuenta:", err)
+		return
+	}
+
+	fmt.Printf("\nEstado de cuenta de %s del %s al %s\n", customer.Name, start.Format("02/01/2006"), end.Format("02/01/2006"))
+	fmt.Printf("%-12s | %-40s | %-10s | %-10s | %-10s\n", "Fecha", "Concepto", "Debe", "Haber", "Saldo")
+	fmt.Println("------------------------------------------------------------------------------------------------")
+	fmt.Printf("%-12s | %-40s | %-10s | %-10s | %-10.2f\n", start.Format("02/01/2006"), "Saldo anterior", "", "", statement.Opening)
+	for _, l := range statement.Lines {
+		fmt.Printf("%-12s | %-40s | %-10s | %-10s | %-10.2f\n", l.Date.Format("02/01/2006"), l.Description, statementAmount(l.Debit), statementAmount(l.Credit), l.Balance)
+	}
+	fmt.Printf("Saldo a pagar: %.2f\n", statement.Closing())
+
+	fmt.Print("\n¿Desea exportarlo a PDF? (s/n): ")
+	exportChoice, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(exportChoice)) != "s" {
+		return
+	}
+	fileName := statementFileName(*customer, end)
+	if err := ExportStatementToPDF(statement, fileName); err != nil {
+		fmt.Printf("Error al crear el archivo PDF: %v\n", err)
+		return
+	}
+	fmt.Println("Estado de cuenta exportado a", fileName)
+}
+
+// ExportAllStatements genera el PDF del estado de cuenta de cada cliente con
+// saldo distinto de cero.
+func ExportAllStatements(customerRepo *repository.CustomerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Estados de Cuenta de Clientes con Saldo ---")
+	start, end := readStatementPeriod(reader)
+
+	customers, err := customerRepo.GetAllCustomers()
+	if err != nil {
+		fmt.Println("Error al obtener los clientes:", err)
+		return
+	}
+	exported := 0
+	for _, c := range customers {
+		statement, err := customerRepo.GetStatement(c, start, end)
+		if err != nil {
+			fmt.Printf("Error al armar el estado de cuenta de %s: %v\n", c.Name, err)
+			continue
+		}
+		if statement.Closing() > -0.005 && statement.Closing() < 0.005 {
+			continue
+		}
+		fileName := statementFileName(c, end)
+		if err := ExportStatementToPDF(statement, fileName); err != nil {
+			fmt.Printf("Error al crear el PDF de %s: %v\n", c.Name, err)
+			continue
+		}
+		exported++
+		fmt.Printf("  %-20s saldo %10.2f -> %s\n", c.Name, statement.Closing(), fileName)
+	}
+	fmt.Printf("Estados de cuenta generados: %d\n", exported)
+}
+
+// readStatementPeriod pide el período del estado de cuenta; por defecto es
+// desde el primer día del mes hasta hoy.
+func readStatementPeriod(reader *bufio.Reader) (time.Time, time.Time) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	end := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
+
+	fmt.Printf("Desde (DD/MM/YYYY, Enter = %s): ", start.Format("02/01/2006"))
+	startStr, _ := reader.ReadString('\n')
+	if date, err := time.ParseInLocation("02/01/2006", strings.TrimSpace(startStr), now.Location()); err == nil {
+		start = date
+	}
+	fmt.Printf("Hasta (DD/MM/YYYY, Enter = %s): ", end.Format("02/01/2006"))
+	endStr, _ := reader.ReadString('\n')
+	if date, err := time.ParseInLocation("02/01/2006", strings.TrimSpace(endStr), now.Location()); err == nil {
+		end = date.Add(24*time.Hour - time.Second)
+	}
+	return start, end
+}
+
+func statementFileName(c models.Customer, end time.Time) string {
+	return fmt.Sprintf("Estado_de_Cuenta_%s_%s.pdf", strings.ReplaceAll(c.Name, " ", "_"), end.Format("2006-01-02"))
+}
+
+// statementAmount muestra un importe del estado de cuenta, o nada si es cero.
+func statementAmount(amount float64) string {
+	if amount == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", amount)
+}
+
+// ShowCustomerHistory muestra todas las compras de un cliente, registrado o
+// no, con sus totales.
+func ShowCustomerHistory(saleRepo *repository.SaleRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Historial de Compras del Cliente ---")
+	fmt.Print("Nombre del cliente: ")
+	client, _ := reader.ReadString('\n')
+	client = strings.TrimSpace(client)
+	if client == "" {
+		return
+	}
+
+	sales, err := saleRepo.GetSalesByClient(client)
+	if err != nil {
+		fmt.Println("Error al obtener las ventas:", err)
+		return
+	}
+	if len(sales) == 0 {
+		fmt.Println("El cliente no tiene compras registradas.")
+		return
+	}
+
+	fmt.Printf("%-5s | %-12s | %-20s | %-10s | %-10s | %-10s | %-10s\n", "ID", "Fecha", "Producto", "Cantidad", "Precio", "Total", "Estado")
+	fmt.Println("-------------------------------------------------------------------------------------------")
+	var total, pending float64
+	days := make(map[string]bool)
+	for _, s := range sales {
+		fmt.Printf("%-5d | %-12s | %-20s | %-10s | %-10.2f | %-10.2f | %-10s\n", s.ID, s.Date.Format("02/01/2006"), s.ProductName, formatQuantity(s.Quantity), s.Price, s.Total, s.Status)
+		if s.Status == models.StatusLayaway {
+			continue
+		}
+		total += s.Total
+		if s.Status == models.StatusPending {
+			pending += s.Total
+		}
+		days[s.Date.Format("2006-01-02")] = true
+	}
+
+	first, last := sales[len(sales)-1].Date, sales[0].Date
+	fmt.Printf("\nPrimera compra: %s | Última compra: %s (hace %d días)\n", first.Format("02/01/2006"), last.Format("02/01/2006"), int(time.Since(last).Hours()/24))
+	fmt.Printf("Compras: %d en %d día(s) | Total comprado: %.2f | Pendiente de pago: %.2f\n", len(sales), len(days), total, pending)
+	if len(days) > 0 {
+		fmt.Printf("Ticket promedio por día de compra: %.2f\n", total/float64(len(days)))
+	}
+}
\ No newline at end of file
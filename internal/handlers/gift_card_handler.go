@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SellGiftCard vende una tarjeta de regalo nueva o recarga una existente. La
+// operación se registra como una venta pagada, sin producto ni stock.
+func SellGiftCard(giftCardRepo *repository.GiftCardRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Vender o Recargar Tarjeta de Regalo ---")
+	fmt.Print("Código de la tarjeta a recargar (Enter = tarjeta nueva): ")
+	code, _ := reader.ReadString('\n')
+	code = strings.TrimSpace(code)
+
+	var card *models.GiftCard
+	if code != "" {
+		var err error
+		card, err = giftCardRepo.GetCardByCode(code)
+		if err != nil {
+			fmt.Println("Tarjeta no encontrada.")
+			return
+		}
+		fmt.Printf("%s %s - saldo actual: %.2f\n", card.Kind, card.Code, card.Balance)
+	}
+
+	fmt.Print("Importe: ")
+	amountStr, _ := reader.ReadString('\n')
+	amount, err := parseAmount(amountStr)
+	if err != nil || amount <= 0 {
+		fmt.Println("Importe inválido. Operación cancelada.")
+		return
+	}
+
+	client := ""
+	if card != nil {
+		client = card.Client
+	} else {
+		fmt.Print("Cliente (opcional): ")
+		client, _ = reader.ReadString('\n')
+		client = strings.TrimSpace(client)
+	}
+
+	now := time.Now()
+	name := "Recarga tarjeta de regalo"
+	if card == nil {
+		card, name = &models.GiftCard{Kind: models.GiftCardKindGift, Client: client, IssuedAt: now}, "Tarjeta de regalo"
+	}
+
+	sale := models.Sale{
+		Date:        now,
+		Client:      client,
+		Quantity:    1,
+		Price:       amount,
+		Total:       amount,
+		Status:      models.StatusPaid,
+		ProductName: name,
+	}
+	card, saleID, err := giftCardRepo.SellCard(*card, sale)
+	if err != nil {
+		fmt.Println("Error al registrar la venta:", err)
+		return
+	}
+
+	fmt.Printf("Venta registrada con éxito. ID: %d\n", saleID)
+	fmt.Printf("Tarjeta: %s | Saldo: %.2f\n", card.Code, card.Balance)
+}
+
+// ShowGiftCardBalance consulta el saldo y los movimientos de una tarjeta.
+func ShowGiftCardBalance(giftCardRepo *repository.GiftCardRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Consultar Saldo ---")
+	fmt.Print("Código de la tarjeta: ")
+	code, _ := reader.ReadString('\n')
+	card, err := giftCardRepo.GetCardByCode(code)
+	if err != nil {
+		fmt.Println("Tarjeta no encontrada.")
+		return
+	}
+	movements, err := giftCardRepo.GetMovements(card.ID)
+	if err != nil {
+		fmt.Println("Error al obtener los movimientos:", err)
+		return
+	}
+
+	fmt.Printf("\n%s %s\n", card.Kind, card.Code)
+	if card.Client != "" {
+		fmt.Printf("Cliente: %s\n", card.Client)
+	}
+	fmt.Printf("Emitida: %s\n", card.IssuedAt.Format("02/01/2006"))
+	fmt.Printf("\n%-12s | %-12s | %-7s | %-10s | %-10s\n", "Fecha", "Tipo", "Venta", "Importe", "Saldo")
+	fmt.Println("-------------------------------------------------------------")
+	balance := 0.0
+	for _, m := range movements {
+		balance += m.Amount
+		sale := ""
+		if m.SaleID > 0 {
+			sale = fmt.Sprintf("#%d", m.SaleID)
+		}
+		fmt.Printf("%-12s | %-12s | %-7s | %10.2f | %10.2f\n", m.Date.Format("02/01/2006"), m.Type, sale, m.Amount, balance)
+	}
+	fmt.Printf("Saldo disponible: %.2f\n", card.Balance)
+}
+
+// ReturnSale registra la devolución de parte o toda una venta: reingresa el
+// stock, anula la parte de los puntos ganados y reintegra la de los canjeados
+// y, si la venta estaba pagada, entrega el importe como crédito en tienda. En
+// una venta pendiente la devolución solo baja la deuda.
+func ReturnSale(saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Devolución ---")
+	fmt.Print("ID de la venta: ")
+	idStr, _ := reader.ReadString('\n')
+	id, err := strconv.Atoi(strings.TrimSpace(idStr))
+	if err != nil {
+		fmt.Println("ID inválido.")
+		return
+	}
+	sale, err := saleRepo.GetSaleByID(id)
+	if err != nil {
+		fmt.Println("Venta no encontrada.")
+		return
+	}
+	if sale.IsGiftCard() {
+		fmt.Println("Las ventas de tarjetas de regalo no admiten devolución.")
+		return
+	}
+	if sale.Status == models.StatusLayaway {
+		fmt.Println("La venta pertenece a un apartado; se gestiona desde Apartados y Cuotas.")
+		return
+	}
+	if sale.Quantity <= 0 {
+		fmt.Println("La venta ya no tiene unidades para devolver.")
+		return
+	}
+
+	product, err := productRepo.GetProductByID(sale.ProductID)
+	if err != nil {
+		product = &models.Product{ID: sale.ProductID, Decimals: 3}
+	}
+	fmt.Printf("%s - vendido: %s x %.2f = %.2f (%s)\n", sale.ProductName, formatQuantity(sale.Quantity), sale.Price, sale.Total, sale.Status)
+	fmt.Print("Cantidad a devolver: ")
+	quantityStr, _ := reader.ReadString('\n')
+	quantity, err := parseQuantity(quantityStr)
+	quantity = product.RoundQuantity(quantity)
+	if err != nil || quantity <= 0 || quantity > sale.Quantity {
+		fmt.Println("Cantidad inválida. Operación cancelada.")
+		return
+	}
+
+	// Se devuelve lo efectivamente cobrado por unidad, con los descuentos aplicados.
+	refund := sale.Total / sale.Quantity * quantity
+	unitCost := sale.Cost / sale.Quantity
+	oldTotal := sale.Total
+	sale.Quantity -= quantity
+	sale.Total -= refund
+	sale.RescaleCurrency(oldTotal)
+	sale.Cost = unitCost * sale.Quantity
+	card, reversed, restored, err := saleRepo.ReturnSale(*sale, quantity, unitCost, refund, time.Now())
+	if err != nil {
+		fmt.Println("Error al registrar la devolución:", err)
+		return
+	}
+	fmt.Printf("Devolución registrada: %s unidades por %.2f.\n", formatQuantity(quantity), refund)
+	printPointsReversal(reversed, restored)
+
+	if card == nil {
+		fmt.Println("La venta estaba pendiente de pago; se descontó de la deuda del cliente.")
+		return
+	}
+	fmt.Printf("Crédito en tienda emitido: %s por %.2f\n", card.Code, refund)
+}
+
+// ShowGiftCardLiability lista las tarjetas con saldo a una fecha. La suma es
+// lo que el negocio adeuda en tarjetas de regalo y crédito en tienda.
+func ShowGiftCardLiability(giftCardRepo *repository.GiftCardRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Saldos de Tarjetas Pendientes de Uso ---")
+	fmt.Print("Fecha de corte (DD/MM/YYYY, Enter = hoy): ")
+	dateStr, _ := reader.ReadString('\n')
+	at := time.Now()
+	if strings.TrimSpace(dateStr) != "" {
+		parsed, err := time.Parse("02/01/2006", strings.TrimSpace(dateStr))
+		if err != nil {
+			fmt.Println("Formato de fecha inválido.")
+			return
+		}
+		at = parsed
+	}
+
+	cards, err := giftCardRepo.GetOutstanding(at)
+	if err != nil {
+		fmt.Println("Error al obtener los saldos:", err)
+		return
+	}
+	fmt.Printf("\nSaldos al %s\n", at.Format("02/01/2006"))
+	if len(cards) == 0 {
+		fmt.Println("No hay tarjetas con saldo a esa fecha.")
+		return
+	}
+
+	fmt.Printf("%-20s | %-18s | %-20s | %-12s | %-10s\n", "Código", "Tipo", "Cliente", "Emitida", "Saldo")
+	fmt.Println("------------------------------------------------------------------------------------------")
+	byKind := make(map[string]float64)
+	total := 0.0
+	for _, c := range cards {
+		fmt.Printf("%-20s | %-18s | %-20s | %-12s | %10.2f\n", c.Code, c.Kind, c.Client, c.IssuedAt.Format("02/01/2006"), c.Balance)
+		byKind[c.Kind] += c.Balance
+		total += c.Balance
+	}
+	fmt.Println()
+	for _, kind := range []string{models.GiftCardKindGift, models.GiftCardKindCredit} {
+		fmt.Printf("%s: %.2f\n", kind, byKind[kind])
+	}
+	fmt.Printf("Total adeudado: %.2f (%d tarjetas)\n", total, len(cards))
+}
+
+// readGiftCardPayments pide las tarjetas de regalo o créditos en tienda con
+// los que se paga una venta. Cada tarjeta cubre lo que alcance su saldo y el
+// resto se cobra con otra tarjeta o en efectivo. Devuelve los pagos y el
+// total cubierto.
+func readGiftCardPayments(reader *bufio.Reader, giftCardRepo *repository.GiftCardRepo, total float64) ([]models.GiftCardPayment, float64) {
+	var payments []models.GiftCardPayment
+	covered := 0.0
+	for total-covered > 0.005 {
+		fmt.Print("Código de tarjeta de regalo o crédito para pagar (Enter = no usar): ")
+		code, _ := reader.ReadString('\n')
+		if strings.TrimSpace(code) == "" {
+			break
+		}
+		card, err := giftCardRepo.GetCardByCode(code)
+		if err != nil {
+			fmt.Println("  Tarjeta no encontrada.")
+			continue
+		}
+		used := false
+		for _, p := range payments {
+			used = used || p.CardID == card.ID
+		}
+		if used {
+			fmt.Println("  La tarjeta ya se aplicó a esta venta.")
+			continue
+		}
+		if card.Balance <= 0.005 {
+			fmt.Println("  La tarjeta no tiene saldo.")
+			continue
+		}
+		amount := min(card.Balance, total-covered)
+		payments = append(payments, models.GiftCardPayment{CardID: card.ID, Code: card.Code, Amount: amount})
+		covered += amount
+		fmt.Printf("  Se aplican %.2f de %s (saldo restante %.2f). Resta cobrar: %.2f\n", amount, card.Code, card.Balance-amount, total-covered)
+	}
+	return payments, covered
+}
+
+// splitGiftCardPayments toma de payments, en orden, los pagos que cubren
+// amount, partiendo el último si hace falta. Devuelve esos pagos y lo que
+// queda sin usar de los demás.
+func splitGiftCardPayments(payments []models.GiftCardPayment, amount float64) (taken, rest []models.GiftCardPayment) {
+	for _, p := range payments {
+		use := min(p.Amount, amount)
+		if use > 0.005 {
+			taken = append(taken, models.GiftCardPayment{CardID: p.CardID, Code: p.Code, Amount: use})
+			amount -= use
+		}
+		if p.Amount-use > 0.005 {
+			p.Amount -= use
+			rest = append(rest, p)
+		}
+	}
+	return taken, rest
+}
+
+// printGiftCardPayments informa lo cobrado con cada tarjeta.
+func printGiftCardPayments(payments []models.GiftCardPayment) {
+	for _, p := range payments {
+		fmt.Printf("Pagado con %s: %.2f\n", p.Code, p.Amount)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"reflect"
+	"sales-system/internal/models"
+	"testing"
+)
+
+func TestSplitGiftCardPayments(t *testing.T) {
+	pay := func(cardID int, code string, amount float64) models.GiftCardPayment {
+		return models.GiftCardPayment{CardID: cardID, Code: code, Amount: amount}
+	}
+	payments := []models.GiftCardPayment{pay(1, "A", 30), pay(2, "B", 50)}
+	tests := []struct {
+		name      string
+		amount    float64
+		wantTaken []models.GiftCardPayment
+		wantRest  []models.GiftCardPayment
+	}{
+		{"nada", 0, nil, payments},
+		{"parte de la primera", 20, []models.GiftCardPayment{pay(1, "A", 20)}, []models.GiftCardPayment{pay(1, "A", 10), pay(2, "B", 50)}},
+		{"la primera entera", 30, []models.GiftCardPayment{pay(1, "A", 30)}, []models.GiftCardPayment{pay(2, "B", 50)}},
+		{"las dos", 45, []models.GiftCardPayment{pay(1, "A", 30), pay(2, "B", 15)}, []models.GiftCardPayment{pay(2, "B", 35)}},
+		{"más que el total", 100, payments, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taken, rest := splitGiftCardPayments(payments, tt.amount)
+			if !reflect.DeepEqual(taken, tt.wantTaken) {
+				t.Errorf("pagos tomados %v, se esperaba %v", taken, tt.wantTaken)
+			}
+			if !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("pagos restantes %v, se esperaba %v", rest, tt.wantRest)
+			}
+		})
+	}
+}
\ No newline at end of file
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strings"
+)
+
+// DefineKit carga la lista de materiales de un kit (por ejemplo una canasta
+// de regalo): qué productos lleva y cuánto de cada uno. Los componentes
+// ingresados reemplazan a los anteriores.
+func DefineKit(productRepo *repository.ProductRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Definir Kit ---")
+	PreviewProducts(productRepo)
+
+	kit, err := readProduct(reader, productRepo, "#ID, SKU o código del producto kit: ")
+	if err != nil {
+		fmt.Println("Producto no encontrado.")
+		return
+	}
+	if hasVariants(productRepo, kit.ID) {
+		fmt.Println("Un producto con variantes no puede ser un kit.")
+		return
+	}
+
+	if kit.IsKit {
+		current, err := productRepo.GetKitComponents(kit.ID)
+		if err != nil {
+			fmt.Println("Error al obtener los componentes:", err)
+			return
+		}
+		fmt.Printf("\nComponentes actuales de %s:\n", kit.Name)
+		printKitComponents(productRepo, current)
+	}
+
+	fmt.Println("\nIngrese los componentes del kit. Enter en el producto para terminar.")
+	var components []models.KitComponent
+	seen := make(map[int]bool)
+	for {
+		fmt.Print("#ID, SKU o código del componente: ")
+		code, _ := reader.ReadString('\n')
+		if strings.TrimSpace(code) == "" {
+			break
+		}
+		component, err := productRepo.GetProductByCode(strings.TrimSpace(code))
+		if err != nil {
+			fmt.Println("Producto no encontrado.")
+			continue
+		}
+		if component.ID == kit.ID {
+			fmt.Println("Un kit no puede ser componente de sí mismo.")
+			continue
+		}
+		if component.IsKit {
+			fmt.Println("Un kit no puede ser componente de otro kit.")
+			continue
+		}
+		if seen[component.ID] {
+			fmt.Println("El producto ya es componente del kit.")
+			continue
+		}
+
+		fmt.Printf("Cantidad de %s por kit (%s): ", component.Name, component.Unit)
+		quantityStr, _ := reader.ReadString('\n')
+		quantity, err := parseQuantity(quantityStr)
+		if err != nil || component.RoundQuantity(quantity) <= 0 {
+			fmt.Println("Cantidad inválida.")
+			continue
+		}
+		seen[component.ID] = true
+		components = append(components, models.KitComponent{KitID: kit.ID, ComponentID: component.ID, Quantity: component.RoundQuantity(quantity)})
+	}
+
+	if len(components) == 0 {
+		if !kit.IsKit {
+			fmt.Println("No se ingresaron componentes. Operación cancelada.")
+			return
+		}
+		fmt.Print("No se ingresaron componentes. ¿Desea que el producto deje de ser un kit? (s/n): ")
+		confirm, _ := reader.ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(confirm)) != "s" {
+			fmt.Println("Operación cancelada.")
+			return
+		}
+	}
+
+	if err := productRepo.SetKitComponents(kit.ID, components); err != nil {
+		fmt.Println("Error al guardar el kit:", err)
+		return
+	}
+	if len(components) == 0 {
+		fmt.Printf("%s ya no es un kit.\n", kit.Name)
+		return
+	}
+	fmt.Printf("Kit %s guardado con %d componente(s).\n", kit.Name, len(components))
+}
+
+// ShowKits lista los kits con sus componentes y cuántos se pueden armar con
+// el stock disponible en cada ubicación.
+func ShowKits(productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo) {
+	fmt.Println("\n--- Kits ---")
+	products, err := productRepo.GetAllProducts()
+	if err != nil {
+		fmt.Println("Error al obtener productos:", err)
+		return
+	}
+	locations, err := locationRepo.GetAllLocations()
+	if err != nil {
+		fmt.Println("Error al obtener las ubicaciones:", err)
+		return
+	}
+
+	kits := 0
+	for _, p := range products {
+		if !p.IsKit {
+			continue
+		}
+		kits++
+
+		components, err := productRepo.GetKitComponents(p.ID)
+		if err != nil {
+			fmt.Println("Error al obtener los componentes:", err)
+			return
+		}
+		fmt.Printf("\n#%d %s - Precio: %.2f\n", p.ID, p.Name, p.Price)
+		printKitComponents(productRepo, components)
+
+		fmt.Print("Disponibles:")
+		for _, l := range locations {
+			available, err := productRepo.GetKitAvailability(p.ID, l.ID)
+			if err != nil {
+				fmt.Println("\nError al calcular la disponibilidad:", err)
+				return
+			}
+			fmt.Printf("  %s: %s", l.Name, formatQuantity(available))
+		}
+		fmt.Println()
+	}
+	if kits == 0 {
+		fmt.Println("No hay kits definidos.")
+	}
+}
+
+func printKitComponents(productRepo *repository.ProductRepo, components []models.KitComponent) {
+	fmt.Printf("  %-5s | %-25s | %-12s | %-12s | %-10s\n", "ID", "Componente", "Por kit", "Stock", "Precio")
+	for _, c := range components {
+		component, err := productRepo.GetProductByID(c.ComponentID)
+		if err != nil {
+			fmt.Printf("  %-5d | %-25s | %-12s | %-12s | %-10s\n", c.ComponentID, "N/A", formatQuantity(c.Quantity), "-", "-")
+			continue
+		}
+		fmt.Printf("  %-5d | %-25s | %-12s | %-12s | %-10.2f\n", c.ComponentID, component.Name, component.FormatQuantity(c.Quantity), component.FormatQuantity(component.Quantity), component.Price)
+	}
+}
+
+// checkKitStock avisa si el stock de los componentes en la ubicación no
+// alcanza para los kits que se quieren vender y pide confirmación para
+// continuar. Devuelve true si el producto no es un kit.
+func checkKitStock(reader *bufio.Reader, productRepo *repository.ProductRepo, product models.Product, locationID int, quantity float64) bool {
+	if !product.IsKit {
+		return true
+	}
+	available, err := productRepo.GetKitAvailability(product.ID, locationID)
+	if err != nil {
+		fmt.Println("Error al calcular la disponibilidad del kit:", err)
+		return false
+	}
+	if quantity <= available {
+		return true
+	}
+	fmt.Printf("Atención: con el stock de los componentes solo se pueden armar %s kit(s) de %s. ¿Continuar? (s/n): ", formatQuantity(available), product.Name)
+	confirm, _ := reader.ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(confirm)) == "s"
+}
\ No newline at end of file
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"sales-system/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// labelLayout describe una hoja de etiquetas autoadhesivas A4. Las medidas
+// están en milímetros.
+type labelLayout struct {
+	Name        string
+	Columns     int
+	Rows        int
+	LabelWidth  float64
+	LabelHeight float64
+	MarginLeft  float64
+	MarginTop   float64
+	GapX        float64
+	GapY        float64
+}
+
+// labelLayouts son los formatos de hoja más comunes.
+var labelLayouts = []labelLayout{
+	{Name: "A4 3x8 (70 x 37 mm)", Columns: 3, Rows: 8, LabelWidth: 70, LabelHeight: 37, MarginTop: 0.5},
+	{Name: "A4 3x7 (70 x 42,3 mm)", Columns: 3, Rows: 7, LabelWidth: 70, LabelHeight: 42.3, MarginTop: 0.45},
+	{Name: "A4 2x7 (99,1 x 38,1 mm)", Columns: 2, Rows: 7, LabelWidth: 99.1, LabelHeight: 38.1, MarginLeft: 4.65, MarginTop: 15.15, GapX: 2.5},
+	{Name: "A4 4x10 (48,5 x 25,4 mm)", Columns: 4, Rows: 10, LabelWidth: 48.5, LabelHeight: 25.4, MarginLeft: 8, MarginTop: 21.5},
+}
+
+// PrintLabels genera un PDF de etiquetas con nombre, precio y código de barras
+// para los productos elegidos o para los de una orden de compra recibida.
+func PrintLabels(productRepo *repository.ProductRepo, purchaseRepo *repository.PurchaseOrderRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Imprimir Etiquetas ---")
+	fmt.Println("1. Productos seleccionados")
+	fmt.Println("2. Todos los productos")
+	fmt.Println("3. Productos de una orden de compra")
+	fmt.Print("Seleccione una opción: ")
+	choiceStr, _ := reader.ReadString('\n')
+
+	// Cada elemento es una etiqueta a imprimir; un producto se repite tantas veces como copias.
+	var labels []models.Product
+
+	switch strings.TrimSpace(choiceStr) {
+	case "1":
+		var selected []models.Product
+		fmt.Println("Ingrese o escanee los productos (#ID, SKU o código). Línea vacía para terminar.")
+		for {
+			fmt.Print("> ")
+			code, _ := reader.ReadString('\n')
+			if strings.TrimSpace(code) == "" {
+				break
+			}
+			product, err := productRepo.GetProductByCode(code)
+			if err != nil {
+				fmt.Println("  Producto no encontrado.")
+				continue
+			}
+			fmt.Println(" ", product.Name)
+			selected = append(selected, *product)
+		}
+		copies := readCopies(reader)
+		for _, p := range selected {
+			labels = appendCopies(labels, p, copies)
+		}
+	case "2":
+		products, err := productRepo.GetAllProducts()
+		if err != nil {
+			fmt.Println("Error al obtener productos:", err)
+			return
+		}
+		copies := readCopies(reader)
+		for _, p := range products {
+			labels = appendCopies(labels, p, copies)
+		}
+	case "3":
+		fmt.Print("ID de la orden de compra: ")
+		idStr, _ := reader.ReadString('\n')
+		id, err := strconv.Atoi(strings.TrimSpace(idStr))
+		if err != nil {
+			fmt.Println("ID inválido.")
+			return
+		}
+		order, err := purchaseRepo.GetPurchaseOrderByID(id)
+		if err != nil {
+			fmt.Println("Orden de compra no encontrada.")
+			return
+		}
+
+		fmt.Print("Copias por producto (Enter = 1, 'r' = una por unidad recibida): ")
+		copiesStr, _ := reader.ReadString('\n')
+		copiesStr = strings.ToLower(strings.TrimSpace(copiesStr))
+		copies, err := strconv.Atoi(copiesStr)
+		if err != nil || copies <= 0 {
+			copies = 1
+		}
+		for _, item := range order.Items {
+			product, err := productRepo.GetProductByID(item.ProductID)
+			if err != nil {
+				continue
+			}
+			n := copies
+			if copiesStr == "r" {
+				n = int(math.Ceil(item.Quantity))
+			}
+			labels = appendCopies(labels, *product, n)
+		}
+	default:
+		fmt.Println("Opción no válida.")
+		return
+	}
+
+	if len(labels) == 0 {
+		fmt.Println("No hay etiquetas para imprimir.")
+		return
+	}
+
+	layout := readLabelLayout(reader)
+
+	fileName := "Etiquetas_" + time.Now().Format("2006-01-02_150405") + ".pdf"
+	if err := ExportLabelsToPDF(fileName, layout, labels); err != nil {
+		fmt.Println("Error al crear el archivo PDF:", err)
+		return
+	}
+	fmt.Printf("%d etiqueta(s) exportadas a %s\n", len(labels), fileName)
+}
+
+func appendCopies(labels []models.Product, p models.Product, copies int) []models.Product {
+	for i := 0; i < copies; i++ {
+		labels = append(labels, p)
+	}
+	return labels
+}
+
+func readCopies(reader *bufio.Reader) int {
+	fmt.Print("Copias por producto (Enter = 1): ")
+	copiesStr, _ := reader.ReadString('\n')
+	copies, err := strconv.Atoi(strings.TrimSpace(copiesStr))
+	if err != nil || copies <= 0 {
+		return 1
+	}
+	return copies
+}
+
+// readLabelLayout pide el formato de hoja; la opción personalizada reparte la
+// hoja A4 en la cantidad de columnas y filas indicada.
+func readLabelLayout(reader *bufio.Reader) labelLayout {
+	fmt.Println("\nFormato de hoja:")
+	for i, l := range labelLayouts {
+		fmt.Printf("%d. %s\n", i+1, l.Name)
+	}
+	fmt.Printf("%d. Personalizado\n", len(labelLayouts)+1)
+	fmt.Print("Seleccione un formato (Enter = 1): ")
+	choiceStr, _ := reader.ReadString('\n')
+	choice, err := strconv.Atoi(strings.TrimSpace(choiceStr))
+	if err != nil || choice < 1 || choice > len(labelLayouts)+1 {
+		return labelLayouts[0]
+	}
+	if choice <= len(labelLayouts) {
+		return labelLayouts[choice-1]
+	}
+
+	fmt.Print("Columnas: ")
+	columnsStr, _ := reader.ReadString('\n')
+	columns, err := strconv.Atoi(strings.TrimSpace(columnsStr))
+	if err != nil || columns <= 0 {
+		columns = 3
+	}
+	fmt.Print("Filas: ")
+	rowsStr, _ := reader.ReadString('\n')
+	rows, err := strconv.Atoi(strings.TrimSpace(rowsStr))
+	if err != nil || rows <= 0 {
+		rows = 8
+	}
+
+	const margin = 5.0
+	return labelLayout{
+		Name:        fmt.Sprintf("A4 %dx%d", columns, rows),
+		Columns:     columns,
+		Rows:        rows,
+		LabelWidth:  (210 - 2*margin) / float64(columns),
+		LabelHeight: (297 - 2*margin) / float64(rows),
+		MarginLeft:  margin,
+		MarginTop:   margin,
+	}
+}
+
+// ExportLabelsToPDF dibuja las etiquetas en hojas A4 según el formato indicado.
+// Se usa EAN-13 cuando el producto tiene código de barras y Code 128 con el
+// SKU (o el #ID, para que el escáner lo encuentre) en caso contrario.
+func ExportLabelsToPDF(fileName string, layout labelLayout, labels []models.Product) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetAutoPageBreak(false, 0)
+
+	perPage := layout.Columns * layout.Rows
+	for i, p := range labels {
+		if i%perPage == 0 {
+			pdf.AddPage()
+		}
+		pos := i % perPage
+		x := layout.MarginLeft + float64(pos%layout.Columns)*(layout.LabelWidth+layout.GapX)
+		y := layout.MarginTop + float64(pos/layout.Columns)*(layout.LabelHeight+layout.GapY)
+
+		padding := 2.0
+		innerWidth := layout.LabelWidth - 2*padding
+
+		// Nombre y precio
+		pdf.SetFont("Arial", "B", 9)
+		pdf.SetXY(x+padding, y+padding)
+		pdf.CellFormat(innerWidth, 4, tr(fitText(pdf, p.Name, innerWidth)), "", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "B", 12)
+		pdf.SetXY(x+padding, y+padding+4)
+		pdf.CellFormat(innerWidth, 5, fmt.Sprintf("$ %.2f", p.Price), "", 0, "L", false, 0, "")
+
+		// Código de barras
+		text := p.Barcode
+		quietLeft, quietRight := utils.EAN13QuietLeft, utils.EAN13QuietRight
+		modules, err := utils.EAN13Modules(p.Barcode)
+		if err != nil {
+			text = p.SKU
+			if text == "" {
+				text = "#" + strconv.Itoa(p.ID)
+			}
+			quietLeft, quietRight = utils.Code128QuietZone, utils.Code128QuietZone
+			modules, err = utils.Code128Modules(text)
+			if err != nil {
+				return err
+			}
+		}
+		barTop := y + padding + 10
+		barHeight := layout.LabelHeight - 2*padding - 14
+		if barHeight < 4 {
+			barHeight = 4
+		}
+		drawBarcode(pdf, modules, quietLeft, quietRight, x+padding, barTop, innerWidth, barHeight)
+
+		pdf.SetFont("Arial", "", 7)
+		pdf.SetXY(x+padding, barTop+barHeight)
+		pdf.CellFormat(innerWidth, 3.5, tr(text), "", 0, "C", false, 0, "")
+	}
+
+	return pdf.OutputFileAndClose(fileName)
+}
+
+// drawBarcode dibuja los módulos como barras verticales dentro del rectángulo
+// indicado, dejando en blanco la zona de silencio de cada lado (quietLeft y
+// quietRight, en módulos). Las barras contiguas se dibujan como un único
+// rectángulo.
+func drawBarcode(pdf *gofpdf.Fpdf, modules []bool, quietLeft, quietRight int, x, y, width, height float64) {
+	pdf.SetFillColor(0, 0, 0)
+	moduleWidth := width / float64(quietLeft+len(modules)+quietRight)
+	x += float64(quietLeft) * moduleWidth
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		start := i
+		for i < len(modules) && modules[i] {
+			i++
+		}
+		pdf.Rect(x+float64(start)*moduleWidth, y, float64(i-start)*moduleWidth, height, "F")
+	}
+}
+
+// fitText recorta el texto para que entre en el ancho indicado con la fuente actual.
+func fitText(pdf *gofpdf.Fpdf, text string, width float64) string {
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
\ No newline at end of file
@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultGraceDays devuelve los días de gracia por defecto para cancelar un
+// apartado con cuotas impagas. Se configura con la variable de entorno
+// LAYAWAY_GRACE_DAYS; si no está definida son 7 días.
+func defaultGraceDays() int {
+	if days, err := strconv.Atoi(os.Getenv("LAYAWAY_GRACE_DAYS")); err == nil && days >= 0 {
+		return days
+	}
+	return 7
+}
+
+// RegisterLayaway registra un apartado: la venta queda con estado Apartado y
+// descuenta el stock para reservarlo, el cliente deja una seña y el saldo se
+// reparte en cuotas.
+func RegisterLayaway(layawayRepo *repository.LayawayRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo, lotRepo *repository.LotRepo, sellerRepo *repository.SellerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Nuevo Apartado ---")
+	PreviewProducts(productRepo)
+
+	fmt.Print("Nombre del Cliente: ")
+	client, _ := reader.ReadString('\n')
+	client = strings.TrimSpace(client)
+	if client == "" {
+		fmt.Println("El cliente es obligatorio en un apartado.")
+		return
+	}
+
+	sellerID := readSaleSeller(reader, sellerRepo)
+	locationID := readLocation(reader, locationRepo, "Ubicación de venta")
+
+	product, err := readProduct(reader, productRepo, "#ID, SKU o código de barras del Producto: ")
+	if err != nil {
+		fmt.Println("Error: Producto no encontrado. Verifique el ID o código.")
+		return
+	}
+	if product.Archived {
+		fmt.Println("Error: El producto está archivado y ya no se vende.")
+		return
+	}
+	if hasVariants(productRepo, product.ID) {
+		fmt.Println("Error: El producto tiene variantes. Indique la variante a vender (talle, color, etc.).")
+		return
+	}
+
+	fmt.Printf("Cantidad (%s): ", product.Unit)
+	quantityStr, _ := reader.ReadString('\n')
+	quantity, err := parseQuantity(quantityStr)
+	if err != nil || product.RoundQuantity(quantity) <= 0 {
+		fmt.Println("Cantidad inválida. Operación cancelada.")
+		return
+	}
+	quantity = product.RoundQuantity(quantity)
+
+	now := time.Now()
+	if !checkKitStock(reader, productRepo, *product, locationID, quantity) {
+		fmt.Println("Operación cancelada.")
+		return
+	}
+	includeExpired, ok := checkExpiredLots(reader, lotRepo, *product, locationID, quantity, now)
+	if !ok {
+		fmt.Println("Operación cancelada.")
+		return
+	}
+
+	total := quantity * product.Price
+	fmt.Printf("Total: %.2f\n", total)
+
+	fmt.Print("Seña: ")
+	depositStr, _ := reader.ReadString('\n')
+	deposit, err := parseAmount(depositStr)
+	if err != nil || deposit <= 0 || deposit >= total {
+		fmt.Println("La seña debe ser mayor a cero y menor al total. Operación cancelada.")
+		return
+	}
+
+	fmt.Print("Cantidad de cuotas (Enter = 4): ")
+	countStr, _ := reader.ReadString('\n')
+	count, err := strconv.Atoi(strings.TrimSpace(countStr))
+	if err != nil || count <= 0 {
+		count = 4
+	}
+	fmt.Print("Días entre cuotas (Enter = 7): ")
+	intervalStr, _ := reader.ReadString('\n')
+	interval, err := strconv.Atoi(strings.TrimSpace(intervalStr))
+	if err != nil || interval <= 0 {
+		interval = 7
+	}
+	graceDays := defaultGraceDays()
+	fmt.Printf("Días de gracia antes de cancelar por falta de pago (Enter = %d): ", graceDays)
+	graceStr, _ := reader.ReadString('\n')
+	if days, err := strconv.Atoi(strings.TrimSpace(graceStr)); err == nil && days >= 0 {
+		graceDays = days
+	}
+
+	layaway := models.Layaway{
+		Date:         now,
+		Client:       client,
+		ProductName:  product.Name,
+		Total:        total,
+		GraceDays:    graceDays,
+		Installments: models.InstallmentSchedule(total-deposit, count, interval, now),
+	}
+	fmt.Printf("\nSeña: %.2f - Saldo: %.2f\n", deposit, total-deposit)
+	printInstallments(layaway.Installments, now)
+
+	fmt.Print("¿Confirmar el apartado? (s/n): ")
+	confirm, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(confirm)) != "s" {
+		fmt.Println("Operación cancelada.")
+		return
+	}
+
+	sale := models.Sale{
+		Date:      now,
+		Client:    client,
+		ProductID: product.ID,
+		Quantity:  quantity,
+		Price:     product.Price,
+		Total:     total,
+		Status:    models.StatusLayaway,
+
+		LocationID: locationID,
+		SellerID:   sellerID,
+	}
+	saleID, id, err := layawayRepo.CreateLayaway(sale, includeExpired, layaway, deposit)
+	if err != nil {
+		fmt.Println("Error al registrar el apartado:", err)
+		return
+	}
+	fmt.Printf("Apartado registrado con éxito. ID: %d (venta #%d)\n", id, saleID)
+}
+
+// ShowLayaways lista los apartados y muestra el detalle de cuotas de uno.
+func ShowLayaways(layawayRepo *repository.LayawayRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	layaways, err := layawayRepo.GetAllLayaways()
+	if err != nil {
+		fmt.Println("Error al obtener los apartados:", err)
+		return
+	}
+
+	fmt.Println("\n--- Apartados ---")
+	printLayaways(layaways)
+
+	layaway := readLayaway(reader, layawayRepo, "\nIngrese el ID del apartado para ver las cuotas (o presione Enter para volver): ")
+	if layaway == nil {
+		return
+	}
+	printLayaway(*layaway)
+}
+
+// RegisterLayawayPayment registra un pago de un apartado activo. El pago se
+// imputa a las cuotas en orden de vencimiento.
+func RegisterLayawayPayment(layawayRepo *repository.LayawayRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Registrar Pago de Apartado ---")
+	layaways, err := layawayRepo.GetAllLayaways()
+	if err != nil {
+		fmt.Println("Error al obtener los apartados:", err)
+		return
+	}
+	var active []models.Layaway
+	for _, l := range layaways {
+		if l.Status == models.LayawayActive {
+			active = append(active, l)
+		}
+	}
+	if len(active) == 0 {
+		fmt.Println("No hay apartados activos.")
+		return
+	}
+	printLayaways(active)
+
+	layaway := readLayaway(reader, layawayRepo, "\nID del apartado: ")
+	if layaway == nil {
+		return
+	}
+	printLayaway(*layaway)
+	if layaway.Status != models.LayawayActive {
+		fmt.Printf("El apartado está %s.\n", strings.ToLower(layaway.Status))
+		return
+	}
+
+	fmt.Printf("Importe (Enter = próxima cuota): ")
+	amountStr, _ := reader.ReadString('\n')
+	var amount float64
+	if strings.TrimSpace(amountStr) == "" {
+		for _, i := range layaway.Installments {
+			if i.Pending() > 0.005 {
+				amount = i.Pending()
+				break
+			}
+		}
+	} else {
+		amount, err = parseAmount(amountStr)
+		if err != nil {
+			fmt.Println("Importe inválido.")
+			return
+		}
+	}
+
+	completed, err := layawayRepo.RegisterPayment(layaway.ID, amount, time.Now())
+	if err != nil {
+		fmt.Println("Error al registrar el pago:", err)
+		return
+	}
+	fmt.Printf("Pago de %.2f registrado. Saldo: %.2f\n", amount, layaway.Balance()-amount)
+	if completed {
+		fmt.Printf("Apartado completado: la venta #%d quedó pagada y se puede entregar la mercadería.\n", layaway.SaleID)
+	}
+}
+
+// ShowInstallmentReport muestra las cuotas vencidas y las que vencen en los
+// próximos días, con el total a cobrar.
+func ShowInstallmentReport(layawayRepo *repository.LayawayRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Print("Días hacia adelante a incluir (Enter = 7): ")
+	daysStr, _ := reader.ReadString('\n')
+	days, err := strconv.Atoi(strings.TrimSpace(daysStr))
+	if err != nil || days < 0 {
+		days = 7
+	}
+
+	now := time.Now()
+	due, err := layawayRepo.GetDueInstallments(now.AddDate(0, 0, days))
+	if err != nil {
+		fmt.Println("Error al obtener las cuotas:", err)
+		return
+	}
+
+	fmt.Println("\n--- Cuotas Vencidas y Próximas ---")
+	if len(due) == 0 {
+		fmt.Println("No hay cuotas a cobrar en el período.")
+		return
+	}
+	fmt.Printf("%-8s | %-5s | %-20s | %-20s | %-12s | %-10s | %-20s\n", "Apartado", "Cuota", "Cliente", "Producto", "Vence", "Saldo", "Estado")
+	fmt.Println("-------------------------------------------------------------------------------------------------------------")
+	var overdueTotal, upcomingTotal float64
+	for _, d := range due {
+		state := "Próxima"
+		if d.IsOverdue(now) {
+			overdueTotal += d.Pending()
+			state = fmt.Sprintf("Vencida (cancela %s)", d.DueDate.AddDate(0, 0, d.GraceDays+1).Format("02/01"))
+		} else {
+			upcomingTotal += d.Pending()
+		}
+		fmt.Printf("%-8d | %-5d | %-20s | %-20s | %-12s | %-10.2f | %-20s\n", d.LayawayID, d.Number, d.Client, d.ProductName, d.DueDate.Format("02/01/2006"), d.Pending(), state)
+	}
+	fmt.Printf("\nTotal vencido: %.2f\n", overdueTotal)
+	fmt.Printf("Total a vencer en %d días: %.2f\n", days, upcomingTotal)
+}
+
+// CancelOverdueLayaways cancela los apartados con cuotas impagas después de
+// los días de gracia: la mercadería vuelve al stock, se elimina la venta
+// reservada y lo pagado se entrega como crédito en tienda.
+func CancelOverdueLayaways(layawayRepo *repository.LayawayRepo, now time.Time) {
+	layaways, err := layawayRepo.GetLayawaysToCancel(now)
+	if err != nil {
+		fmt.Println("Error al revisar los apartados vencidos:", err)
+		return
+	}
+	for _, l := range layaways {
+		credit, err := layawayRepo.CancelLayaway(l.ID, now)
+		if errors.Is(err, repository.ErrLayawayNotActive) {
+			continue
+		}
+		if err != nil {
+			fmt.Printf("Error al cancelar el apartado #%d: %v\n", l.ID, err)
+			continue
+		}
+		fmt.Printf("Apartado #%d de %s cancelado por falta de pago; %s volvió al stock.\n", l.ID, l.Client, l.ProductName)
+		if credit != nil {
+			fmt.Printf("  Lo pagado (%.2f) quedó como crédito en tienda: %s\n", credit.Balance, credit.Code)
+		}
+	}
+}
+
+// readLayaway pide el ID de un apartado y lo busca. Devuelve nil si se deja
+// en blanco o no existe.
+func readLayaway(reader *bufio.Reader, layawayRepo *repository.LayawayRepo, prompt string) *models.Layaway {
+	fmt.Print(prompt)
+	idStr, _ := reader.ReadString('\n')
+	idStr = strings.TrimSpace(idStr)
+	if idStr == "" {
+		return nil
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		fmt.Println("ID inválido.")
+		return nil
+	}
+	layaway, err := layawayRepo.GetLayawayByID(id)
+	if err != nil {
+		fmt.Println("Apartado no encontrado.")
+		return nil
+	}
+	return layaway
+}
+
+func printLayaways(layaways []models.Layaway) {
+	fmt.Printf("%-5s | %-12s | %-20s | %-20s | %-10s | %-10s | %-10s\n", "ID", "Fecha", "Cliente", "Producto", "Total", "Saldo", "Estado")
+	fmt.Println("---------------------------------------------------------------------------------------------------")
+	for _, l := range layaways {
+		fmt.Printf("%-5d | %-12s | %-20s | %-20s | %-10.2f | %-10.2f | %-10s\n", l.ID, l.Date.Format("02/01/2006"), l.Client, l.ProductName, l.Total, l.Balance(), l.Status)
+	}
+}
+
+func printLayaway(l models.Layaway) {
+	fmt.Printf("\n--- Apartado #%d (%s) ---\n", l.ID, l.Status)
+	fmt.Printf("Cliente: %s | Producto: %s | Venta #%d\n", l.Client, l.ProductName, l.SaleID)
+	fmt.Printf("Total: %.2f | Pagado: %.2f | Saldo: %.2f | Días de gracia: %d\n", l.Total, l.Paid, l.Balance(), l.GraceDays)
+	if l.Status == models.LayawayCancelled {
+		fmt.Printf("Cancelado el %s\n", l.CancelledDate.Format("02/01/2006"))
+	}
+	printInstallments(l.Installments, time.Now())
+}
+
+func printInstallments(installments []models.Installment, now time.Time) {
+	fmt.Printf("%-5s | %-12s | %-10s | %-10s | %-10s\n", "Cuota", "Vence", "Importe", "Pagado", "Estado")
+	fmt.Println("------------------------------------------------------------")
+	for _, i := range installments {
+		state := "Pendiente"
+		switch {
+		case i.Pending() <= 0.005:
+			state = "Pagada"
+		case i.IsOverdue(now):
+			state = "Vencida"
+		}
+		fmt.Printf("%-5d | %-12s | %-10.2f | %-10.2f | %-10s\n", i.Number, i.DueDate.Format("02/01/2006"), i.Amount, i.Paid, state)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RegisterLocation da de alta un local o depósito.
+func RegisterLocation(locationRepo *repository.LocationRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Registrar Ubicación ---")
+	fmt.Print("Nombre (ej. Local, Depósito): ")
+	name, _ := reader.ReadString('\n')
+	name = strings.TrimSpace(name)
+	if name == "" {
+		fmt.Println("El nombre no puede estar vacío.")
+		return
+	}
+
+	id, err := locationRepo.CreateLocation(name)
+	if err != nil {
+		fmt.Println("Error al registrar la ubicación:", err)
+		return
+	}
+	fmt.Printf("Ubicación registrada con éxito. ID: %d\n", id)
+}
+
+// ShowStockByLocation muestra el stock de cada producto en cada ubicación y
+// lo que está en tránsito entre ellas.
+func ShowStockByLocation(productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo) {
+	locations, err := locationRepo.GetAllLocations()
+	if err != nil {
+		fmt.Println("Error al obtener las ubicaciones:", err)
+		return
+	}
+	stock, err := locationRepo.GetStockByLocation()
+	if err != nil {
+		fmt.Println("Error al obtener el stock por ubicación:", err)
+		return
+	}
+	inTransit, err := locationRepo.GetInTransitByProduct()
+	if err != nil {
+		fmt.Println("Error al obtener las transferencias:", err)
+		return
+	}
+	products, err := productRepo.GetAllProducts()
+	if err != nil {
+		fmt.Println("Error al obtener productos:", err)
+		return
+	}
+
+	fmt.Println("\n--- Stock por Ubicación ---")
+	fmt.Printf("%-5s | %-20s", "ID", "Producto")
+	for _, l := range locations {
+		fmt.Printf(" | %12.12s", l.Name)
+	}
+	fmt.Printf(" | %12s | %12s\n", "En tránsito", "Total")
+	fmt.Println(strings.Repeat("-", 28+15*(len(locations)+2)))
+
+	for _, p := range products {
+		fmt.Printf("%-5d | %-20s", p.ID, p.Name)
+		for _, l := range locations {
+			fmt.Printf(" | %12s", models.FormatQuantity(stock[p.ID][l.ID], p.Decimals))
+		}
+		fmt.Printf(" | %12s | %12s\n", models.FormatQuantity(inTransit[p.ID], p.Decimals), p.FormatQuantity(p.Quantity))
+	}
+}
+
+// CreateTransfer registra una transferencia de mercadería entre dos
+// ubicaciones. El stock sale del origen al confirmar y queda en tránsito
+// hasta que se recibe en el destino.
+func CreateTransfer(locationRepo *repository.LocationRepo, productRepo *repository.ProductRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Nueva Transferencia ---")
+	locations, err := locationRepo.GetAllLocations()
+	if err != nil {
+		fmt.Println("Error al obtener las ubicaciones:", err)
+		return
+	}
+	if len(locations) < 2 {
+		fmt.Println("Se necesitan al menos dos ubicaciones para transferir mercadería.")
+		return
+	}
+
+	fromID := readLocation(reader, locationRepo, "Ubicación de origen")
+	toID := readLocation(reader, locationRepo, "Ubicación de destino")
+	if fromID == toID {
+		fmt.Println("El origen y el destino deben ser distintos.")
+		return
+	}
+
+	transfer := models.Transfer{Date: time.Now(), FromID: fromID, ToID: toID}
+	fmt.Println("Ingrese el #ID, SKU o código de barras de cada producto. Línea vacía para terminar.")
+	for {
+		fmt.Print("> ")
+		code, _ := reader.ReadString('\n')
+		if strings.TrimSpace(code) == "" {
+			break
+		}
+		product, err := productRepo.GetProductByCode(code)
+		if err != nil {
+			fmt.Printf("  Código '%s' no encontrado.\n", strings.TrimSpace(code))
+			continue
+		}
+		if product.IsKit {
+			fmt.Println("  Los kits no tienen stock propio; transfiera sus componentes.")
+			continue
+		}
+		available, err := locationRepo.GetLocationQuantity(product.ID, fromID)
+		if err != nil {
+			fmt.Println("  Error al obtener el stock:", err)
+			continue
+		}
+
+		fmt.Printf("  %s - disponible en origen: %s. Cantidad: ", product.Name, product.FormatQuantity(available))
+		quantityStr, _ := reader.ReadString('\n')
+		quantity, err := parseQuantity(quantityStr)
+		quantity = product.RoundQuantity(quantity)
+		if err != nil || quantity <= 0 {
+			fmt.Println("  Cantidad inválida, se ignora el producto.")
+			continue
+		}
+		if quantity > available {
+			fmt.Println("  No hay stock suficiente en el origen, se ignora el producto.")
+			continue
+		}
+		transfer.Items = append(transfer.Items, models.TransferItem{ProductID: product.ID, Quantity: quantity})
+	}
+
+	if len(transfer.Items) == 0 {
+		fmt.Println("No se cargaron productos. Transferencia cancelada.")
+		return
+	}
+
+	id, err := locationRepo.CreateTransfer(transfer)
+	if err != nil {
+		fmt.Println("Error al registrar la transferencia:", err)
+		return
+	}
+	fmt.Printf("Transferencia #%d registrada. La mercadería queda en tránsito hasta su recepción.\n", id)
+}
+
+// ShowTransfers lista las transferencias y permite recibir las que están en tránsito.
+func ShowTransfers(locationRepo *repository.LocationRepo, productRepo *repository.ProductRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	transfers, err := locationRepo.GetAllTransfers()
+	if err != nil {
+		fmt.Println("Error al obtener las transferencias:", err)
+		return
+	}
+	names, err := locationRepo.GetLocationNames()
+	if err != nil {
+		fmt.Println("Error al obtener las ubicaciones:", err)
+		return
+	}
+
+	fmt.Println("\n--- Transferencias ---")
+	fmt.Printf("%-5s | %-12s | %-15s | %-15s | %-12s\n", "ID", "Fecha", "Origen", "Destino", "Estado")
+	fmt.Println("---------------------------------------------------------------------")
+	for _, t := range transfers {
+		fmt.Printf("%-5d | %-12s | %-15s | %-15s | %-12s\n", t.ID, t.Date.Format("02/01/2006"), names[t.FromID], names[t.ToID], t.Status)
+	}
+
+	fmt.Print("\nIngrese el ID de la transferencia para ver detalles (o presione Enter para volver): ")
+	idStr, _ := reader.ReadString('\n')
+	idStr = strings.TrimSpace(idStr)
+	if idStr == "" {
+		return
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		fmt.Println("ID inválido.")
+		return
+	}
+
+	transfer, err := locationRepo.GetTransferByID(id)
+	if err != nil {
+		fmt.Println("Transferencia no encontrada.")
+		return
+	}
+
+	fmt.Printf("\n--- Transferencia #%d: %s -> %s (%s) ---\n", transfer.ID, names[transfer.FromID], names[transfer.ToID], transfer.Status)
+	fmt.Printf("%-20s | %-12s | %s\n", "Producto", "Cantidad", "Lote")
+	fmt.Println("------------------------------------------------------")
+	for _, item := range transfer.Items {
+		product, _ := productRepo.GetProductByID(item.ProductID)
+		productName, quantity := "N/A", formatQuantity(item.Quantity)
+		if product != nil {
+			productName, quantity = product.Name, product.FormatQuantity(item.Quantity)
+		}
+		lot := ""
+		if item.LotNumber != "" {
+			lot = fmt.Sprintf("%s (vence %s)", item.LotNumber, item.LotExpiry.Format("02/01/2006"))
+		}
+		fmt.Printf("%-20s | %-12s | %s\n", productName, quantity, lot)
+	}
+
+	if transfer.Status != models.TransferInTransit {
+		fmt.Printf("Recibida el %s.\n", transfer.ReceivedDate.Format("02/01/2006"))
+		return
+	}
+
+	fmt.Printf("\n¿Confirma la recepción en %s? (s/n): ", names[transfer.ToID])
+	confirmation, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(confirmation)) != "s" {
+		return
+	}
+	if err := locationRepo.ReceiveTransfer(transfer.ID, time.Now()); err != nil {
+		fmt.Println("Error al recibir la transferencia:", err)
+		return
+	}
+	fmt.Println("Transferencia recibida con éxito.")
+}
+
+// readLocation pide una ubicación. Si hay una sola se usa sin preguntar; por
+// defecto se propone la ubicación principal.
+func readLocation(reader *bufio.Reader, locationRepo *repository.LocationRepo, label string) int {
+	locations, err := locationRepo.GetAllLocations()
+	if err != nil || len(locations) <= 1 {
+		return models.DefaultLocationID
+	}
+
+	options := make([]string, 0, len(locations))
+	for _, l := range locations {
+		options = append(options, fmt.Sprintf("%d. %s", l.ID, l.Name))
+	}
+	fmt.Printf("%s (%s; Enter = %d): ", label, strings.Join(options, ", "), models.DefaultLocationID)
+	idStr, _ := reader.ReadString('\n')
+	id, err := strconv.Atoi(strings.TrimSpace(idStr))
+	if err != nil {
+		return models.DefaultLocationID
+	}
+	for _, l := range locations {
+		if l.ID == id {
+			return id
+		}
+	}
+	fmt.Println("Ubicación no encontrada. Se usa la ubicación principal.")
+	return models.DefaultLocationID
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ShowExpiringLots lista los lotes con stock que vencen dentro de los
+// próximos días, incluidos los ya vencidos.
+func ShowExpiringLots(lotRepo *repository.LotRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Lotes por Vencer ---")
+	fmt.Print("Días a considerar (Enter = 30): ")
+	daysStr, _ := reader.ReadString('\n')
+	days, err := strconv.Atoi(strings.TrimSpace(daysStr))
+	if err != nil || days < 0 {
+		days = 30
+	}
+
+	now := time.Now()
+	lots, err := lotRepo.GetExpiringLots(now.AddDate(0, 0, days))
+	if err != nil {
+		fmt.Println("Error al obtener los lotes:", err)
+		return
+	}
+	if len(lots) == 0 {
+		fmt.Printf("No hay lotes que venzan en los próximos %d días.\n", days)
+		return
+	}
+	names, err := locationRepo.GetLocationNames()
+	if err != nil {
+		fmt.Println("Error al obtener las ubicaciones:", err)
+		return
+	}
+
+	fmt.Printf("%-20s | %-12s | %-12s | %-15s | %-12s | %s\n", "Producto", "Lote", "Vence", "Ubicación", "Cantidad", "Días")
+	fmt.Println("------------------------------------------------------------------------------------------------")
+	for _, l := range lots {
+		product, _ := productRepo.GetProductByID(l.ProductID)
+		productName, quantity := "N/A", formatQuantity(l.Quantity)
+		if product != nil {
+			productName, quantity = product.Name, product.FormatQuantity(l.Quantity)
+		}
+		remaining := strconv.Itoa(l.DaysToExpiry(now))
+		if l.IsExpired(now) {
+			remaining = "VENCIDO"
+		}
+		fmt.Printf("%-20s | %-12s | %-12s | %-15s | %-12s | %s\n", productName, l.Number, l.Expiry.Format("02/01/2006"), names[l.LocationID], quantity, remaining)
+	}
+}
+
+// readLot pide el número de lote y el vencimiento de una entrada de
+// mercadería. Los productos sin control de lotes no preguntan nada.
+func readLot(reader *bufio.Reader, product models.Product) models.Lot {
+	if !product.TrackLots {
+		return models.Lot{}
+	}
+
+	fmt.Printf("Número de lote de %s (Enter = sin lote): ", product.Name)
+	number, _ := reader.ReadString('\n')
+	number = strings.TrimSpace(number)
+	if number == "" {
+		return models.Lot{}
+	}
+
+	for {
+		fmt.Print("Vencimiento (DD/MM/YYYY): ")
+		expiryStr, _ := reader.ReadString('\n')
+		expiry, err := time.Parse("02/01/2006", strings.TrimSpace(expiryStr))
+		if err == nil {
+			return models.Lot{Number: number, Expiry: expiry}
+		}
+		fmt.Println("Fecha inválida.")
+	}
+}
+
+// checkExpiredLots revisa si para vender la cantidad habría que tomar lotes
+// vencidos. El stock sin lote se considera vigente. Según la variable de entorno LOTES_VENCIDOS la venta se bloquea
+// ("bloquear") o se avisa y se pide confirmación (por defecto). Devuelve si
+// se pueden consumir lotes vencidos y si la venta puede seguir.
+func checkExpiredLots(reader *bufio.Reader, lotRepo *repository.LotRepo, product models.Product, locationID int, quantity float64, now time.Time) (includeExpired, ok bool) {
+	if !product.TrackLots {
+		return false, true
+	}
+	lots, err := lotRepo.GetLots(product.ID, locationID)
+	if err != nil {
+		fmt.Println("Error al obtener los lotes:", err)
+		return false, false
+	}
+
+	valid, err := lotRepo.GetStockWithoutLot(product.ID, locationID)
+	if err != nil {
+		fmt.Println("Error al obtener el stock sin lote:", err)
+		return false, false
+	}
+	var expired float64
+	var expiredNumbers []string
+	for _, l := range lots {
+		if l.IsExpired(now) {
+			expired += l.Quantity
+			expiredNumbers = append(expiredNumbers, l.Number)
+		} else {
+			valid += l.Quantity
+		}
+	}
+	if expired == 0 || quantity <= valid {
+		return false, true
+	}
+
+	fmt.Printf("Atención: %s tiene solo %s sin vencer; los lotes %s están vencidos.\n", product.Name, product.FormatQuantity(valid), strings.Join(expiredNumbers, ", "))
+	if strings.ToLower(os.Getenv("LOTES_VENCIDOS")) == "bloquear" {
+		fmt.Println("No se permite vender lotes vencidos.")
+		return false, false
+	}
+	fmt.Print("¿Desea vender igualmente mercadería de lotes vencidos? (s/n): ")
+	confirmation, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(confirmation)) != "s" {
+		return false, false
+	}
+	return true, true
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigureLoyalty permite cambiar las reglas del programa de puntos.
+func ConfigureLoyalty(loyaltyRepo *repository.LoyaltyRepo, categoryRepo *repository.CategoryRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	settings, err := loyaltyRepo.GetSettings()
+	if err != nil {
+		fmt.Println("Error al obtener las reglas del programa:", err)
+		return
+	}
+
+	fmt.Println("\n--- Programa de Puntos ---")
+	printLoyaltySettings(settings)
+	fmt.Println()
+
+	fmt.Printf("Importe de compra por punto, 0 = programa desactivado (actual: %.2f): ", settings.AmountPerPoint)
+	if value, ok := readOptionalFloat(reader); ok {
+		settings.AmountPerPoint = value
+	}
+	fmt.Printf("Valor de cada punto al canjearlo (actual: %.2f): ", settings.PointValue)
+	if value, ok := readOptionalFloat(reader); ok {
+		settings.PointValue = value
+	}
+	fmt.Printf("Días de vigencia de los puntos, 0 = no vencen (actual: %d): ", settings.ExpiryDays)
+	daysStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(daysStr) != "" {
+		if days, err := strconv.Atoi(strings.TrimSpace(daysStr)); err == nil && days >= 0 {
+			settings.ExpiryDays = days
+		} else {
+			fmt.Println("Días inválidos, se mantienen los actuales.")
+		}
+	}
+
+	ShowCategories(categoryRepo)
+	fmt.Print("IDs de categorías que no suman puntos, separados por coma (Enter = sin cambios, - = ninguna): ")
+	idsStr, _ := reader.ReadString('\n')
+	idsStr = strings.TrimSpace(idsStr)
+	switch idsStr {
+	case "":
+	case "-":
+		settings.ExcludedCategories = nil
+	default:
+		var ids []int
+		for _, part := range strings.Split(idsStr, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || id <= 0 {
+				fmt.Printf("ID de categoría inválido: %s. Operación cancelada.\n", strings.TrimSpace(part))
+				return
+			}
+			ids = append(ids, id)
+		}
+		settings.ExcludedCategories = ids
+	}
+
+	fmt.Println("Multiplicador de puntos por día de la semana (Enter = sin cambios, 1 = sin bonificación):")
+	for day, name := range models.WeekdayNames {
+		weekday := time.Weekday(day)
+		current := 1.0
+		if multiplier, ok := settings.BonusDays[weekday]; ok {
+			current = multiplier
+		}
+		fmt.Printf("  %s (actual: x%s): ", name, strconv.FormatFloat(current, 'f', -1, 64))
+		value, ok := readOptionalFloat(reader)
+		if !ok {
+			continue
+		}
+		if value == 1 || value == 0 {
+			delete(settings.BonusDays, weekday)
+		} else {
+			settings.BonusDays[weekday] = value
+		}
+	}
+
+	if err := loyaltyRepo.SaveSettings(settings); err != nil {
+		fmt.Println("Error al guardar las reglas:", err)
+		return
+	}
+	fmt.Println("Reglas del programa de puntos actualizadas.")
+}
+
+// readOptionalFloat lee un número no negativo. Devuelve false si la línea
+// está vacía o el valor es inválido, en cuyo caso se conserva el actual.
+func readOptionalFloat(reader *bufio.Reader) (float64, bool) {
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return 0, false
+	}
+	value, err := parseAmount(input)
+	if err != nil || value < 0 {
+		fmt.Println("Valor inválido, se mantiene el actual.")
+		return 0, false
+	}
+	return value, true
+}
+
+func printLoyaltySettings(s models.LoyaltySettings) {
+	if !s.Enabled() {
+		fmt.Println("El programa está desactivado.")
+		return
+	}
+	fmt.Printf("1 punto cada %.2f de compra. Cada punto vale %.2f.\n", s.AmountPerPoint, s.PointValue)
+	if s.ExpiryDays > 0 {
+		fmt.Printf("Los puntos vencen a los %d días.\n", s.ExpiryDays)
+	} else {
+		fmt.Println("Los puntos no vencen.")
+	}
+	if len(s.ExcludedCategories) > 0 {
+		ids := make([]string, len(s.ExcludedCategories))
+		for i, id := range s.ExcludedCategories {
+			ids[i] = strconv.Itoa(id)
+		}
+		fmt.Printf("Categorías excluidas: %s\n", strings.Join(ids, ", "))
+	}
+	for day, name := range models.WeekdayNames {
+		if multiplier, ok := s.BonusDays[time.Weekday(day)]; ok {
+			fmt.Printf("%s: puntos x%s\n", name, strconv.FormatFloat(multiplier, 'f', -1, 64))
+		}
+	}
+}
+
+// ShowLoyaltyLedger muestra los movimientos de puntos de un cliente.
+func ShowLoyaltyLedger(customerRepo *repository.CustomerRepo, loyaltyRepo *repository.LoyaltyRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Movimientos de Puntos ---")
+	customer := readCustomer(reader, customerRepo, "ID o nombre del cliente: ")
+	if customer == nil {
+		return
+	}
+	entries, err := loyaltyRepo.GetLedger(customer.ID)
+	if err != nil {
+		fmt.Println("Error al obtener los movimientos:", err)
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Println("El cliente no tiene movimientos de puntos.")
+		return
+	}
+
+	fmt.Printf("\n%-12s | %-12s | %-7s | %-8s | %-8s | %-12s\n", "Fecha", "Tipo", "Venta", "Puntos", "Saldo", "Vence")
+	fmt.Println("-----------------------------------------------------------------------------")
+	balance := 0
+	for _, e := range entries {
+		balance += e.Points
+		sale, expires := "", ""
+		if e.SaleID > 0 {
+			sale = fmt.Sprintf("#%d", e.SaleID)
+		}
+		if !e.ExpiresAt.IsZero() {
+			expires = e.ExpiresAt.Format("02/01/2006")
+			if e.Remaining == 0 {
+				expires += " (sin saldo)"
+			}
+		}
+		fmt.Printf("%-12s | %-12s | %-7s | %8d | %8d | %-12s\n", e.Date.Format("02/01/2006"), e.Type, sale, e.Points, balance, expires)
+	}
+	fmt.Printf("Saldo de puntos: %d\n", balance)
+}
+
+// loyaltyAccount devuelve el cliente registrado y las reglas del programa si
+// la venta puede sumar o canjear puntos. Los clientes sin cuenta no
+// participan del programa.
+func loyaltyAccount(customerRepo *repository.CustomerRepo, loyaltyRepo *repository.LoyaltyRepo, client string) (*models.Customer, models.LoyaltySettings, bool) {
+	settings, err := loyaltyRepo.GetSettings()
+	if err != nil || !settings.Enabled() {
+		return nil, settings, false
+	}
+	customer, err := customerRepo.GetCustomerByName(client)
+	if err != nil {
+		return nil, settings, false
+	}
+	return customer, settings, true
+}
+
+// readRedemption ofrece canjear puntos del cliente como descuento sobre el
+// total de la venta. Devuelve los puntos a canjear y el descuento que
+// representan, que nunca supera el total.
+func readRedemption(reader *bufio.Reader, loyaltyRepo *repository.LoyaltyRepo, customer *models.Customer, settings models.LoyaltySettings, total float64) (int, float64) {
+	balance, err := loyaltyRepo.GetBalance(customer.ID)
+	if err != nil || balance <= 0 || settings.PointValue <= 0 {
+		return 0, 0
+	}
+	maxPoints := min(balance, int(total/settings.PointValue))
+	if maxPoints <= 0 {
+		return 0, 0
+	}
+	fmt.Printf("%s tiene %d puntos (%.2f). Puntos a canjear, máximo %d (Enter = ninguno): ", customer.Name, balance, settings.RedeemValue(balance), maxPoints)
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return 0, 0
+	}
+	points, err := strconv.Atoi(input)
+	if err != nil || points < 0 || points > maxPoints {
+		fmt.Println("Cantidad de puntos inválida, no se canjean puntos.")
+		return 0, 0
+	}
+	return points, settings.RedeemValue(points)
+}
+
+// earnPoints acredita los puntos que gana una venta pagada, salvo que el
+// producto pertenezca a una categoría excluida. Devuelve los puntos ganados.
+func earnPoints(loyaltyRepo *repository.LoyaltyRepo, settings models.LoyaltySettings, customer *models.Customer, product models.Product, saleID int, amount float64, date time.Time) int {
+	earns, err := loyaltyRepo.EarnsPoints(product)
+	if err != nil || !earns {
+		return 0
+	}
+	points := settings.PointsFor(amount, date)
+	if err := loyaltyRepo.EarnPoints(customer.ID, saleID, points, date, settings.ExpiryDays); err != nil {
+		fmt.Println("Error al acreditar los puntos:", err)
+		return 0
+	}
+	return points
+}
+
+// printPointsBalance muestra en el comprobante los puntos ganados y el saldo
+// de puntos del cliente.
+func printPointsBalance(loyaltyRepo *repository.LoyaltyRepo, settings models.LoyaltySettings, customer *models.Customer, earned int) {
+	balance, err := loyaltyRepo.GetBalance(customer.ID)
+	if err != nil {
+		return
+	}
+	fmt.Printf("Puntos ganados: %d | Saldo de puntos: %d (%.2f)\n", earned, balance, settings.RedeemValue(balance))
+}
+
+// printPointsReversal informa los puntos anulados y reintegrados al
+// eliminar, editar o devolver una venta.
+func printPointsReversal(reversed, restored int) {
+	if reversed > 0 {
+		fmt.Printf("Se anularon %d puntos ganados con la venta.\n", reversed)
+	}
+	if restored > 0 {
+		fmt.Printf("Se reintegraron %d puntos canjeados en la venta.\n", restored)
+	}
+}
\ No newline at end of file
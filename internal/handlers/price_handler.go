@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ShowPriceHistory muestra los cambios de precio de un producto y permite
+// consultar qué precio tenía en una fecha determinada.
+func ShowPriceHistory(priceRepo *repository.PriceRepo, productRepo *repository.ProductRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Historial de Precios ---")
+	PreviewProducts(productRepo)
+	product, err := readProduct(reader, productRepo, "#ID, SKU o código del producto: ")
+	if err != nil {
+		fmt.Println("Producto no encontrado.")
+		return
+	}
+
+	changes, err := priceRepo.GetPriceHistory(product.ID)
+	if err != nil {
+		fmt.Println("Error al obtener el historial de precios:", err)
+		return
+	}
+	fmt.Printf("\n%s - precio actual: %.2f\n", product.Name, product.Price)
+	printPriceChanges(changes, nil)
+
+	fmt.Print("\nConsultar el precio a una fecha (DD/MM/YYYY, Enter = no): ")
+	dateStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(dateStr) == "" {
+		return
+	}
+	date, err := time.Parse("02/01/2006", strings.TrimSpace(dateStr))
+	if err != nil {
+		fmt.Println("Fecha inválida.")
+		return
+	}
+	price, err := priceRepo.GetPriceAt(product.ID, date)
+	if err == sql.ErrNoRows {
+		fmt.Printf("El producto no tenía precio registrado al %s.\n", date.Format("02/01/2006"))
+		return
+	}
+	if err != nil {
+		fmt.Println("Error al consultar el precio:", err)
+		return
+	}
+	fmt.Printf("Precio vigente al %s: %.2f\n", date.Format("02/01/2006"), price)
+}
+
+// SchedulePriceChange registra un nuevo precio para un producto con fecha de
+// vigencia. Si la fecha es hoy se aplica en el momento; si es futura, queda
+// programado y se aplica solo cuando llega el día.
+func SchedulePriceChange(priceRepo *repository.PriceRepo, productRepo *repository.ProductRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Programar Cambio de Precio ---")
+	PreviewProducts(productRepo)
+	product, err := readProduct(reader, productRepo, "#ID, SKU o código del producto: ")
+	if err != nil {
+		fmt.Println("Producto no encontrado.")
+		return
+	}
+
+	fmt.Printf("Nuevo precio (actual: %.2f): ", product.Price)
+	priceStr, _ := reader.ReadString('\n')
+	newPrice, err := parseAmount(priceStr)
+	if err != nil || newPrice < 0 {
+		fmt.Println("Precio inválido.")
+		return
+	}
+
+	date, ok := readEffectiveDate(reader)
+	if !ok {
+		return
+	}
+
+	fmt.Print("Motivo: ")
+	reason, _ := reader.ReadString('\n')
+
+	change := models.PriceChange{ProductID: product.ID, NewPrice: newPrice, EffectiveDate: date, Reason: strings.TrimSpace(reason)}
+	changes := []models.PriceChange{change}
+
+	// Las variantes sin precio propio siguen el precio del producto padre.
+	variants, err := productRepo.GetVariants(product.ID)
+	if err != nil {
+		fmt.Println("Error al obtener las variantes:", err)
+		return
+	}
+	for _, v := range variants {
+		if v.Price == product.Price {
+			change.ProductID = v.ID
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) > 1 {
+		fmt.Printf("El cambio también se aplicará a %d variante(s) con el mismo precio.\n", len(changes)-1)
+	}
+
+	applied, scheduled, err := priceRepo.SavePriceChanges(changes, time.Now())
+	if err != nil {
+		fmt.Println("Error al guardar el cambio de precio:", err)
+		return
+	}
+	printSavedPriceChanges(applied, scheduled, date)
+}
+
+// ShowScheduledPriceChanges lista los cambios de precio pendientes y permite
+// cancelar alguno.
+func ShowScheduledPriceChanges(priceRepo *repository.PriceRepo, productRepo *repository.ProductRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Cambios de Precio Programados ---")
+	changes, err := priceRepo.GetScheduledChanges()
+	if err != nil {
+		fmt.Println("Error al obtener los cambios programados:", err)
+		return
+	}
+	if len(changes) == 0 {
+		fmt.Println("No hay cambios de precio programados.")
+		return
+	}
+	names, err := productNames(productRepo)
+	if err != nil {
+		fmt.Println("Error al obtener productos:", err)
+		return
+	}
+	printPriceChanges(changes, names)
+
+	fmt.Print("\nID del cambio a cancelar (Enter = ninguno): ")
+	idStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(idStr) == "" {
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(idStr))
+	if err != nil {
+		fmt.Println("ID inválido.")
+		return
+	}
+	if err := priceRepo.CancelPriceChange(id); err == sql.ErrNoRows {
+		fmt.Println("No hay un cambio programado con ese ID.")
+		return
+	} else if err != nil {
+		fmt.Println("Error al cancelar el cambio de precio:", err)
+		return
+	}
+	fmt.Println("Cambio de precio cancelado.")
+}
+
+// BulkUpdatePrices sube o baja en un porcentaje el precio de todos los
+// productos que cumplen un filtro, en el momento o a partir de una fecha.
+func BulkUpdatePrices(priceRepo *repository.PriceRepo, productRepo *repository.ProductRepo, categoryRepo *repository.CategoryRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Actualización Masiva de Precios ---")
+	filter, err := readProductFilter(reader, categoryRepo)
+	if err != nil {
+		fmt.Println("Error al obtener categorías:", err)
+		return
+	}
+	filter.SortBy = "nombre"
+	products, err := productRepo.FindProducts(filter)
+	if err != nil {
+		fmt.Println("Error al obtener productos:", err)
+		return
+	}
+	if len(products) == 0 {
+		fmt.Println("Ningún producto cumple el filtro.")
+		return
+	}
+
+	fmt.Print("Porcentaje de ajuste (ej. 10 para subir, -5 para bajar): ")
+	percentStr, _ := reader.ReadString('\n')
+	percent, err := parseAmount(percentStr)
+	if err != nil || percent <= -100 {
+		fmt.Println("Porcentaje inválido.")
+		return
+	}
+
+	date, ok := readEffectiveDate(reader)
+	if !ok {
+		return
+	}
+
+	fmt.Print("Motivo: ")
+	reason, _ := reader.ReadString('\n')
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		reason = fmt.Sprintf("Ajuste masivo %+.2f%%", percent)
+	}
+
+	var changes []models.PriceChange
+	fmt.Printf("\n%-5s | %-30s | %-10s | %-10s\n", "ID", "Producto", "Actual", "Nuevo")
+	fmt.Println("--------------------------------------------------------------")
+	for _, p := range products {
+		newPrice := math.Round(p.Price*(1+percent/100)*100) / 100
+		if newPrice == p.Price {
+			continue
+		}
+		fmt.Printf("%-5d | %-30s | %-10.2f | %-10.2f\n", p.ID, p.Name, p.Price, newPrice)
+		changes = append(changes, models.PriceChange{ProductID: p.ID, NewPrice: newPrice, EffectiveDate: date, Reason: reason})
+	}
+	if len(changes) == 0 {
+		fmt.Println("El ajuste no modifica ningún precio.")
+		return
+	}
+
+	fmt.Printf("\n¿Confirma el cambio de precio de %d producto(s) desde el %s? (s/n): ", len(changes), date.Format("02/01/2006"))
+	confirm, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(confirm)) != "s" {
+		fmt.Println("Operación cancelada.")
+		return
+	}
+
+	applied, scheduled, err := priceRepo.SavePriceChanges(changes, time.Now())
+	if err != nil {
+		fmt.Println("Error al guardar los cambios de precio:", err)
+		return
+	}
+	printSavedPriceChanges(applied, scheduled, date)
+}
+
+// ShowPriceChangeReport lista los cambios de precio que entraron en vigencia
+// en un período, con la variación de cada uno.
+func ShowPriceChangeReport(priceRepo *repository.PriceRepo, productRepo *repository.ProductRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Informe de Cambios de Precio ---")
+	end := time.Now()
+	start := end.AddDate(0, -1, 0)
+
+	fmt.Printf("Desde (DD/MM/YYYY, Enter = %s): ", start.Format("02/01/2006"))
+	startStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(startStr) != "" {
+		date, err := time.Parse("02/01/2006", strings.TrimSpace(startStr))
+		if err != nil {
+			fmt.Println("Fecha inválida.")
+			return
+		}
+		start = date
+	}
+	fmt.Printf("Hasta (DD/MM/YYYY, Enter = %s): ", end.Format("02/01/2006"))
+	endStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(endStr) != "" {
+		date, err := time.Parse("02/01/2006", strings.TrimSpace(endStr))
+		if err != nil {
+			fmt.Println("Fecha inválida.")
+			return
+		}
+		end = date
+	}
+
+	changes, err := priceRepo.GetAppliedChanges(start, end)
+	if err != nil {
+		fmt.Println("Error al obtener los cambios de precio:", err)
+		return
+	}
+	names, err := productNames(productRepo)
+	if err != nil {
+		fmt.Println("Error al obtener productos:", err)
+		return
+	}
+
+	fmt.Printf("\nPeríodo: %s a %s\n", start.Format("02/01/2006"), end.Format("02/01/2006"))
+	printPriceChanges(changes, names)
+
+	var increases, decreases int
+	for _, c := range changes {
+		switch {
+		case c.OldPrice == 0:
+		case c.NewPrice > c.OldPrice:
+			increases++
+		case c.NewPrice < c.OldPrice:
+			decreases++
+		}
+	}
+	fmt.Printf("\n%d cambio(s): %d aumento(s), %d baja(s).\n", len(changes), increases, decreases)
+}
+
+// printPriceChanges imprime una tabla de cambios de precio. Si se pasan los
+// nombres de los productos, se agrega la columna Producto.
+func printPriceChanges(changes []models.PriceChange, names map[int]string) {
+	if len(changes) == 0 {
+		fmt.Println("No hay cambios de precio para mostrar.")
+		return
+	}
+
+	product := ""
+	if names != nil {
+		product = fmt.Sprintf("%-25s | ", "Producto")
+	}
+	fmt.Printf("%-5s | %-12s | %s%-10s | %-10s | %-8s | %-10s | %s\n", "ID", "Vigencia", product, "Anterior", "Nuevo", "Var.", "Estado", "Motivo")
+	fmt.Println("------------------------------------------------------------------------------------------------------------")
+	for _, c := range changes {
+		if names != nil {
+			product = fmt.Sprintf("%-25s | ", names[c.ProductID])
+		}
+		variation := "-"
+		if c.OldPrice != 0 {
+			variation = fmt.Sprintf("%+.1f%%", c.Percent())
+		}
+		fmt.Printf("%-5d | %-12s | %s%-10.2f | %-10.2f | %-8s | %-10s | %s\n", c.ID, c.EffectiveDate.Format("02/01/2006"), product, c.OldPrice, c.NewPrice, variation, c.Status, c.Reason)
+	}
+}
+
+// readEffectiveDate pide la fecha desde la que rige un nuevo precio. Un
+// precio no puede regir hacia atrás: una fecha pasada se toma como hoy.
+func readEffectiveDate(reader *bufio.Reader) (time.Time, bool) {
+	fmt.Print("Vigente desde (DD/MM/YYYY, Enter = hoy): ")
+	dateStr, _ := reader.ReadString('\n')
+	now := time.Now()
+	if strings.TrimSpace(dateStr) == "" {
+		return now, true
+	}
+	date, err := time.Parse("02/01/2006", strings.TrimSpace(dateStr))
+	if err != nil {
+		fmt.Println("Fecha inválida.")
+		return time.Time{}, false
+	}
+	if date.Format("2006-01-02") < now.Format("2006-01-02") {
+		fmt.Println("La fecha ya pasó; el precio rige desde hoy.")
+		return now, true
+	}
+	return date, true
+}
+
+func printSavedPriceChanges(applied, scheduled int, date time.Time) {
+	if applied > 0 {
+		fmt.Printf("%d precio(s) actualizados.\n", applied)
+	}
+	if scheduled > 0 {
+		fmt.Printf("%d cambio(s) de precio programados para el %s.\n", scheduled, date.Format("02/01/2006"))
+	}
+}
+
+// productNames devuelve el nombre de cada producto por su ID.
+func productNames(productRepo *repository.ProductRepo) (map[int]string, error) {
+	products, err := productRepo.GetAllProducts()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(products))
+	for _, p := range products {
+		names[p.ID] = p.Name
+	}
+	return names, nil
+}
\ No newline at end of file
@@ -2,17 +2,19 @@ package handlers
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"sales-system/internal/models"
 	"sales-system/internal/repository"
+	"sales-system/internal/utils"
 	"strconv"
 	"strings"
 	"time"
 )
 
 // RegistrarProducto maneja la opción para registrar un nuevo producto.
-func RegisterProduct(productRepo *repository.ProductRepo) {
+func RegisterProduct(productRepo *repository.ProductRepo, categoryRepo *repository.CategoryRepo) {
 	reader := bufio.NewReader(os.Stdin)
 	
 	fmt.Println("\n--- Registrar Producto ---")
@@ -29,30 +31,94 @@ func RegisterProduct(productRepo *repository.ProductRepo) {
 	productName, _ := reader.ReadString('\n')
 	productName = strings.TrimSpace(productName)
 
-	fmt.Print("Cantidad Inicial: ")
+	unit := models.Product{Unit: models.UnitPiece}
+	readUnit(reader, &unit)
+
+	fmt.Printf("Cantidad Inicial (%s): ", unit.Unit)
 	quantityStr, _ := reader.ReadString('\n')
-	quantity, err := strconv.Atoi(strings.TrimSpace(quantityStr))
+	quantity, err := parseQuantity(quantityStr)
 	if err != nil {
 		fmt.Println("Cantidad inválida. Usando 0.")
 		quantity = 0
 	}
+	quantity = unit.RoundQuantity(quantity)
+
+	fmt.Print("Costo Unitario: ")
+	costStr, _ := reader.ReadString('\n')
+	cost, err := parseAmount(costStr)
+	if err != nil {
+		fmt.Println("Costo inválido. Usando 0.0.")
+		cost = 0.0
+	}
 
 	fmt.Print("Precio: ")
 	priceStr, _ := reader.ReadString('\n')
-	price, err := strconv.ParseFloat(strings.TrimSpace(priceStr), 64)
+	price, err := parseAmount(priceStr)
 	if err != nil {
 		fmt.Println("Precio inválido. Usando 0.0.")
 		price = 0.0
 	}
 
+	fmt.Print("Método de costeo (1. Promedio ponderado, 2. FIFO): ")
+	methodStr, _ := reader.ReadString('\n')
+	costMethod := models.CostMethodAverage
+	if strings.TrimSpace(methodStr) == "2" {
+		costMethod = models.CostMethodFIFO
+	}
+
+	fmt.Print("Stock Mínimo (Enter = sin alerta): ")
+	minStockStr, _ := reader.ReadString('\n')
+	minStock, _ := parseQuantity(minStockStr)
+
+	fmt.Print("Cantidad a Reponer (Enter = 0): ")
+	reorderStr, _ := reader.ReadString('\n')
+	reorderQty, _ := parseQuantity(reorderStr)
+
+	categoryID := readCategory(reader, categoryRepo, 0)
+
+	fmt.Print("Marca: ")
+	brand, _ := reader.ReadString('\n')
+
+	fmt.Print("Etiquetas (separadas por comas): ")
+	tagsStr, _ := reader.ReadString('\n')
+
+	fmt.Print("SKU (Enter = sin SKU): ")
+	sku, _ := reader.ReadString('\n')
+
+	barcode := readBarcode(reader, "Código de barras EAN-13/UPC (Enter = sin código): ", "")
+
+	fmt.Print("¿Controla lotes y vencimientos? (s/n): ")
+	trackLotsStr, _ := reader.ReadString('\n')
+
 	product := models.Product{
-		Date:     date,
-		Name:     productName,
-		Quantity: quantity,
-		Price:    price,
+		Date:       date,
+		Name:       productName,
+		Quantity:   quantity,
+		Price:      price,
+		Cost:       cost,
+		CostMethod: costMethod,
+		MinStock:   minStock,
+		ReorderQty: reorderQty,
+		CategoryID: categoryID,
+		Brand:      strings.TrimSpace(brand),
+		Tags:       models.ParseTags(tagsStr),
+		SKU:        strings.TrimSpace(sku),
+		Barcode:    barcode,
+
+		Unit:           unit.Unit,
+		Decimals:       unit.Decimals,
+		PurchaseUnit:   unit.PurchaseUnit,
+		PurchaseFactor: unit.PurchaseFactor,
+		TrackLots:      strings.ToLower(strings.TrimSpace(trackLotsStr)) == "s",
 	}
 
-	_, err = productRepo.CreateProduct(product)
+	// El stock inicial de un producto con control de lotes entra en un lote.
+	var lot models.Lot
+	if quantity > 0 {
+		lot = readLot(reader, product)
+	}
+
+	_, err = productRepo.CreateProduct(product, lot)
 	if err != nil {
 		fmt.Println("Error al registrar el producto:", err)
 		return
@@ -62,37 +128,111 @@ func RegisterProduct(productRepo *repository.ProductRepo) {
 }
 
 // ShowProducts visualiza todos los productos registrados en una tabla.
-func ShowProducts(productRepo *repository.ProductRepo) {
+func ShowProducts(productRepo *repository.ProductRepo, categoryRepo *repository.CategoryRepo) {
 	products, err := productRepo.GetAllProducts()
 	if err != nil {
 		fmt.Println("Error al obtener productos:", err)
 		return
 	}
+	printProducts(products, productRepo, categoryRepo)
+}
+
+// ListProducts muestra el listado de productos filtrado por categoría, marca,
+// etiqueta o nombre y ordenado según elija el usuario.
+func ListProducts(productRepo *repository.ProductRepo, categoryRepo *repository.CategoryRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Buscar Productos ---")
+	filter, err := readProductFilter(reader, categoryRepo)
+	if err != nil {
+		fmt.Println("Error al obtener categorías:", err)
+		return
+	}
+
+	fmt.Print("Ordenar por (id, nombre, stock, precio, marca): ")
+	sortBy, _ := reader.ReadString('\n')
+	filter.SortBy = strings.ToLower(strings.TrimSpace(sortBy))
+
+	products, err := productRepo.FindProducts(filter)
+	if err != nil {
+		fmt.Println("Error al obtener productos:", err)
+		return
+	}
+	printProducts(products, productRepo, categoryRepo)
+	fmt.Printf("\n%d producto(s) encontrados.\n", len(products))
+}
+
+// readProductFilter pide los criterios de búsqueda de productos: categoría
+// (con sus subcategorías), marca, etiqueta y nombre.
+func readProductFilter(reader *bufio.Reader, categoryRepo *repository.CategoryRepo) (repository.ProductFilter, error) {
+	fmt.Println("Deje los campos en blanco para no filtrar.")
+
+	var filter repository.ProductFilter
+
+	ShowCategories(categoryRepo)
+	fmt.Print("ID de Categoría (incluye subcategorías): ")
+	categoryStr, _ := reader.ReadString('\n')
+	if categoryID, err := strconv.Atoi(strings.TrimSpace(categoryStr)); err == nil {
+		filter.CategoryIDs, err = categoryRepo.GetDescendantIDs(categoryID)
+		if err != nil {
+			return filter, err
+		}
+	}
+
+	fmt.Print("Marca: ")
+	brand, _ := reader.ReadString('\n')
+	filter.Brand = strings.TrimSpace(brand)
+
+	fmt.Print("Etiqueta: ")
+	tag, _ := reader.ReadString('\n')
+	filter.Tag = strings.TrimSpace(tag)
+
+	fmt.Print("Nombre contiene (o SKU / código de barras): ")
+	name, _ := reader.ReadString('\n')
+	filter.Name = strings.TrimSpace(name)
+
+	return filter, nil
+}
+
+// printProducts imprime la tabla de productos marcando los que tienen stock
+// bajo. Para los kits se muestra cuántos se pueden armar con sus componentes.
+func printProducts(products []models.Product, productRepo *repository.ProductRepo, categoryRepo *repository.CategoryRepo) {
+	categoryPaths, err := categoryRepo.GetCategoryPaths()
+	if err != nil {
+		fmt.Println("Error al obtener categorías:", err)
+		return
+	}
 
 	fmt.Println("\n--- Listado de Productos ---")
-	fmt.Printf("%-5s | %-12s | %-20s | %-8s | %-8s\n", "ID", "Fecha", "Producto", "Cantidad", "Precio")
-	fmt.Println("------------------------------------------------------------------")
+	fmt.Printf("%-5s | %-12s | %-10s | %-13s | %-20s | %-20s | %-12s | %-12s | %-6s | %-8s | %-8s | %-8s | %-8s | %s\n", "ID", "Fecha", "SKU", "Código", "Producto", "Categoría", "Marca", "Stock", "Mínimo", "Precio", "Costo", "Margen", "Costeo", "Etiquetas")
+	fmt.Println("----------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------------")
+	lowStock := 0
 	for _, p := range products {
-		fmt.Printf("%-5d | %-12s | %-20s | %-8d | %-8.2f\n", p.ID, p.Date.Format("02/01/2006"), p.Name, p.Quantity, p.Price)
+		alert := ""
+		if p.IsLowStock() {
+			alert = "  <-- STOCK BAJO"
+			lowStock++
+		}
+		stock := p.FormatQuantity(p.Quantity)
+		if p.IsKit {
+			available, _ := productRepo.GetKitAvailability(p.ID, 0)
+			stock = "kit: " + formatQuantity(available)
+		}
+		fmt.Printf("%-5d | %-12s | %-10s | %-13s | %-20s | %-20s | %-12s | %-12s | %-6s | %-8.2f | %-8.2f | %7.1f%% | %-8s | %s%s\n", p.ID, p.Date.Format("02/01/2006"), p.SKU, p.Barcode, p.Name, categoryPaths[p.CategoryID], p.Brand, stock, models.FormatQuantity(p.MinStock, p.Decimals), p.Price, p.Cost, models.MarginPercent(p.Price, p.Cost), p.CostMethod, strings.Join(p.Tags, ", "), alert)
+	}
+	if lowStock > 0 {
+		fmt.Printf("\n¡Atención! %d producto(s) con stock bajo.\n", lowStock)
 	}
 }
 
 // EditProduct maneja la edición de los datos de un producto.
-func EditProduct(productRepo *repository.ProductRepo) {
+func EditProduct(productRepo *repository.ProductRepo, categoryRepo *repository.CategoryRepo) {
 	reader := bufio.NewReader(os.Stdin)
 
 	fmt.Println("\n--- Editar Producto ---")
-	ShowProducts(productRepo) // Muestra la lista para que el usuario elija un ID
-
-	fmt.Print("\nIngrese el ID del producto a editar: ")
-	idStr, _ := reader.ReadString('\n')
-	id, err := strconv.Atoi(strings.TrimSpace(idStr))
-	if err != nil {
-		fmt.Println("ID inválido.")
-		return
-	}
+	ShowProducts(productRepo, categoryRepo) // Muestra la lista para que el usuario elija un ID
 
-	product, err := productRepo.GetProductByID(id)
+	product, err := readProduct(reader, productRepo, "\nIngrese el #ID, SKU o código de barras del producto a editar: ")
 	if err != nil {
 		fmt.Println("Producto no encontrado.")
 		return
@@ -109,58 +249,368 @@ func EditProduct(productRepo *repository.ProductRepo) {
 		}
 	}
 
-	fmt.Printf("Cantidad (actual: %d): ", product.Quantity)
-	quantityStr, _ := reader.ReadString('\n')
-	if strings.TrimSpace(quantityStr) != "" {
-		newQuantity, err := strconv.Atoi(strings.TrimSpace(quantityStr))
-		if err == nil {
-			product.Quantity = newQuantity
-		}
+	// La unidad no puede cambiar mientras haya stock: las cantidades ya
+	// registradas quedarían expresadas en otra unidad.
+	unit, decimals := product.Unit, product.Decimals
+	readUnit(reader, product)
+	if product.Unit != unit && product.Quantity != 0 {
+		fmt.Printf("No se puede cambiar la unidad de un producto con stock (%s). Se mantiene %s.\n", product.FormatQuantity(product.Quantity), unit)
+		product.Unit, product.Decimals = unit, decimals
 	}
 
-	fmt.Printf("Precio (actual: %.2f): ", product.Price)
+	// La cantidad no se edita a mano: cambia con compras, ventas,
+	// transferencias y ajustes de la toma de inventario, que registran el
+	// movimiento, el costo y los lotes.
+	fmt.Printf("Cantidad: %s (se corrige con una Toma de Inventario)\n", product.FormatQuantity(product.Quantity))
+
+	// Una variante con precio propio puede volver a seguir el del padre.
+	useParentPrice := false
+	if product.ParentID != 0 && product.PriceOverride {
+		fmt.Printf("Precio (actual: %.2f, propio de la variante; \"p\" = usar el del producto padre): ", product.Price)
+	} else {
+		fmt.Printf("Precio (actual: %.2f): ", product.Price)
+	}
 	priceStr, _ := reader.ReadString('\n')
-	if strings.TrimSpace(priceStr) != "" {
-		newPrice, err := strconv.ParseFloat(strings.TrimSpace(priceStr), 64)
+	if strings.EqualFold(strings.TrimSpace(priceStr), "p") && product.ParentID != 0 {
+		useParentPrice = true
+	} else if strings.TrimSpace(priceStr) != "" {
+		newPrice, err := parseAmount(priceStr)
 		if err == nil {
 			product.Price = newPrice
 		}
 	}
 
+	fmt.Printf("Costo Unitario (actual: %.2f): ", product.Cost)
+	costStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(costStr) != "" {
+		newCost, err := parseAmount(costStr)
+		if err == nil {
+			product.Cost = newCost
+		}
+	}
+
+	fmt.Printf("Método de costeo (actual: %s - 1. Promedio ponderado, 2. FIFO): ", product.CostMethod)
+	methodStr, _ := reader.ReadString('\n')
+	switch strings.TrimSpace(methodStr) {
+	case "1":
+		product.CostMethod = models.CostMethodAverage
+	case "2":
+		product.CostMethod = models.CostMethodFIFO
+	}
+
+	fmt.Printf("Stock Mínimo (actual: %s): ", models.FormatQuantity(product.MinStock, product.Decimals))
+	minStockStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(minStockStr) != "" {
+		newMinStock, err := parseQuantity(minStockStr)
+		if err == nil {
+			product.MinStock = newMinStock
+		}
+	}
+
+	fmt.Printf("Cantidad a Reponer (actual: %s): ", models.FormatQuantity(product.ReorderQty, product.Decimals))
+	reorderStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(reorderStr) != "" {
+		newReorderQty, err := parseQuantity(reorderStr)
+		if err == nil {
+			product.ReorderQty = newReorderQty
+		}
+	}
+
+	product.CategoryID = readCategory(reader, categoryRepo, product.CategoryID)
+
+	fmt.Printf("Marca (actual: %s): ", product.Brand)
+	brand, _ := reader.ReadString('\n')
+	if strings.TrimSpace(brand) != "" {
+		product.Brand = strings.TrimSpace(brand)
+	}
+
+	fmt.Printf("Etiquetas (actual: %s): ", strings.Join(product.Tags, ", "))
+	tagsStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(tagsStr) != "" {
+		product.Tags = models.ParseTags(tagsStr)
+	}
+
+	fmt.Printf("SKU (actual: %s, \"-\" = quitar): ", product.SKU)
+	sku, _ := reader.ReadString('\n')
+	switch strings.TrimSpace(sku) {
+	case "":
+	case "-":
+		product.SKU = ""
+	default:
+		product.SKU = strings.TrimSpace(sku)
+	}
+
+	product.Barcode = readBarcode(reader, fmt.Sprintf("Código de barras (actual: %s): ", product.Barcode), product.Barcode)
+
+	trackLots := "n"
+	if product.TrackLots {
+		trackLots = "s"
+	}
+	fmt.Printf("¿Controla lotes y vencimientos? (actual: %s): ", trackLots)
+	trackLotsStr, _ := reader.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(trackLotsStr)) {
+	case "s":
+		product.TrackLots = true
+	case "n":
+		product.TrackLots = false
+	}
+
 	err = productRepo.UpdateProduct(*product)
 	if err != nil {
 		fmt.Println("Error al actualizar el producto:", err)
 		return
 	}
+	if useParentPrice {
+		if err := productRepo.UseParentPrice(product.ID); err != nil {
+			fmt.Println("Error al aplicar el precio del producto padre:", err)
+			return
+		}
+	}
 	fmt.Println("Producto actualizado con éxito.")
 }
 
 // DeleteProduct maneja la eliminación de un producto.
-func DeleteProduct(productRepo *repository.ProductRepo) {
+func DeleteProduct(productRepo *repository.ProductRepo, categoryRepo *repository.CategoryRepo) {
 	reader := bufio.NewReader(os.Stdin)
 
 	fmt.Println("\n--- Eliminar Producto ---")
-	ShowProducts(productRepo) // Muestra la lista para que el usuario elija un ID
+	ShowProducts(productRepo, categoryRepo) // Muestra la lista para que el usuario elija un ID
 
-	fmt.Print("\nIngrese el ID del producto a eliminar: ")
-	idStr, _ := reader.ReadString('\n')
-	id, err := strconv.Atoi(strings.TrimSpace(idStr))
+	product, err := readProduct(reader, productRepo, "\nIngrese el #ID, SKU o código de barras del producto a eliminar: ")
 	if err != nil {
-		fmt.Println("ID inválido.")
+		fmt.Println("Producto no encontrado.")
 		return
 	}
 
-	fmt.Print("¿Está seguro de que desea eliminar este producto? (s/n): ")
+	fmt.Printf("¿Está seguro de que desea eliminar el producto '%s'? (s/n): ", product.Name)
 	confirmation, _ := reader.ReadString('\n')
 	if strings.ToLower(strings.TrimSpace(confirmation)) != "s" {
 		fmt.Println("Operación cancelada.")
 		return
 	}
 
-	err = productRepo.DeleteProduct(id)
+	err = productRepo.DeleteProduct(product.ID)
+	if errors.Is(err, repository.ErrProductHasSales) {
+		// Se archiva para no perder el historial de ventas.
+		if err := productRepo.ArchiveProduct(product.ID); err != nil {
+			fmt.Println("Error al archivar el producto:", err)
+			return
+		}
+		fmt.Println("El producto tiene ventas registradas, por lo que se archivó en lugar de eliminarse.")
+		fmt.Println("Ya no aparece en los listados ni se puede vender, pero sus ventas se conservan.")
+		return
+	}
 	if err != nil {
 		fmt.Println("Error al eliminar el producto:", err)
 		return
 	}
 	fmt.Println("Producto eliminado con éxito.")
-}
\ No newline at end of file
+}
+
+// ShowArchivedProducts lista los productos archivados y permite volver a
+// habilitar alguno.
+func ShowArchivedProducts(productRepo *repository.ProductRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Productos Archivados ---")
+	products, err := productRepo.GetArchivedProducts()
+	if err != nil {
+		fmt.Println("Error al obtener productos:", err)
+		return
+	}
+	if len(products) == 0 {
+		fmt.Println("No hay productos archivados.")
+		return
+	}
+	fmt.Printf("%-5s | %-10s | %-25s | %-12s | %-8s\n", "ID", "SKU", "Producto", "Stock", "Precio")
+	fmt.Println("------------------------------------------------------------------------")
+	archived := make(map[int]bool, len(products))
+	for _, p := range products {
+		archived[p.ID] = true
+		fmt.Printf("%-5d | %-10s | %-25s | %-12s | %-8.2f\n", p.ID, p.SKU, p.Name, p.FormatQuantity(p.Quantity), p.Price)
+	}
+
+	fmt.Print("\nID del producto a restaurar (Enter = ninguno): ")
+	idStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(idStr) == "" {
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(idStr))
+	if err != nil || !archived[id] {
+		fmt.Println("ID inválido.")
+		return
+	}
+	if err := productRepo.RestoreProduct(id); err != nil {
+		fmt.Println("Error al restaurar el producto:", err)
+		return
+	}
+	fmt.Println("Producto restaurado con éxito.")
+}
+
+// RegisterStockReceipt registra una entrada de mercadería y actualiza el costo del producto.
+func RegisterStockReceipt(productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Entrada de Mercadería ---")
+	PreviewProducts(productRepo)
+
+	fmt.Print("Fecha (DD/MM/YYYY): ")
+	dateStr, _ := reader.ReadString('\n')
+	date, err := time.Parse("02/01/2006", strings.TrimSpace(dateStr))
+	if err != nil {
+		fmt.Println("Formato de fecha inválido. Usando la fecha actual.")
+		date = time.Now()
+	}
+
+	product, err := readProduct(reader, productRepo, "#ID, SKU o código de barras del Producto: ")
+	if err != nil {
+		fmt.Println("Producto no encontrado.")
+		return
+	}
+	if product.IsKit {
+		fmt.Println("Los kits no tienen stock propio; registre la entrada de sus componentes.")
+		return
+	}
+
+	locationID := readLocation(reader, locationRepo, "Ubicación de destino")
+
+	quantity, err := readReceivedQuantity(reader, *product)
+	if err != nil || quantity <= 0 {
+		fmt.Println("Cantidad inválida. Operación cancelada.")
+		return
+	}
+
+	lot := readLot(reader, *product)
+
+	fmt.Printf("Costo por %s (último: %.2f): ", product.Unit, product.Cost)
+	costStr, _ := reader.ReadString('\n')
+	cost := product.Cost
+	if strings.TrimSpace(costStr) != "" {
+		cost, err = parseAmount(costStr)
+		if err != nil {
+			fmt.Println("Costo inválido. Operación cancelada.")
+			return
+		}
+	}
+
+	fmt.Print("Referencia (factura, proveedor): ")
+	reference, _ := reader.ReadString('\n')
+	reference = strings.TrimSpace(reference)
+
+	err = productRepo.ReceiveStock(product.ID, locationID, quantity, cost, date, reference, lot)
+	if err != nil {
+		fmt.Println("Error al registrar la entrada de mercadería:", err)
+		return
+	}
+
+	updated, err := productRepo.GetProductByID(product.ID)
+	if err == nil {
+		fmt.Printf("Entrada registrada con éxito. Stock actual: %s - Costo promedio: %.2f\n", updated.FormatQuantity(updated.Quantity), updated.Cost)
+	}
+}
+
+// readProduct pide un producto y lo busca por SKU, código de barras o "#ID",
+// de modo que se puede escanear el código en lugar de tipear el ID.
+func readProduct(reader *bufio.Reader, productRepo *repository.ProductRepo, prompt string) (*models.Product, error) {
+	fmt.Print(prompt)
+	code, _ := reader.ReadString('\n')
+	return productRepo.GetProductByCode(code)
+}
+
+// readBarcode pide un código EAN-13 o UPC-A y valida su dígito verificador.
+// Si se deja en blanco o el código es inválido se conserva el valor actual.
+func readBarcode(reader *bufio.Reader, prompt, current string) string {
+	fmt.Print(prompt)
+	code, _ := reader.ReadString('\n')
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return current
+	}
+	barcode, ok := utils.NormalizeBarcode(code)
+	if !ok {
+		fmt.Println("Código de barras inválido (longitud o dígito verificador incorrecto). Se mantiene el valor actual.")
+		return current
+	}
+	return barcode
+}
+
+// parseQuantity interpreta una cantidad aceptando coma o punto como separador
+// decimal.
+func parseQuantity(s string) (float64, error) {
+	return strconv.ParseFloat(strings.Replace(strings.TrimSpace(s), ",", ".", 1), 64)
+}
+
+// parseAmount interpreta un importe aceptando coma o punto como separador
+// decimal.
+func parseAmount(s string) (float64, error) {
+	return strconv.ParseFloat(strings.Replace(strings.TrimSpace(s), ",", ".", 1), 64)
+}
+
+// readUnit pide la unidad de venta, su precisión y la unidad de compra del
+// producto. Los campos en blanco conservan el valor actual.
+func readUnit(reader *bufio.Reader, product *models.Product) {
+	fmt.Printf("Unidad de medida (%s) (actual: %s): ", strings.Join(models.Units, ", "), product.Unit)
+	unitStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(unitStr) != "" {
+		unit := models.NormalizeUnit(unitStr)
+		if unit != product.Unit {
+			product.Unit = unit
+			product.Decimals = models.DefaultDecimals[unit]
+		}
+	}
+
+	fmt.Printf("Decimales (actual: %d): ", product.Decimals)
+	decimalsStr, _ := reader.ReadString('\n')
+	if decimals, err := strconv.Atoi(strings.TrimSpace(decimalsStr)); err == nil && decimals >= 0 && decimals <= 6 {
+		product.Decimals = decimals
+	}
+
+	fmt.Printf("Unidad de compra (ej. caja, bolsa; actual: %s): ", product.PurchaseUnit)
+	purchaseUnit, _ := reader.ReadString('\n')
+	if strings.TrimSpace(purchaseUnit) != "" {
+		product.PurchaseUnit = strings.TrimSpace(purchaseUnit)
+	}
+	if product.PurchaseFactor <= 0 {
+		product.PurchaseFactor = 1
+	}
+	if product.PurchaseUnit != "" {
+		fmt.Printf("Cantidad de %s por %s (actual: %s): ", product.Unit, product.PurchaseUnit, models.FormatQuantity(product.PurchaseFactor, product.Decimals))
+		factorStr, _ := reader.ReadString('\n')
+		if factor, err := parseQuantity(factorStr); err == nil && factor > 0 {
+			product.PurchaseFactor = factor
+		}
+	}
+}
+
+// readReceivedQuantity pide una cantidad recibida en la unidad de venta, en la
+// unidad de compra o en otra unidad compatible (por ejemplo g para un producto
+// en kg) y la devuelve convertida a la unidad de venta.
+func readReceivedQuantity(reader *bufio.Reader, product models.Product) (float64, error) {
+	units := product.Unit
+	if product.PurchaseUnit != "" {
+		units += ", " + product.PurchaseUnit
+	}
+	fmt.Printf("Cantidad recibida (ej. 12 o 3 %s): ", units)
+	input, _ := reader.ReadString('\n')
+	fields := strings.Fields(input)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, fmt.Errorf("cantidad inválida")
+	}
+	quantity, err := parseQuantity(fields[0])
+	if err != nil {
+		return 0, err
+	}
+	if len(fields) == 2 {
+		unit := strings.ToLower(fields[1])
+		switch {
+		case product.PurchaseUnit != "" && unit == strings.ToLower(product.PurchaseUnit):
+			quantity *= product.PurchaseFactor
+		default:
+			quantity, err = models.ConvertQuantity(quantity, models.NormalizeUnit(unit), product.Unit)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return product.RoundQuantity(quantity), nil
+}
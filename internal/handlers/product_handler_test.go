@@ -0,0 +1,32 @@
+package handlers
+
+import "testing"
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{"12.50", 12.5, false},
+		{"12,50", 12.5, false},
+		{" 0,75\n", 0.75, false},
+		{"100", 100, false},
+		{"-3,5", -3.5, false},
+		{"1.234,56", 0, true},
+		{"12,5,0", 0, true},
+		{"abc", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseAmount(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAmount(%q): error %v, se esperaba error: %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseAmount(%q) = %g, se esperaba %g", tt.input, got, tt.want)
+			}
+		})
+	}
+}
\ No newline at end of file
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reorderSuggestion es una línea de la sugerencia de reposición.
+type reorderSuggestion struct {
+	Product       models.Product
+	DailyVelocity float64
+	Suggested     float64
+}
+
+// ShowReorderSuggestions calcula qué productos reponer según el stock mínimo
+// y la velocidad de venta reciente, y permite exportar la lista a CSV o
+// convertirla en un borrador de orden de compra.
+func ShowReorderSuggestions(productRepo *repository.ProductRepo, saleRepo *repository.SaleRepo, purchaseRepo *repository.PurchaseOrderRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Sugerencia de Reposición ---")
+
+	fmt.Print("Días de historial de ventas a considerar (Enter = 30): ")
+	historyStr, _ := reader.ReadString('\n')
+	historyDays, err := strconv.Atoi(strings.TrimSpace(historyStr))
+	if err != nil || historyDays <= 0 {
+		historyDays = 30
+	}
+
+	fmt.Print("Días de stock a cubrir (Enter = 30): ")
+	coverageStr, _ := reader.ReadString('\n')
+	coverageDays, err := strconv.Atoi(strings.TrimSpace(coverageStr))
+	if err != nil || coverageDays <= 0 {
+		coverageDays = 30
+	}
+
+	products, err := productRepo.GetAllProducts()
+	if err != nil {
+		fmt.Println("Error al obtener productos:", err)
+		return
+	}
+
+	end := time.Now()
+	start := end.AddDate(0, 0, -historyDays)
+	sold, err := saleRepo.GetQuantitySoldByProduct(start, end)
+	if err != nil {
+		fmt.Println("Error al obtener las ventas:", err)
+		return
+	}
+
+	var suggestions []reorderSuggestion
+	for _, p := range products {
+		velocity := sold[p.ID] / float64(historyDays)
+		// Lo necesario para cubrir el período sin bajar del mínimo.
+		needed := velocity*float64(coverageDays) + p.MinStock - p.Quantity
+		if !p.IsLowStock() && needed <= 0 {
+			continue
+		}
+		suggested := roundUpQuantity(max(needed, p.ReorderQty), p)
+		if suggested <= 0 {
+			continue
+		}
+		suggestions = append(suggestions, reorderSuggestion{Product: p, DailyVelocity: velocity, Suggested: suggested})
+	}
+
+	if len(suggestions) == 0 {
+		fmt.Println("No hay productos para reponer.")
+		return
+	}
+
+	fmt.Printf("%-5s | %-20s | %-12s | %-8s | %-10s | %-12s\n", "ID", "Producto", "Stock", "Mínimo", "Venta/día", "Sugerido")
+	fmt.Println("-----------------------------------------------------------------------------------")
+	for _, s := range suggestions {
+		fmt.Printf("%-5d | %-20s | %-12s | %-8s | %-10.2f | %-12s\n", s.Product.ID, s.Product.Name, s.Product.FormatQuantity(s.Product.Quantity), models.FormatQuantity(s.Product.MinStock, s.Product.Decimals), s.DailyVelocity, s.Product.FormatQuantity(s.Suggested))
+	}
+
+	fmt.Println("\n1. Exportar a CSV")
+	fmt.Println("2. Crear borrador de orden de compra")
+	fmt.Println("3. Volver")
+	fmt.Print("Seleccione una opción: ")
+	choiceStr, _ := reader.ReadString('\n')
+
+	switch strings.TrimSpace(choiceStr) {
+	case "1":
+		fileName := "Sugerencia_de_Reposicion_" + time.Now().Format("2006-01-02") + ".csv"
+		if err := exportReorderSuggestionsToCSV(fileName, suggestions); err != nil {
+			fmt.Println("Error al exportar el CSV:", err)
+			return
+		}
+		fmt.Println("Sugerencia exportada a", fileName)
+	case "2":
+		fmt.Print("Proveedor: ")
+		supplier, _ := reader.ReadString('\n')
+
+		order := models.PurchaseOrder{
+			Date:     time.Now(),
+			Supplier: strings.TrimSpace(supplier),
+			Status:   models.PurchaseDraft,
+		}
+		for _, s := range suggestions {
+			order.Items = append(order.Items, models.PurchaseOrderItem{ProductID: s.Product.ID, Quantity: s.Suggested, UnitCost: s.Product.Cost})
+		}
+
+		id, err := purchaseRepo.CreatePurchaseOrder(order)
+		if err != nil {
+			fmt.Println("Error al crear la orden de compra:", err)
+			return
+		}
+		fmt.Printf("Borrador de orden de compra creado con éxito. ID: %d\n", id)
+	}
+}
+
+// exportReorderSuggestionsToCSV guarda la sugerencia de reposición en un archivo CSV.
+func exportReorderSuggestionsToCSV(fileName string, suggestions []reorderSuggestion) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"ID", "Producto", "Unidad", "Stock", "Minimo", "Venta por dia", "Cantidad sugerida", "Costo unitario"})
+	for _, s := range suggestions {
+		w.Write([]string{
+			strconv.Itoa(s.Product.ID),
+			s.Product.Name,
+			s.Product.Unit,
+			models.FormatQuantity(s.Product.Quantity, s.Product.Decimals),
+			models.FormatQuantity(s.Product.MinStock, s.Product.Decimals),
+			fmt.Sprintf("%.2f", s.DailyVelocity),
+			models.FormatQuantity(s.Suggested, s.Product.Decimals),
+			fmt.Sprintf("%.2f", s.Product.Cost),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// ShowPurchaseOrders lista las órdenes de compra y permite recibir un borrador en el stock.
+func ShowPurchaseOrders(purchaseRepo *repository.PurchaseOrderRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	orders, err := purchaseRepo.GetAllPurchaseOrders()
+	if err != nil {
+		fmt.Println("Error al obtener las órdenes de compra:", err)
+		return
+	}
+
+	fmt.Println("\n--- Órdenes de Compra ---")
+	fmt.Printf("%-5s | %-12s | %-20s | %-10s\n", "ID", "Fecha", "Proveedor", "Estado")
+	fmt.Println("-----------------------------------------------------")
+	for _, o := range orders {
+		fmt.Printf("%-5d | %-12s | %-20s | %-10s\n", o.ID, o.Date.Format("02/01/2006"), o.Supplier, o.Status)
+	}
+
+	fmt.Print("\nIngrese el ID de la orden para ver detalles (o presione Enter para volver): ")
+	idStr, _ := reader.ReadString('\n')
+	idStr = strings.TrimSpace(idStr)
+	if idStr == "" {
+		return
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		fmt.Println("ID inválido.")
+		return
+	}
+
+	order, err := purchaseRepo.GetPurchaseOrderByID(id)
+	if err != nil {
+		fmt.Println("Orden de compra no encontrada.")
+		return
+	}
+
+	fmt.Printf("\n--- Orden de Compra #%d (%s) ---\n", order.ID, order.Status)
+	fmt.Printf("%-20s | %-12s | %-10s | %-10s\n", "Producto", "Cantidad", "Costo", "Subtotal")
+	fmt.Println("-------------------------------------------------------------")
+	for _, item := range order.Items {
+		product, _ := productRepo.GetProductByID(item.ProductID)
+		productName, quantity := "N/A", models.FormatQuantity(item.Quantity, 2)
+		if product != nil {
+			productName, quantity = product.Name, product.FormatQuantity(item.Quantity)
+		}
+		fmt.Printf("%-20s | %-12s | %-10.2f | %-10.2f\n", productName, quantity, item.UnitCost, item.Quantity*item.UnitCost)
+	}
+	fmt.Printf("Total: %.2f\n", order.Total())
+
+	if order.Status != models.PurchaseDraft {
+		return
+	}
+
+	fmt.Print("\n¿Desea recibir esta orden e ingresar la mercadería al stock? (s/n): ")
+	confirmation, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(confirmation)) != "s" {
+		return
+	}
+
+	locationID := readLocation(reader, locationRepo, "Ubicación de destino")
+	lots := make(map[int]models.Lot)
+	for _, item := range order.Items {
+		if product, err := productRepo.GetProductByID(item.ProductID); err == nil {
+			lots[item.ID] = readLot(reader, *product)
+		}
+	}
+	if err := purchaseRepo.ReceivePurchaseOrder(order.ID, locationID, lots, time.Now()); err != nil {
+		fmt.Println("Error al recibir la orden de compra:", err)
+		return
+	}
+	fmt.Println("Orden de compra recibida con éxito.")
+}
+
+// roundUpQuantity redondea hacia arriba la cantidad a pedir: a cajas completas
+// si el producto se compra en otra unidad, o a la precisión del producto.
+func roundUpQuantity(quantity float64, p models.Product) float64 {
+	if p.PurchaseUnit != "" && p.PurchaseFactor > 0 {
+		return math.Ceil(quantity/p.PurchaseFactor-1e-9) * p.PurchaseFactor
+	}
+	pow := math.Pow(10, float64(p.Decimals))
+	return math.Ceil(quantity*pow-1e-9) / pow
+}
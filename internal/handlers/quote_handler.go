@@ -0,0 +1,415 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// CreateQuote arma un presupuesto para un cliente con las mismas líneas que
+// una venta, más un descuento opcional por línea. No descuenta stock.
+func CreateQuote(quoteRepo *repository.QuoteRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Nuevo Presupuesto ---")
+	PreviewProducts(productRepo)
+
+	fmt.Print("Nombre del Cliente: ")
+	client, _ := reader.ReadString('\n')
+	client = strings.TrimSpace(client)
+
+	locationID := readLocation(reader, locationRepo, "Ubicación desde la que se vendería")
+
+	fmt.Print("Días de validez (Enter = 15): ")
+	daysStr, _ := reader.ReadString('\n')
+	days, err := strconv.Atoi(strings.TrimSpace(daysStr))
+	if err != nil || days <= 0 {
+		days = 15
+	}
+
+	now := time.Now()
+	quote := models.Quote{
+		Date:       now,
+		Client:     client,
+		ValidUntil: now.AddDate(0, 0, days),
+		Status:     models.QuoteDraft,
+		LocationID: locationID,
+	}
+
+	fmt.Println("\nIngrese los productos. Enter en el producto para terminar.")
+	for {
+		fmt.Print("#ID, SKU o código del Producto: ")
+		code, _ := reader.ReadString('\n')
+		if strings.TrimSpace(code) == "" {
+			break
+		}
+		product, err := productRepo.GetProductByCode(strings.TrimSpace(code))
+		if err != nil {
+			fmt.Println("Producto no encontrado.")
+			continue
+		}
+		if product.Archived {
+			fmt.Println("El producto está archivado y ya no se vende.")
+			continue
+		}
+		if hasVariants(productRepo, product.ID) {
+			fmt.Println("El producto tiene variantes. Indique la variante (talle, color, etc.).")
+			continue
+		}
+
+		fmt.Printf("Cantidad (%s): ", product.Unit)
+		quantityStr, _ := reader.ReadString('\n')
+		quantity, err := parseQuantity(quantityStr)
+		if err != nil || product.RoundQuantity(quantity) <= 0 {
+			fmt.Println("Cantidad inválida.")
+			continue
+		}
+
+		fmt.Print("Descuento % (Enter = 0): ")
+		discountStr, _ := reader.ReadString('\n')
+		discount := 0.0
+		if strings.TrimSpace(discountStr) != "" {
+			discount, err = parseQuantity(discountStr)
+			if err != nil || discount < 0 || discount > 100 {
+				fmt.Println("Descuento inválido.")
+				continue
+			}
+		}
+
+		line := models.QuoteLine{
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			Quantity:    product.RoundQuantity(quantity),
+			Price:       product.Price,
+			Discount:    discount,
+		}
+		quote.Lines = append(quote.Lines, line)
+		fmt.Printf("  %-20s x%-10s %10.2f   | Total: %.2f\n", product.Name, product.FormatQuantity(line.Quantity), line.Total(), quote.Total())
+	}
+
+	if len(quote.Lines) == 0 {
+		fmt.Println("No se ingresaron productos. Presupuesto cancelado.")
+		return
+	}
+
+	id, err := quoteRepo.CreateQuote(quote)
+	if err != nil {
+		fmt.Println("Error al guardar el presupuesto:", err)
+		return
+	}
+	quote.ID = int(id)
+	fmt.Printf("Presupuesto registrado con éxito. ID: %d (válido hasta %s)\n", id, quote.ValidUntil.Format("02/01/2006"))
+
+	fmt.Print("¿Desea exportarlo a PDF? (s/n): ")
+	exportChoice, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(exportChoice)) == "s" {
+		exportQuote(quote)
+	}
+}
+
+// ShowQuotes lista los presupuestos y permite ver uno, marcarlo como enviado
+// o exportarlo a PDF.
+func ShowQuotes(quoteRepo *repository.QuoteRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	quotes, err := quoteRepo.GetAllQuotes()
+	if err != nil {
+		fmt.Println("Error al obtener los presupuestos:", err)
+		return
+	}
+
+	fmt.Println("\n--- Presupuestos ---")
+	fmt.Printf("%-5s | %-12s | %-20s | %-12s | %-10s | %-10s\n", "ID", "Fecha", "Cliente", "Válido hasta", "Total", "Estado")
+	fmt.Println("-------------------------------------------------------------------------------")
+	for _, q := range quotes {
+		fmt.Printf("%-5d | %-12s | %-20s | %-12s | %-10.2f | %-10s\n", q.ID, q.Date.Format("02/01/2006"), q.Client, q.ValidUntil.Format("02/01/2006"), q.Total(), q.Status)
+	}
+
+	quote := readQuote(reader, quoteRepo, "\nIngrese el ID del presupuesto para ver detalles (o presione Enter para volver): ")
+	if quote == nil {
+		return
+	}
+	printQuote(*quote)
+
+	fmt.Println("\n1. Marcar como enviada")
+	fmt.Println("2. Exportar a PDF")
+	fmt.Println("3. Volver")
+	fmt.Print("Seleccione una opción: ")
+	choiceStr, _ := reader.ReadString('\n')
+
+	switch strings.TrimSpace(choiceStr) {
+	case "1":
+		if quote.Status != models.QuoteDraft {
+			fmt.Printf("Solo se puede enviar un presupuesto en borrador (estado actual: %s).\n", quote.Status)
+			return
+		}
+		if err := quoteRepo.UpdateQuoteStatus(quote.ID, models.QuoteSent); err != nil {
+			fmt.Println("Error al actualizar el presupuesto:", err)
+			return
+		}
+		fmt.Println("Presupuesto marcado como enviado.")
+	case "2":
+		exportQuote(*quote)
+	}
+}
+
+// ConvertQuoteToSale registra en un paso las ventas de un presupuesto, una
+// por línea. Antes vuelve a controlar el stock de la ubicación y los precios
+// actuales, que se usan salvo que se decida respetar los presupuestados. El
+// descuento de cada línea se mantiene.
+func ConvertQuoteToSale(quoteRepo *repository.QuoteRepo, saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo, lotRepo *repository.LotRepo, customerRepo *repository.CustomerRepo, sellerRepo *repository.SellerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Convertir Presupuesto en Venta ---")
+	quote := readQuote(reader, quoteRepo, "ID del presupuesto: ")
+	if quote == nil {
+		return
+	}
+	printQuote(*quote)
+
+	switch quote.Status {
+	case models.QuoteAccepted:
+		fmt.Println("El presupuesto ya fue aceptado y convertido en venta.")
+		return
+	case models.QuoteExpired:
+		fmt.Print("El presupuesto está vencido. ¿Convertirlo de todos modos? (s/n): ")
+		confirm, _ := reader.ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(confirm)) != "s" {
+			fmt.Println("Operación cancelada.")
+			return
+		}
+	}
+
+	// Se vuelven a leer los productos para controlar precio y stock actuales.
+	products := make(map[int]*models.Product)
+	priceChanged, shortage := false, false
+	for _, l := range quote.Lines {
+		if l.SaleID != 0 {
+			continue
+		}
+		product, err := productRepo.GetProductByID(l.ProductID)
+		if err != nil {
+			fmt.Printf("Error: %s ya no existe. Operación cancelada.\n", l.ProductName)
+			return
+		}
+		if product.Archived {
+			fmt.Printf("Error: %s está archivado y ya no se vende. Operación cancelada.\n", product.Name)
+			return
+		}
+		products[l.ProductID] = product
+
+		if product.Price != l.Price {
+			priceChanged = true
+			fmt.Printf("Precio de %s: presupuestado %.2f, actual %.2f\n", product.Name, l.Price, product.Price)
+		}
+		if product.IsKit {
+			continue
+		}
+		available, err := locationRepo.GetLocationQuantity(product.ID, quote.LocationID)
+		if err != nil {
+			fmt.Println("Error al obtener el stock:", err)
+			return
+		}
+		if l.Quantity > available {
+			shortage = true
+			fmt.Printf("Stock insuficiente de %s: se necesitan %s y hay %s.\n", product.Name, product.FormatQuantity(l.Quantity), product.FormatQuantity(available))
+		}
+	}
+	if len(products) == 0 {
+		fmt.Println("Todas las líneas del presupuesto ya se vendieron.")
+		return
+	}
+
+	useQuoted := false
+	if priceChanged {
+		fmt.Print("¿Respetar los precios del presupuesto? (s/n, Enter = precios actuales): ")
+		choice, _ := reader.ReadString('\n')
+		useQuoted = strings.ToLower(strings.TrimSpace(choice)) == "s"
+	}
+	if shortage {
+		fmt.Print("El stock no alcanza para todo el presupuesto. ¿Continuar de todos modos? (s/n): ")
+		confirm, _ := reader.ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(confirm)) != "s" {
+			fmt.Println("Operación cancelada.")
+			return
+		}
+	}
+
+	sellerID := readSaleSeller(reader, sellerRepo)
+	status := readSaleStatus(reader)
+
+	date := time.Now()
+	if status == models.StatusPending {
+		var amount float64
+		for _, l := range quote.Lines {
+			if product := products[l.ProductID]; product != nil && l.SaleID == 0 {
+				price := product.Price
+				if useQuoted {
+					price = l.Price
+				}
+				amount += models.DiscountedTotal(l.Quantity, price, l.Discount)
+			}
+		}
+		if !checkCredit(reader, customerRepo, quote.Client, amount, date) {
+			fmt.Println("Operación cancelada.")
+			return
+		}
+	}
+	pending := 0
+	var total float64
+	fmt.Println("\n--- Ticket ---")
+	for _, l := range quote.Lines {
+		product := products[l.ProductID]
+		if l.SaleID != 0 || product == nil {
+			continue
+		}
+		price := product.Price
+		if useQuoted {
+			price = l.Price
+		}
+		sale := models.Sale{
+			Date:      date,
+			Client:    quote.Client,
+			ProductID: product.ID,
+			Quantity:  l.Quantity,
+			Price:     price,
+			Total:     models.DiscountedTotal(l.Quantity, price, l.Discount),
+			Status:    status,
+
+			LocationID: quote.LocationID,
+			SellerID:   sellerID,
+		}
+		if !checkKitStock(reader, productRepo, *product, quote.LocationID, l.Quantity) {
+			fmt.Printf("%s no se registró.\n", product.Name)
+			pending++
+			continue
+		}
+		includeExpired, ok := checkExpiredLots(reader, lotRepo, *product, quote.LocationID, l.Quantity, date)
+		if !ok {
+			fmt.Printf("%s no se registró.\n", product.Name)
+			pending++
+			continue
+		}
+		id, err := saleRepo.RecordSale(sale, includeExpired, models.PointsRedemption{}, nil)
+		if err != nil {
+			fmt.Printf("Error al registrar %s: %v\n", product.Name, err)
+			pending++
+			continue
+		}
+		if err := quoteRepo.SetQuoteLineSale(l.ID, id); err != nil {
+			fmt.Println("Error al actualizar el presupuesto:", err)
+			return
+		}
+		total += sale.Total
+		fmt.Printf("#%-5d %-20s %10s x %8.2f = %10.2f\n", id, product.Name, product.FormatQuantity(l.Quantity), price, sale.Total)
+	}
+	fmt.Printf("TOTAL: %.2f (%s)\n", total, status)
+
+	if pending > 0 {
+		fmt.Printf("Quedaron %d línea(s) sin vender; el presupuesto sigue abierto para convertirlas después.\n", pending)
+		return
+	}
+	if err := quoteRepo.UpdateQuoteStatus(quote.ID, models.QuoteAccepted); err != nil {
+		fmt.Println("Error al actualizar el presupuesto:", err)
+		return
+	}
+	fmt.Printf("Presupuesto #%d aceptado y convertido en venta.\n", quote.ID)
+}
+
+// readQuote pide el ID de un presupuesto y lo busca. Devuelve nil si se deja
+// en blanco o no existe.
+func readQuote(reader *bufio.Reader, quoteRepo *repository.QuoteRepo, prompt string) *models.Quote {
+	fmt.Print(prompt)
+	idStr, _ := reader.ReadString('\n')
+	idStr = strings.TrimSpace(idStr)
+	if idStr == "" {
+		return nil
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		fmt.Println("ID inválido.")
+		return nil
+	}
+	quote, err := quoteRepo.GetQuoteByID(id)
+	if err != nil {
+		fmt.Println("Presupuesto no encontrado.")
+		return nil
+	}
+	return quote
+}
+
+func printQuote(q models.Quote) {
+	fmt.Printf("\n--- Presupuesto #%d (%s) ---\n", q.ID, q.Status)
+	fmt.Printf("Cliente: %s | Fecha: %s | Válido hasta: %s\n", q.Client, q.Date.Format("02/01/2006"), q.ValidUntil.Format("02/01/2006"))
+	fmt.Printf("%-20s | %-10s | %-10s | %-6s | %-10s | %-8s\n", "Producto", "Cantidad", "Precio", "Desc.", "Subtotal", "Venta")
+	fmt.Println("---------------------------------------------------------------------------")
+	for _, l := range q.Lines {
+		sale := "-"
+		if l.SaleID != 0 {
+			sale = fmt.Sprintf("#%d", l.SaleID)
+		}
+		fmt.Printf("%-20s | %-10s | %-10.2f | %5.1f%% | %-10.2f | %-8s\n", l.ProductName, formatQuantity(l.Quantity), l.Price, l.Discount, l.Total(), sale)
+	}
+	fmt.Printf("Total: %.2f\n", q.Total())
+}
+
+func exportQuote(q models.Quote) {
+	fileName := fmt.Sprintf("Presupuesto_%d_%s.pdf", q.ID, strings.ReplaceAll(q.Client, " ", "_"))
+	if err := ExportQuoteToPDF(q, fileName); err != nil {
+		fmt.Printf("Error al crear el archivo PDF: %v\n", err)
+		return
+	}
+	fmt.Println("Presupuesto exportado a", fileName)
+}
+
+// ExportQuoteToPDF genera el documento del presupuesto para entregar al cliente.
+func ExportQuoteToPDF(q models.Quote, fileName string) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+
+	pdf.Cell(40, 10, fmt.Sprintf("Presupuesto #%d", q.ID))
+	pdf.Ln(8)
+	pdf.SetFont("Arial", "", 12)
+	pdf.Cell(40, 10, tr("Cliente: "+q.Client))
+	pdf.Ln(6)
+	pdf.Cell(40, 10, fmt.Sprintf("Fecha: %s", q.Date.Format("02/01/2006")))
+	pdf.Ln(6)
+	pdf.Cell(40, 10, tr(fmt.Sprintf("Válido hasta: %s", q.ValidUntil.Format("02/01/2006"))))
+	pdf.Ln(12)
+
+	pdf.SetFont("Arial", "B", 9)
+	pdf.Cell(70, 7, "Producto")
+	pdf.Cell(22, 7, "Cantidad")
+	pdf.Cell(25, 7, "Precio")
+	pdf.Cell(18, 7, "Desc.")
+	pdf.Cell(28, 7, "Subtotal")
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 9)
+	for _, l := range q.Lines {
+		pdf.Cell(70, 7, tr(l.ProductName))
+		pdf.Cell(22, 7, formatQuantity(l.Quantity))
+		pdf.Cell(25, 7, fmt.Sprintf("%.2f", l.Price))
+		pdf.Cell(18, 7, fmt.Sprintf("%.1f%%", l.Discount))
+		pdf.Cell(28, 7, fmt.Sprintf("%.2f", l.Total()))
+		pdf.Ln(-1)
+	}
+
+	pdf.Ln(4)
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(40, 10, fmt.Sprintf("Total: %.2f", q.Total()))
+	pdf.Ln(10)
+	pdf.SetFont("Arial", "", 9)
+	pdf.Cell(40, 7, tr("Precios sujetos a disponibilidad de stock al momento de la compra."))
+
+	return pdf.OutputFileAndClose(fileName)
+}
\ No newline at end of file
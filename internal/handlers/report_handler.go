@@ -6,6 +6,7 @@ import (
 	"os"
 	"sales-system/internal/models"
 	"sales-system/internal/repository"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -14,7 +15,7 @@ import (
 )
 
 // GenerateReport maneja la generación de reportes diarios, semanales o mensuales.
-func GenerateReport(saleRepo *repository.SaleRepo, cashRepo *repository.CashDeliveryRepo, productRepo *repository.ProductRepo) {
+func GenerateReport(saleRepo *repository.SaleRepo, cashRepo *repository.CashDeliveryRepo, categoryRepo *repository.CategoryRepo, locationRepo *repository.LocationRepo, giftCardRepo *repository.GiftCardRepo, shipmentRepo *repository.ShipmentRepo) {
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Println("\n--- Reportes de Ventas ---")
 	fmt.Println("1. Diario")
@@ -24,8 +25,6 @@ func GenerateReport(saleRepo *repository.SaleRepo, cashRepo *repository.CashDeli
 	choiceStr, _ := reader.ReadString('\n')
 	choice, _ := strconv.Atoi(strings.TrimSpace(choiceStr))
 
-	var sales []models.Sale
-	var deliveries []models.CashDelivery
 	var reportTitle string
 
 	now := time.Now()
@@ -50,70 +49,340 @@ func GenerateReport(saleRepo *repository.SaleRepo, cashRepo *repository.CashDeli
 		return
 	}
 
-	sales, err := saleRepo.GetSalesByDateRange(start, end)
+	deliveries, err := cashRepo.GetCashDeliveriesByDateRange(start, end)
 	if err != nil {
-		fmt.Println("Error al obtener ventas para el reporte:", err)
+		fmt.Println("Error al obtener entregas de dinero para el reporte:", err)
 		return
 	}
 
-	deliveries, err = cashRepo.GetCashDeliveriesByDateRange(start, end)
+	fmt.Print("¿Agrupar las variantes (talle, color) en su producto padre? (s/n): ")
+	rollUpChoice, _ := reader.ReadString('\n')
+	rollUpVariants := strings.ToLower(strings.TrimSpace(rollUpChoice)) == "s"
+
+	fmt.Print("¿Atribuir las ventas de kits a sus componentes? (s/n): ")
+	kitChoice, _ := reader.ReadString('\n')
+	splitKits := strings.ToLower(strings.TrimSpace(kitChoice)) == "s"
+
+	locationNames, err := locationRepo.GetLocationNames()
 	if err != nil {
-		fmt.Println("Error al obtener entregas de dinero para el reporte:", err)
+		fmt.Println("Error al obtener las ubicaciones:", err)
 		return
 	}
-
-	// Sumatoria de todos los totales
-	var totalSalesAmount float64
-	var totalProductsSold int
-	for _, s := range sales {
-		totalSalesAmount += s.Total
-		totalProductsSold += s.Quantity
-	}
-	var totalCashDelivered float64
-	for _, d := range deliveries {
-		totalCashDelivered += d.Amount
+	categoryPaths, err := categoryRepo.GetCategoryPaths()
+	if err != nil {
+		fmt.Println("Error al obtener categorías:", err)
+		return
 	}
+	builder := newReportBuilder(categoryPaths, locationNames, rollUpVariants)
 
 	// Mostrar reporte en consola
 	fmt.Printf("\n--- %s ---\n", reportTitle)
 	fmt.Printf("Período: %s a %s\n", start.Format("02/01/2006"), end.Format("02/01/2006"))
-	
-	// Tabla de ventas
+
+	// Tabla de ventas. Las ventas se recorren de a una, sin cargarlas todas
+	// en memoria; si no se reparten los kits, el resumen se arma en la misma pasada.
 	fmt.Println("\nDetalles de Ventas:")
-	fmt.Printf("%-5s | %-12s | %-20s | %-10s | %-8s | %-8s\n", "ID", "Fecha", "Cliente", "Producto", "Cantidad", "Total")
-	fmt.Println("-------------------------------------------------------------------------------------")
-	for _, s := range sales {
-		product, _ := productRepo.GetProductByID(s.ProductID)
-		productName := "N/A"
-		if product != nil {
-			productName = product.Name
+	fmt.Printf("%-5s | %-12s | %-20s | %-10s | %-8s | %-8s | %-8s | %-8s | %-7s\n", "ID", "Fecha", "Cliente", "Producto", "Cantidad", "Total", "Costo", "Ganancia", "Margen")
+	fmt.Println("----------------------------------------------------------------------------------------------------------------")
+	err = saleRepo.EachReportSale(start, end, false, func(row repository.ReportRow) error {
+		s := row.Sale
+		fmt.Printf("%-5d | %-12s | %-20s | %-10s | %-8s | %-8.2f | %-8.2f | %-8.2f | %6.1f%%\n", s.ID, s.Date.Format("02/01/2006"), s.Client, s.ProductName, formatQuantity(s.Quantity), s.Total, s.Cost, s.Profit(), s.Margin())
+		if !splitKits {
+			builder.add(row)
 		}
-		fmt.Printf("%-5d | %-12s | %-20s | %-10s | %-8d | %-8.2f\n", s.ID, s.Date.Format("02/01/2006"), s.Client, productName, s.Quantity, s.Total)
+		return nil
+	})
+	if err == nil && splitKits {
+		err = saleRepo.EachReportSale(start, end, true, func(row repository.ReportRow) error {
+			builder.add(row)
+			return nil
+		})
+	}
+	if err != nil {
+		fmt.Println("Error al obtener ventas para el reporte:", err)
+		return
+	}
+	summary := builder.summary(deliveries)
+	summary.TotalGiftCardPayments, err = giftCardRepo.GetRedeemedTotal(start, end)
+	if err != nil {
+		fmt.Println("Error al obtener los cobros con tarjetas de regalo:", err)
+		return
+	}
+	summary.TotalGiftCardsSold, err = giftCardRepo.GetSoldTotal(start, end)
+	if err != nil {
+		fmt.Println("Error al obtener las tarjetas de regalo vendidas:", err)
+		return
+	}
+	summary.ForeignCash, err = saleRepo.GetForeignCurrencyTotals(start, end)
+	if err != nil {
+		fmt.Println("Error al obtener los cobros en moneda extranjera:", err)
+		return
+	}
+	summary.TotalShippingFees, err = shipmentRepo.GetFeeTotal(start, end)
+	if err != nil {
+		fmt.Println("Error al obtener los costos de envío:", err)
+		return
+	}
+
+	// Rentabilidad por producto
+	fmt.Println("\nRentabilidad por Producto:")
+	printGroupSummaries("Producto", summary.Products)
+
+	// Ventas agrupadas por categoría y por marca
+	fmt.Println("\nVentas por Categoría:")
+	printGroupSummaries("Categoría", summary.Categories)
+	if len(summary.ParentCategories) > 0 {
+		fmt.Println("\nSubtotales por Categoría Padre (incluyen sus subcategorías):")
+		printGroupSummaries("Categoría", summary.ParentCategories)
 	}
+	fmt.Println("\nVentas por Marca:")
+	printGroupSummaries("Marca", summary.Brands)
+	fmt.Println("\nVentas por Ubicación:")
+	printGroupSummaries("Ubicación", summary.Locations)
 
 	// Resumen del reporte
 	fmt.Println("\n--- Resumen del Reporte ---")
-	fmt.Printf("Total de Ventas: %.2f\n", totalSalesAmount)
-	fmt.Printf("Total de Productos Vendidos: %d\n", totalProductsSold)
-	fmt.Printf("Total de Dinero Entregado: %.2f\n", totalCashDelivered)
-	
-	neto := totalSalesAmount - totalCashDelivered
-	fmt.Printf("Neto (Ventas - Entregas): %.2f\n", neto)
+	fmt.Printf("Total de Ventas: %.2f\n", summary.TotalSales)
+	fmt.Printf("Total de Productos Vendidos: %s\n", formatQuantity(summary.TotalProductsSold))
+	fmt.Printf("Costo de lo Vendido: %.2f\n", summary.TotalCost)
+	fmt.Printf("Ganancia Bruta: %.2f (Margen %.1f%%)\n", summary.GrossProfit(), summary.Margin())
+	fmt.Printf("Total de Dinero Entregado: %.2f\n", summary.TotalCashDelivered)
+	fmt.Printf("Cobrado con Tarjetas de Regalo y Crédito: %.2f\n", summary.TotalGiftCardPayments)
+	fmt.Printf("Tarjetas de Regalo Vendidas (no son venta): %.2f\n", summary.TotalGiftCardsSold)
+	fmt.Printf("Costos de Envío Cobrados: %.2f\n", summary.TotalShippingFees)
+	fmt.Printf("Neto (Ventas + Envíos + Tarjetas vendidas - Entregas - Cobrado con tarjetas): %.2f\n", summary.Net())
+	if len(summary.ForeignCash) > 0 {
+		fmt.Printf("\nEfectivo en Moneda Extranjera (incluido en el total, en %s):\n", baseCurrency())
+		for _, c := range summary.ForeignCash {
+			fmt.Printf("  %s %.2f (equivale a %.2f)\n", c.Currency, c.Amount, c.BaseAmount)
+		}
+	}
 
 	fmt.Print("\n¿Desea exportar este reporte a PDF? (s/n): ")
 	exportChoice, _ := reader.ReadString('\n')
 	if strings.ToLower(strings.TrimSpace(exportChoice)) == "s" {
-		ExportReportToPDF(reportTitle, start, end, sales, deliveries, totalSalesAmount, totalProductsSold, totalCashDelivered, productRepo)
+		if err := ExportReportToPDF(reportTitle, start, end, saleRepo, summary); err != nil {
+			fmt.Printf("Error al crear el archivo PDF: %v\n", err)
+			return
+		}
 		fmt.Println("Reporte exportado a PDF con éxito.")
 	}
 }
 
-// ExportReportToPDF genera y guarda un archivo PDF del reporte.
-func ExportReportToPDF(title string, start, end time.Time, sales []models.Sale, deliveries []models.CashDelivery, totalSalesAmount float64, totalProductsSold int, totalCashDelivered float64, productRepo *repository.ProductRepo) {
+func printGroupSummaries(label string, groups []GroupSummary) {
+	fmt.Printf("%-30s | %-8s | %-10s | %-10s | %-10s | %-7s\n", label, "Cantidad", "Ventas", "Costo", "Ganancia", "Margen")
+	fmt.Println("---------------------------------------------------------------------------------------------")
+	for _, g := range groups {
+		fmt.Printf("%-30s | %-8s | %-10.2f | %-10.2f | %-10.2f | %6.1f%%\n", g.Name, formatQuantity(g.Quantity), g.Total, g.Cost, g.Profit(), g.Margin())
+	}
+}
+
+// GroupSummary acumula las ventas de un grupo (producto, categoría, marca o ubicación)
+// dentro del período del reporte.
+type GroupSummary struct {
+	Name     string
+	Category string
+	Quantity float64
+	Total    float64
+	Cost     float64
+}
+
+// Profit devuelve la ganancia bruta del grupo en el período.
+func (g GroupSummary) Profit() float64 {
+	return g.Total - g.Cost
+}
+
+// Margin devuelve el margen bruto del grupo en porcentaje.
+func (g GroupSummary) Margin() float64 {
+	return models.MarginPercent(g.Total, g.Cost)
+}
+
+func (g *GroupSummary) add(s models.Sale) {
+	g.Quantity += s.Quantity
+	g.Total += s.Total
+	g.Cost += s.Cost
+}
+
+// ReportSummary contiene los totales del período de un reporte.
+type ReportSummary struct {
+	TotalSales         float64
+	TotalProductsSold  float64
+	TotalCost          float64
+	TotalCashDelivered float64
+	Products           []GroupSummary
+	Categories         []GroupSummary
+	ParentCategories   []GroupSummary
+	Brands             []GroupSummary
+	Locations          []GroupSummary
+	ProductNames       map[int]string
+
+	// TotalGiftCardPayments es lo cobrado con tarjetas de regalo y crédito
+	// en tienda, que no entra como dinero.
+	TotalGiftCardPayments float64
+	// TotalGiftCardsSold es lo cobrado por emitir y recargar tarjetas de
+	// regalo. Entra como dinero pero no como venta: es una deuda con el
+	// cliente hasta que la tarjeta se consume.
+	TotalGiftCardsSold float64
+	// ForeignCash es lo cobrado en moneda extranjera. Ya está incluido, en
+	// moneda local, en TotalSales.
+	ForeignCash []models.CurrencyTotal
+	// TotalShippingFees es lo cobrado por envíos a domicilio, que no forma
+	// parte del total de las ventas.
+	TotalShippingFees float64
+}
+
+// GrossProfit devuelve la ganancia bruta del período (ventas menos costo de lo vendido).
+func (r ReportSummary) GrossProfit() float64 {
+	return r.TotalSales - r.TotalCost
+}
+
+// Margin devuelve el margen bruto del período en porcentaje.
+func (r ReportSummary) Margin() float64 {
+	return models.MarginPercent(r.TotalSales, r.TotalCost)
+}
+
+// Net devuelve las ventas más los envíos y las tarjetas vendidas, menos el
+// dinero entregado y lo cobrado con tarjetas.
+func (r ReportSummary) Net() float64 {
+	return r.TotalSales + r.TotalShippingFees + r.TotalGiftCardsSold - r.TotalCashDelivered - r.TotalGiftCardPayments
+}
+
+// reportBuilder acumula los totales del período y agrupa las ventas por
+// producto, categoría, marca y ubicación a medida que se recorren. Con
+// rollUpVariants las variantes se acumulan en su producto padre.
+type reportBuilder struct {
+	categoryPaths  map[int]string
+	locationNames  map[int]string
+	rollUpVariants bool
+
+	totals        ReportSummary
+	products      map[int]*GroupSummary
+	categories    map[string]*GroupSummary
+	categoryTrees map[string]*GroupSummary
+	parents       map[string]bool
+	brands        map[string]*GroupSummary
+	locations     map[int]*GroupSummary
+}
+
+func newReportBuilder(categoryPaths, locationNames map[int]string, rollUpVariants bool) *reportBuilder {
+	return &reportBuilder{
+		categoryPaths:  categoryPaths,
+		locationNames:  locationNames,
+		rollUpVariants: rollUpVariants,
+		totals:         ReportSummary{ProductNames: make(map[int]string)},
+		products:       make(map[int]*GroupSummary),
+		categories:     make(map[string]*GroupSummary),
+		categoryTrees:  make(map[string]*GroupSummary),
+		parents:        make(map[string]bool),
+		brands:         make(map[string]*GroupSummary),
+		locations:      make(map[int]*GroupSummary),
+	}
+}
+
+func (b *reportBuilder) add(row repository.ReportRow) {
+	s := row.Sale
+	b.totals.TotalSales += s.Total
+	b.totals.TotalProductsSold += s.Quantity
+	b.totals.TotalCost += s.Cost
+	if _, ok := b.totals.ProductNames[s.ProductID]; !ok {
+		b.totals.ProductNames[s.ProductID] = s.ProductName
+	}
+
+	// Los productos eliminados se agrupan con el nombre que tenían al venderse.
+	groupID, name, brand, categoryID := s.ProductID, s.ProductName, "", 0
+	if row.ProductFound {
+		name, brand, categoryID = row.Name, row.Brand, row.CategoryID
+		if b.rollUpVariants && row.ParentID != 0 {
+			groupID, name, brand, categoryID = row.ParentID, row.ParentName, row.ParentBrand, row.ParentCategoryID
+		}
+	}
+	if brand == "" {
+		brand = "Sin marca"
+	}
+
+	ps := b.products[groupID]
+	if ps == nil {
+		ps = &GroupSummary{Name: name, Category: "Sin categoría"}
+		if path, ok := b.categoryPaths[categoryID]; ok && row.ProductFound {
+			ps.Category = path
+		}
+		b.products[groupID] = ps
+	}
+	ps.add(s)
+
+	if b.categories[ps.Category] == nil {
+		b.categories[ps.Category] = &GroupSummary{Name: ps.Category}
+	}
+	b.categories[ps.Category].add(s)
+
+	// Cada categoría suma también en el subtotal de ella misma y de sus
+	// ancestros, que incluye las ventas de todas sus subcategorías.
+	parts := strings.Split(ps.Category, " > ")
+	for i := 1; i <= len(parts); i++ {
+		path := strings.Join(parts[:i], " > ")
+		if b.categoryTrees[path] == nil {
+			b.categoryTrees[path] = &GroupSummary{Name: path}
+		}
+		b.categoryTrees[path].add(s)
+		if i < len(parts) {
+			b.parents[path] = true
+		}
+	}
+
+	if b.brands[brand] == nil {
+		b.brands[brand] = &GroupSummary{Name: brand}
+	}
+	b.brands[brand].add(s)
+
+	if b.locations[s.LocationID] == nil {
+		b.locations[s.LocationID] = &GroupSummary{Name: b.locationNames[s.LocationID]}
+	}
+	b.locations[s.LocationID].add(s)
+}
+
+// summary devuelve los totales acumulados con los grupos ordenados por nombre.
+func (b *reportBuilder) summary(deliveries []models.CashDelivery) ReportSummary {
+	summary := b.totals
+	for _, d := range deliveries {
+		summary.TotalCashDelivered += d.Amount
+	}
+	summary.Products = sortedGroups(b.products)
+	summary.Categories = sortedGroups(b.categories)
+	// Solo las categorías con subcategorías vendidas tienen subtotal propio.
+	parentCategories := make(map[string]*GroupSummary, len(b.parents))
+	for path := range b.parents {
+		parentCategories[path] = b.categoryTrees[path]
+	}
+	summary.ParentCategories = sortedGroups(parentCategories)
+	summary.Brands = sortedGroups(b.brands)
+	summary.Locations = sortedGroups(b.locations)
+	return summary
+}
+
+// sortedGroups devuelve los grupos ordenados por categoría y nombre.
+func sortedGroups[K comparable](groups map[K]*GroupSummary) []GroupSummary {
+	result := make([]GroupSummary, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Category != result[j].Category {
+			return result[i].Category < result[j].Category
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// ExportReportToPDF genera y guarda un archivo PDF del reporte. Las ventas del
+// período se vuelven a recorrer de a una para el detalle.
+func ExportReportToPDF(title string, start, end time.Time, saleRepo *repository.SaleRepo, summary ReportSummary) error {
 	pdf := gofpdf.New("P", "mm", "A4", "")
+	// Traduce a la codificación de las fuentes estándar los textos con acentos.
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 16)
-	
+
 	// Título del PDF
 	pdf.Cell(40, 10, title)
 	pdf.Ln(8)
@@ -122,32 +391,92 @@ func ExportReportToPDF(title string, start, end time.Time, sales []models.Sale,
 	pdf.Ln(12)
 
 	// Encabezados de la tabla
-	pdf.SetFont("Arial", "B", 10)
-	pdf.Cell(15, 7, "ID")
-	pdf.Cell(25, 7, "Fecha")
-	pdf.Cell(40, 7, "Cliente")
+	pdf.SetFont("Arial", "B", 9)
+	pdf.Cell(10, 7, "ID")
+	pdf.Cell(20, 7, "Fecha")
+	pdf.Cell(32, 7, "Cliente")
 	pdf.Cell(30, 7, "Producto")
-	pdf.Cell(20, 7, "Cantidad")
-	pdf.Cell(20, 7, "Total")
-	pdf.Cell(20, 7, "Estado")
+	pdf.Cell(14, 7, "Cant.")
+	pdf.Cell(18, 7, "Total")
+	pdf.Cell(18, 7, "Costo")
+	pdf.Cell(18, 7, "Ganancia")
+	pdf.Cell(14, 7, "Margen")
+	pdf.Cell(16, 7, "Estado")
 	pdf.Ln(-1)
 
 	// Líneas de la tabla
-	pdf.SetFont("Arial", "", 10)
-	for _, s := range sales {
-		product, _ := productRepo.GetProductByID(s.ProductID)
-		productName := "N/A"
-		if product != nil {
-			productName = product.Name
+	pdf.SetFont("Arial", "", 9)
+	err := saleRepo.EachReportSale(start, end, false, func(row repository.ReportRow) error {
+		s := row.Sale
+		pdf.Cell(10, 7, strconv.Itoa(s.ID))
+		pdf.Cell(20, 7, s.Date.Format("02/01/2006"))
+		pdf.Cell(32, 7, tr(s.Client))
+		pdf.Cell(30, 7, tr(s.ProductName))
+		pdf.Cell(14, 7, formatQuantity(s.Quantity))
+		pdf.Cell(18, 7, fmt.Sprintf("%.2f", s.Total))
+		pdf.Cell(18, 7, fmt.Sprintf("%.2f", s.Cost))
+		pdf.Cell(18, 7, fmt.Sprintf("%.2f", s.Profit()))
+		pdf.Cell(14, 7, fmt.Sprintf("%.1f%%", s.Margin()))
+		pdf.Cell(16, 7, s.Status)
+		pdf.Ln(-1)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	pdf.Ln(6)
+
+	// Productos agrupados por categoría con subtotales
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(50, 7, "Ventas por Categoria:")
+	pdf.Ln(-1)
+	writeGroupHeader(pdf, "Producto")
+	for _, c := range summary.Categories {
+		pdf.SetFont("Arial", "B", 9)
+		pdf.Cell(190, 7, tr(c.Name))
+		pdf.Ln(-1)
+		pdf.SetFont("Arial", "", 9)
+		for _, p := range summary.Products {
+			if p.Category == c.Name {
+				writeGroupRow(pdf, tr("   "+p.Name), p)
+			}
 		}
-		pdf.Cell(15, 7, strconv.Itoa(s.ID))
-		pdf.Cell(25, 7, s.Date.Format("02/01/2006"))
-		pdf.Cell(40, 7, s.Client)
-		pdf.Cell(30, 7, productName)
-		pdf.Cell(20, 7, strconv.Itoa(s.Quantity))
-		pdf.Cell(20, 7, fmt.Sprintf("%.2f", s.Total))
-		pdf.Cell(20, 7, s.Status)
+		pdf.SetFont("Arial", "B", 9)
+		writeGroupRow(pdf, tr("Subtotal "+c.Name), c)
+	}
+	if len(summary.ParentCategories) > 0 {
+		pdf.Ln(4)
+		pdf.SetFont("Arial", "B", 9)
+		pdf.Cell(190, 7, tr("Subtotales por categoría padre (incluyen sus subcategorías)"))
 		pdf.Ln(-1)
+		for _, c := range summary.ParentCategories {
+			writeGroupRow(pdf, tr("Subtotal "+c.Name), c)
+		}
+	}
+
+	pdf.Ln(6)
+
+	// Subtotales por marca
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(50, 7, "Ventas por Marca:")
+	pdf.Ln(-1)
+	writeGroupHeader(pdf, "Marca")
+	pdf.SetFont("Arial", "", 9)
+	for _, b := range summary.Brands {
+		writeGroupRow(pdf, tr(b.Name), b)
+	}
+
+	pdf.Ln(6)
+
+	// Subtotales por ubicación
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(50, 7, tr("Ventas por Ubicación:"))
+	pdf.Ln(-1)
+	writeGroupHeader(pdf, tr("Ubicación"))
+	pdf.SetFont("Arial", "", 9)
+	for _, l := range summary.Locations {
+		writeGroupRow(pdf, tr(l.Name), l)
 	}
 
 	pdf.Ln(10) // Espacio entre la tabla y el resumen
@@ -157,18 +486,234 @@ func ExportReportToPDF(title string, start, end time.Time, sales []models.Sale,
 	pdf.Cell(50, 7, "Resumen:")
 	pdf.Ln(-1)
 	pdf.SetFont("Arial", "", 12)
-	pdf.Cell(50, 7, fmt.Sprintf("Total de Ventas: %.2f", totalSalesAmount))
+	pdf.Cell(50, 7, fmt.Sprintf("Total de Ventas: %.2f", summary.TotalSales))
+	pdf.Ln(-1)
+	pdf.Cell(50, 7, fmt.Sprintf("Total de Productos Vendidos: %s", formatQuantity(summary.TotalProductsSold)))
+	pdf.Ln(-1)
+	pdf.Cell(50, 7, fmt.Sprintf("Costo de lo Vendido: %.2f", summary.TotalCost))
+	pdf.Ln(-1)
+	pdf.Cell(50, 7, fmt.Sprintf("Ganancia Bruta: %.2f (Margen %.1f%%)", summary.GrossProfit(), summary.Margin()))
+	pdf.Ln(-1)
+	pdf.Cell(50, 7, fmt.Sprintf("Total de Dinero Entregado: %.2f", summary.TotalCashDelivered))
 	pdf.Ln(-1)
-	pdf.Cell(50, 7, fmt.Sprintf("Total de Productos Vendidos: %d", totalProductsSold))
+	pdf.Cell(50, 7, tr(fmt.Sprintf("Cobrado con Tarjetas de Regalo y Crédito: %.2f", summary.TotalGiftCardPayments)))
 	pdf.Ln(-1)
-	pdf.Cell(50, 7, fmt.Sprintf("Total de Dinero Entregado: %.2f", totalCashDelivered))
+	pdf.Cell(50, 7, tr(fmt.Sprintf("Tarjetas de Regalo Vendidas (no son venta): %.2f", summary.TotalGiftCardsSold)))
 	pdf.Ln(-1)
-	pdf.Cell(50, 7, fmt.Sprintf("Neto (Ventas - Entregas): %.2f", totalSalesAmount-totalCashDelivered))
+	pdf.Cell(50, 7, tr(fmt.Sprintf("Costos de Envío Cobrados: %.2f", summary.TotalShippingFees)))
+	pdf.Ln(-1)
+	pdf.Cell(50, 7, tr(fmt.Sprintf("Neto (Ventas + Envíos + Tarjetas vendidas - Entregas - Cobrado con tarjetas): %.2f", summary.Net())))
+	pdf.Ln(-1)
+	for _, c := range summary.ForeignCash {
+		pdf.Cell(50, 7, fmt.Sprintf("Efectivo en %s: %.2f (equivale a %.2f %s)", c.Currency, c.Amount, c.BaseAmount, baseCurrency()))
+		pdf.Ln(-1)
+	}
 
 	// Guardar el PDF
 	fileName := strings.ReplaceAll(title, " ", "_") + "_" + time.Now().Format("2006-01-02") + ".pdf"
-	err := pdf.OutputFileAndClose(fileName)
-	if err != nil {
-		fmt.Printf("Error al crear el archivo PDF: %v\n", err)
+	return pdf.OutputFileAndClose(fileName)
+}
+
+// ExportCommissionToPDF genera la planilla de pago de comisiones de un
+// vendedor para un mes, con el detalle de cada venta.
+func ExportCommissionToPDF(statement models.CommissionStatement, fileName string) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+
+	pdf.Cell(40, 10, tr("Liquidación de Comisiones"))
+	pdf.Ln(8)
+	pdf.SetFont("Arial", "", 12)
+	pdf.Cell(40, 10, tr("Vendedor: "+statement.Seller.Name))
+	pdf.Ln(6)
+	pdf.Cell(40, 10, "Mes: "+statement.Month.Format("01/2006"))
+	pdf.Ln(6)
+	pdf.Cell(40, 10, tr(fmt.Sprintf("Ventas del mes: %.2f - Comisión sobre %s, %.2f%% general", statement.Volume, strings.ToLower(statement.Basis), statement.TierPercent)))
+	pdf.Ln(12)
+
+	pdf.SetFont("Arial", "B", 9)
+	pdf.Cell(16, 7, "Venta")
+	pdf.Cell(22, 7, "Fecha")
+	pdf.Cell(64, 7, "Producto")
+	pdf.Cell(26, 7, "Total")
+	pdf.Cell(26, 7, "Base")
+	pdf.Cell(14, 7, "%")
+	pdf.Cell(24, 7, tr("Comisión"))
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 9)
+	for _, l := range statement.Lines {
+		pdf.Cell(16, 7, fmt.Sprintf("#%d", l.SaleID))
+		pdf.Cell(22, 7, l.Date.Format("02/01/2006"))
+		pdf.Cell(64, 7, tr(l.ProductName))
+		pdf.Cell(26, 7, fmt.Sprintf("%.2f", l.Total))
+		pdf.Cell(26, 7, fmt.Sprintf("%.2f", l.Base))
+		pdf.Cell(14, 7, strconv.FormatFloat(l.Percent, 'f', -1, 64))
+		pdf.Cell(24, 7, fmt.Sprintf("%.2f", l.Commission))
+		pdf.Ln(-1)
+	}
+
+	pdf.Ln(4)
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(40, 10, fmt.Sprintf("Total a pagar: %.2f", statement.Total))
+	pdf.Ln(20)
+	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(80, 7, "______________________________")
+	pdf.Ln(5)
+	pdf.Cell(80, 7, tr("Firma del vendedor"))
+
+	return pdf.OutputFileAndClose(fileName)
+}
+
+// ExportDispatchListToPDF genera la hoja de ruta de un día con los envíos
+// agrupados por zona, para que el repartidor firme cada entrega.
+func ExportDispatchListToPDF(date time.Time, shipments []models.Shipment, fileName string) error {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+
+	pdf.Cell(40, 10, "Hoja de Ruta")
+	pdf.Ln(8)
+	pdf.SetFont("Arial", "", 12)
+	pdf.Cell(40, 10, "Fecha: "+date.Format("02/01/2006"))
+	pdf.Ln(12)
+
+	writeHeader := func() {
+		pdf.SetFont("Arial", "B", 9)
+		pdf.Cell(14, 7, tr("Envío"))
+		pdf.Cell(36, 7, "Cliente")
+		pdf.Cell(60, 7, tr("Dirección"))
+		pdf.Cell(28, 7, tr("Teléfono"))
+		pdf.Cell(50, 7, "Detalle")
+		pdf.Cell(28, 7, "Repartidor")
+		pdf.Cell(18, 7, "Costo")
+		pdf.Cell(18, 7, "Pago")
+		pdf.Cell(25, 7, "Firma")
+		pdf.Ln(-1)
+	}
+
+	zone, count, fees, total := "", 0, 0.0, 0.0
+	writeSubtotal := func() {
+		pdf.SetFont("Arial", "B", 9)
+		pdf.Cell(216, 7, tr(fmt.Sprintf("Subtotal %s: %d envío(s)", zone, count)))
+		pdf.Cell(18, 7, fmt.Sprintf("%.2f", fees))
+		pdf.Ln(10)
+	}
+	for i, s := range shipments {
+		if i == 0 || !strings.EqualFold(shipmentZone(s), zone) {
+			if i > 0 {
+				writeSubtotal()
+			}
+			zone, count, fees = shipmentZone(s), 0, 0
+			pdf.SetFont("Arial", "B", 12)
+			pdf.Cell(40, 8, tr("Zona: "+zone))
+			pdf.Ln(-1)
+			writeHeader()
+		}
+		pdf.SetFont("Arial", "", 9)
+		pdf.Cell(14, 7, fmt.Sprintf("#%d", s.ID))
+		pdf.Cell(36, 7, tr(s.Client))
+		pdf.Cell(60, 7, tr(s.Address))
+		pdf.Cell(28, 7, tr(s.Phone))
+		detail := ""
+		if len(s.Items) > 0 {
+			detail = fmt.Sprintf("%s x%s", s.Items[0].ProductName, formatQuantity(s.Items[0].Quantity))
+		}
+		pdf.Cell(50, 7, tr(detail))
+		pdf.Cell(28, 7, tr(s.Courier))
+		pdf.Cell(18, 7, fmt.Sprintf("%.2f", s.Fee))
+		pdf.Cell(18, 7, s.SaleStatus)
+		pdf.Cell(25, 7, "____________")
+		pdf.Ln(-1)
+		// El resto de las ventas del envío van debajo, en la columna del detalle.
+		for i := 1; i < len(s.Items); i++ {
+			pdf.Cell(138, 6, "")
+			pdf.Cell(50, 6, tr(fmt.Sprintf("%s x%s", s.Items[i].ProductName, formatQuantity(s.Items[i].Quantity))))
+			pdf.Ln(-1)
+		}
+		count++
+		fees += s.Fee
+		total += s.Fee
+	}
+	writeSubtotal()
+
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(40, 10, tr(fmt.Sprintf("Total: %d envío(s), costo de envío %.2f", len(shipments), total)))
+
+	return pdf.OutputFileAndClose(fileName)
+}
+
+// ExportStatementToPDF genera el estado de cuenta de un cliente con el saldo
+// anterior, los movimientos del período con su saldo y el total a pagar.
+func ExportStatementToPDF(statement models.Statement, fileName string) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+
+	pdf.Cell(40, 10, "Estado de Cuenta")
+	pdf.Ln(8)
+	pdf.SetFont("Arial", "", 12)
+	pdf.Cell(40, 10, tr("Cliente: "+statement.Customer.Name))
+	pdf.Ln(6)
+	pdf.Cell(40, 10, fmt.Sprintf("Periodo: %s a %s", statement.Start.Format("02/01/2006"), statement.End.Format("02/01/2006")))
+	pdf.Ln(12)
+
+	pdf.SetFont("Arial", "B", 9)
+	pdf.Cell(22, 7, "Fecha")
+	pdf.Cell(86, 7, "Concepto")
+	pdf.Cell(26, 7, "Debe")
+	pdf.Cell(26, 7, "Haber")
+	pdf.Cell(26, 7, "Saldo")
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 9)
+	pdf.Cell(22, 7, statement.Start.Format("02/01/2006"))
+	pdf.Cell(86, 7, "Saldo anterior")
+	pdf.Cell(26, 7, "")
+	pdf.Cell(26, 7, "")
+	pdf.Cell(26, 7, fmt.Sprintf("%.2f", statement.Opening))
+	pdf.Ln(-1)
+	for _, l := range statement.Lines {
+		pdf.Cell(22, 7, l.Date.Format("02/01/2006"))
+		pdf.Cell(86, 7, tr(l.Description))
+		pdf.Cell(26, 7, statementAmount(l.Debit))
+		pdf.Cell(26, 7, statementAmount(l.Credit))
+		pdf.Cell(26, 7, fmt.Sprintf("%.2f", l.Balance))
+		pdf.Ln(-1)
 	}
+
+	pdf.Ln(4)
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(40, 10, fmt.Sprintf("Saldo a pagar: %.2f", statement.Closing()))
+	if statement.Customer.PaymentTerms > 0 {
+		pdf.Ln(8)
+		pdf.SetFont("Arial", "", 9)
+		pdf.Cell(40, 7, tr(fmt.Sprintf("Condición de pago: %d días desde la fecha de cada venta.", statement.Customer.PaymentTerms)))
+	}
+
+	return pdf.OutputFileAndClose(fileName)
+}
+
+func writeGroupHeader(pdf *gofpdf.Fpdf, label string) {
+	pdf.SetFont("Arial", "B", 9)
+	pdf.Cell(70, 7, label)
+	pdf.Cell(20, 7, "Cantidad")
+	pdf.Cell(25, 7, "Ventas")
+	pdf.Cell(25, 7, "Costo")
+	pdf.Cell(25, 7, "Ganancia")
+	pdf.Cell(20, 7, "Margen")
+	pdf.Ln(-1)
+}
+
+func writeGroupRow(pdf *gofpdf.Fpdf, label string, g GroupSummary) {
+	pdf.Cell(70, 7, label)
+	pdf.Cell(20, 7, formatQuantity(g.Quantity))
+	pdf.Cell(25, 7, fmt.Sprintf("%.2f", g.Total))
+	pdf.Cell(25, 7, fmt.Sprintf("%.2f", g.Cost))
+	pdf.Cell(25, 7, fmt.Sprintf("%.2f", g.Profit()))
+	pdf.Cell(20, 7, fmt.Sprintf("%.1f%%", g.Margin()))
+	pdf.Ln(-1)
 }
\ No newline at end of file
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ShowRFMReport puntúa a los clientes por recencia, frecuencia y valor
+// monetario de sus compras, los agrupa en segmentos y permite exportar las
+// listas de cada segmento a CSV.
+func ShowRFMReport(saleRepo *repository.SaleRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	activity, err := saleRepo.GetCustomerActivity()
+	if err != nil {
+		fmt.Println("Error al obtener las compras de los clientes:", err)
+		return
+	}
+	if len(activity) == 0 {
+		fmt.Println("No hay ventas con cliente para analizar.")
+		return
+	}
+	scores := models.ScoreRFM(activity, time.Now())
+	sortRFMScores(scores)
+
+	fmt.Println("\n--- Análisis RFM de Clientes ---")
+	fmt.Println("R = recencia, F = frecuencia, M = valor monetario (1 a 5, 5 es mejor)")
+	fmt.Printf("\n%-22s | %-8s | %-12s | %-8s\n", "Segmento", "Clientes", "Total", "% Ventas")
+	fmt.Println("------------------------------------------------------------")
+	var grandTotal float64
+	for _, s := range scores {
+		grandTotal += s.Total
+	}
+	for _, segment := range models.RFMSegments {
+		count, total := 0, 0.0
+		for _, s := range scores {
+			if s.Segment == segment {
+				count++
+				total += s.Total
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		fmt.Printf("%-22s | %-8d | %-12.2f | %7.1f%%\n", segment, count, total, total/grandTotal*100)
+	}
+
+	fmt.Printf("\n%-22s | %-20s | %-3s | %-3s | %-3s | %-12s | %-7s | %-10s\n", "Segmento", "Cliente", "R", "F", "M", "Última", "Compras", "Total")
+	fmt.Println("---------------------------------------------------------------------------------------------------------")
+	for _, s := range scores {
+		fmt.Printf("%-22s | %-20s | %-3d | %-3d | %-3d | %-12s | %-7d | %-10.2f\n", s.Segment, s.Client, s.Recency, s.Frequency, s.Monetary, s.LastPurchase.Format("02/01/2006"), s.Orders, s.Total)
+	}
+
+	fmt.Print("\n¿Desea exportar las listas de segmentos a CSV? (s/n): ")
+	exportChoice, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(exportChoice)) != "s" {
+		return
+	}
+	fileName := "Segmentos_RFM_" + time.Now().Format("2006-01-02") + ".csv"
+	if err := exportRFMToCSV(fileName, scores); err != nil {
+		fmt.Println("Error al exportar el CSV:", err)
+		return
+	}
+	fmt.Println("Segmentos exportados a", fileName)
+}
+
+// sortRFMScores ordena los clientes por segmento y, dentro de cada uno, por
+// total comprado de mayor a menor.
+func sortRFMScores(scores []models.RFMScore) {
+	position := make(map[string]int)
+	for i, segment := range models.RFMSegments {
+		position[segment] = i
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Segment != scores[j].Segment {
+			return position[scores[i].Segment] < position[scores[j].Segment]
+		}
+		return scores[i].Total > scores[j].Total
+	})
+}
+
+// exportRFMToCSV guarda los clientes con su puntuación, agrupados por segmento.
+func exportRFMToCSV(fileName string, scores []models.RFMScore) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"Segmento", "Cliente", "R", "F", "M", "Primera compra", "Ultima compra", "Dias sin comprar", "Compras", "Total"})
+	for _, s := range scores {
+		w.Write([]string{
+			s.Segment,
+			s.Client,
+			strconv.Itoa(s.Recency),
+			strconv.Itoa(s.Frequency),
+			strconv.Itoa(s.Monetary),
+			s.FirstPurchase.Format("02/01/2006"),
+			s.LastPurchase.Format("02/01/2006"),
+			strconv.Itoa(s.DaysSinceLast),
+			strconv.Itoa(s.Orders),
+			fmt.Sprintf("%.2f", s.Total),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
\ No newline at end of file
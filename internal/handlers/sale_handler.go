@@ -11,7 +11,7 @@ import (
 	"time"
 )
 
-// PreviewProducts muestra una lista simple de productos (ID y Nombre) para referencia.
+// PreviewProducts muestra una lista simple de productos (ID, SKU y Nombre) para referencia.
 func PreviewProducts(productRepo *repository.ProductRepo) {
 	products, err := productRepo.GetAllProducts()
 	if err != nil {
@@ -19,17 +19,17 @@ func PreviewProducts(productRepo *repository.ProductRepo) {
 		return
 	}
 
-	fmt.Println("\n--- Productos Registrados (ID y Nombre) ---")
-	fmt.Printf("%-5s | %-20s\n", "ID", "Producto")
-	fmt.Println("-------------------------------")
+	fmt.Println("\n--- Productos Registrados (ID, SKU y Nombre) ---")
+	fmt.Printf("%-5s | %-10s | %-20s\n", "ID", "SKU", "Producto")
+	fmt.Println("------------------------------------------")
 	for _, p := range products {
-		fmt.Printf("%-5d | %-20s\n", p.ID, p.Name)
+		fmt.Printf("%-5d | %-10s | %-20s\n", p.ID, p.SKU, p.Name)
 	}
-	fmt.Println("-------------------------------")
+	fmt.Println("------------------------------------------")
 }
 
 // RegisterSale maneja la lógica para registrar una nueva venta.
-func RegisterSale(saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo) {
+func RegisterSale(saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo, lotRepo *repository.LotRepo, customerRepo *repository.CustomerRepo, loyaltyRepo *repository.LoyaltyRepo, giftCardRepo *repository.GiftCardRepo, rateRepo *repository.ExchangeRateRepo, sellerRepo *repository.SellerRepo, shipmentRepo *repository.ShipmentRepo) {
 	reader := bufio.NewReader(os.Stdin)
 
 	fmt.Println("\n--- Registrar Venta ---")
@@ -49,64 +49,317 @@ func RegisterSale(saleRepo *repository.SaleRepo, productRepo *repository.Product
 	client, _ := reader.ReadString('\n')
 	client = strings.TrimSpace(client)
 
-	fmt.Print("ID del Producto: ")
-	productIDStr, _ := reader.ReadString('\n')
-	productID, err := strconv.Atoi(strings.TrimSpace(productIDStr))
+	sellerID := readSaleSeller(reader, sellerRepo)
+	locationID := readLocation(reader, locationRepo, "Ubicación de venta")
+
+	product, err := readProduct(reader, productRepo, "#ID, SKU o código de barras del Producto: ")
 	if err != nil {
-		fmt.Println("ID de producto inválido.")
+		fmt.Println("Error: Producto no encontrado. Verifique el ID o código.")
 		return
 	}
-
-	product, err := productRepo.GetProductByID(productID)
-	if err != nil {
-		fmt.Println("Error: Producto no encontrado. Verifique el ID.")
+	if product.Archived {
+		fmt.Println("Error: El producto está archivado y ya no se vende.")
+		return
+	}
+	if hasVariants(productRepo, product.ID) {
+		fmt.Println("Error: El producto tiene variantes. Indique la variante a vender (talle, color, etc.).")
 		return
 	}
 
-	fmt.Print("Cantidad: ")
+	fmt.Printf("Cantidad (%s): ", product.Unit)
 	quantityStr, _ := reader.ReadString('\n')
-	quantity, err := strconv.Atoi(strings.TrimSpace(quantityStr))
-	if err != nil {
+	quantity, err := parseQuantity(quantityStr)
+	if err != nil || product.RoundQuantity(quantity) <= 0 {
 		fmt.Println("Cantidad inválida. Operación cancelada.")
 		return
 	}
+	quantity = product.RoundQuantity(quantity)
+
+	if !checkKitStock(reader, productRepo, *product, locationID, quantity) {
+		fmt.Println("Operación cancelada.")
+		return
+	}
+	includeExpired, ok := checkExpiredLots(reader, lotRepo, *product, locationID, quantity, date)
+	if !ok {
+		fmt.Println("Operación cancelada.")
+		return
+	}
+
+	// Una venta en moneda extranjera puede llevar un precio propio en esa
+	// moneda; se registra convertido a moneda local.
+	price := product.Price
+	rate := readSaleCurrency(reader, rateRepo, date)
+	if rate != nil {
+		fmt.Printf("Precio unitario en %s (Enter = %.2f): ", rate.Currency, rate.ToForeign(price))
+		priceStr, _ := reader.ReadString('\n')
+		if strings.TrimSpace(priceStr) != "" {
+			foreignPrice, err := parseAmount(priceStr)
+			if err != nil || foreignPrice <= 0 {
+				fmt.Println("Precio inválido. Operación cancelada.")
+				return
+			}
+			price = rate.ToBase(foreignPrice)
+		}
+	}
 
 	// El sistema multiplica la cantidad por el precio para obtener el total.
-	total := float64(quantity) * product.Price
+	total := quantity * price
+
+	status := readSaleStatus(reader)
+
+	// Los puntos canjeados se descuentan del total de la venta.
+	customer, loyalty, inProgram := loyaltyAccount(customerRepo, loyaltyRepo, client)
+	redeemed := 0
+	if inProgram {
+		var discount float64
+		redeemed, discount = readRedemption(reader, loyaltyRepo, customer, loyalty, total)
+		if redeemed > 0 {
+			total -= discount
+			fmt.Printf("Descuento por %d puntos: %.2f. Total a pagar: %.2f\n", redeemed, discount, total)
+		}
+	}
 
-	fmt.Print("Estado (1. Pagado, 2. Pendiente): ")
-	statusChoiceStr, _ := reader.ReadString('\n')
-	statusChoice, err := strconv.Atoi(strings.TrimSpace(statusChoiceStr))
-	if err != nil || (statusChoice != 1 && statusChoice != 2) {
-		fmt.Println("Opción de estatus inválida. Usando 'Pendiente'.")
-		statusChoice = 2
+	if status == models.StatusPending && !checkCredit(reader, customerRepo, client, total, date) {
+		fmt.Println("Venta no registrada.")
+		return
 	}
-	status := models.StatusPending
-	if statusChoice == 1 {
-		status = models.StatusPaid
+
+	var cardPayments []models.GiftCardPayment
+	covered := 0.0
+	if status == models.StatusPaid {
+		cardPayments, covered = readGiftCardPayments(reader, giftCardRepo, total)
 	}
 
 	newSale := models.Sale{
 		Date:      date,
 		Client:    client,
-		ProductID: productID,
+		ProductID: product.ID,
 		Quantity:  quantity,
-		Price:     product.Price,
+		Price:     price,
 		Total:     total,
 		Status:    status,
+
+		LocationID: locationID,
+		SellerID:   sellerID,
+	}
+	// Lo que no se pagó con tarjetas se cobra en la moneda elegida.
+	if rate != nil && total-covered > 0.005 {
+		newSale.Currency = rate.Currency
+		newSale.CurrencyAmount = rate.ToForeign(total - covered)
+		newSale.ExchangeRate = rate.Rate
+		fmt.Printf("A cobrar: %s %.2f\n", rate.Currency, newSale.CurrencyAmount)
 	}
 
-	id, err := saleRepo.CreateSale(newSale)
+	var redemption models.PointsRedemption
+	if redeemed > 0 {
+		redemption = models.PointsRedemption{CustomerID: customer.ID, Points: redeemed}
+	}
+	id, err := saleRepo.RecordSale(newSale, includeExpired, redemption, cardPayments)
 	if err != nil {
 		fmt.Println("Error al registrar la venta:", err)
 		return
 	}
 
 	fmt.Printf("Venta registrada con éxito. ID: %d\n", id)
+	printGiftCardPayments(cardPayments)
+
+	if inProgram {
+		earned := 0
+		if status == models.StatusPaid {
+			earned = earnPoints(loyaltyRepo, loyalty, customer, *product, int(id), total, date)
+		}
+		printPointsBalance(loyaltyRepo, loyalty, customer, earned)
+	}
+	registerShipment(reader, shipmentRepo, []int{int(id)}, client, date)
+}
+
+// readSaleStatus pide el estado de pago de la venta; por defecto queda pendiente.
+func readSaleStatus(reader *bufio.Reader) string {
+	fmt.Print("Estado (1. Pagado, 2. Pendiente): ")
+	statusChoiceStr, _ := reader.ReadString('\n')
+	statusChoice, err := strconv.Atoi(strings.TrimSpace(statusChoiceStr))
+	if err != nil || (statusChoice != 1 && statusChoice != 2) {
+		fmt.Println("Opción de estatus inválida. Usando 'Pendiente'.")
+		statusChoice = 2
+	}
+	if statusChoice == 1 {
+		return models.StatusPaid
+	}
+	return models.StatusPending
+}
+
+// scannedItem es un producto acumulado durante una venta en modo escáner.
+type scannedItem struct {
+	Product  *models.Product
+	Quantity float64
+}
+
+// ScanSale registra una venta rápida leyendo códigos de barras o SKU, uno por
+// línea, tal como los envía un lector USB que actúa como teclado. Cada lectura
+// suma una unidad; se puede anteponer "cantidad*" para sumar varias. Los
+// productos fraccionables (por peso o medida) piden la cantidad si no se
+// indicó. Una línea vacía termina la venta.
+func ScanSale(saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo, lotRepo *repository.LotRepo, customerRepo *repository.CustomerRepo, loyaltyRepo *repository.LoyaltyRepo, giftCardRepo *repository.GiftCardRepo, rateRepo *repository.ExchangeRateRepo, sellerRepo *repository.SellerRepo, shipmentRepo *repository.ShipmentRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Venta Rápida (Modo Escáner) ---")
+
+	fmt.Print("Nombre del Cliente: ")
+	client, _ := reader.ReadString('\n')
+	client = strings.TrimSpace(client)
+
+	sellerID := readSaleSeller(reader, sellerRepo)
+	locationID := readLocation(reader, locationRepo, "Ubicación de venta")
+
+	fmt.Println("Escanee los productos (ej. 7791234567897 o 3*7791234567897). Línea vacía para terminar.")
+
+	var items []*scannedItem
+	byProduct := make(map[int]*scannedItem)
+	var total float64
+	for {
+		fmt.Print("> ")
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		quantity := 0.0
+		code := line
+		if qtyStr, rest, found := strings.Cut(line, "*"); found {
+			quantity, err = parseQuantity(qtyStr)
+			if err != nil || quantity <= 0 {
+				fmt.Println("  Cantidad inválida, se ignora la lectura.")
+				continue
+			}
+			code = rest
+		}
+
+		product, err := productRepo.GetProductByCode(code)
+		if err != nil {
+			fmt.Printf("  Código '%s' no encontrado.\n", code)
+			continue
+		}
+		if product.Archived {
+			fmt.Printf("  %s está archivado y ya no se vende.\n", product.Name)
+			continue
+		}
+		if hasVariants(productRepo, product.ID) {
+			fmt.Printf("  %s tiene variantes; escanee la variante.\n", product.Name)
+			continue
+		}
+		if quantity == 0 {
+			quantity = 1
+			if product.Decimals > 0 {
+				fmt.Printf("  Cantidad de %s (%s): ", product.Name, product.Unit)
+				qtyStr, _ := reader.ReadString('\n')
+				quantity, err = parseQuantity(qtyStr)
+				if err != nil || quantity <= 0 {
+					fmt.Println("  Cantidad inválida, se ignora la lectura.")
+					continue
+				}
+			}
+		}
+		quantity = product.RoundQuantity(quantity)
+
+		item, ok := byProduct[product.ID]
+		if !ok {
+			item = &scannedItem{Product: product}
+			byProduct[product.ID] = item
+			items = append(items, item)
+		}
+		item.Quantity += quantity
+		total += quantity * product.Price
+		fmt.Printf("  %-20s x%-10s %10.2f   | Total: %.2f\n", product.Name, product.FormatQuantity(item.Quantity), item.Quantity*product.Price, total)
+	}
+
+	if len(items) == 0 {
+		fmt.Println("No se escanearon productos. Venta cancelada.")
+		return
+	}
+
+	status := readSaleStatus(reader)
+
+	// Cada producto se registra como una venta, igual que en el registro manual.
+	date := time.Now()
+	if status == models.StatusPending && !checkCredit(reader, customerRepo, client, total, date) {
+		fmt.Println("Venta cancelada.")
+		return
+	}
+	var cardPayments, paid []models.GiftCardPayment
+	cardLeft := 0.0
+	if status == models.StatusPaid {
+		cardPayments, cardLeft = readGiftCardPayments(reader, giftCardRepo, total)
+	}
+	rate := readSaleCurrency(reader, rateRepo, date)
+	customer, loyalty, inProgram := loyaltyAccount(customerRepo, loyaltyRepo, client)
+	earned := 0
+	var saleIDs []int
+	registered, foreign := 0.0, 0.0
+	fmt.Println("\n--- Ticket ---")
+	for _, item := range items {
+		sale := models.Sale{
+			Date:      date,
+			Client:    client,
+			ProductID: item.Product.ID,
+			Quantity:  item.Quantity,
+			Price:     item.Product.Price,
+			Total:     item.Quantity * item.Product.Price,
+			Status:    status,
+
+			LocationID: locationID,
+			SellerID:   sellerID,
+		}
+		// Las tarjetas cubren las primeras ventas del ticket y cada consumo
+		// queda asociado a la venta que pagó; el resto se cobra en la moneda
+		// elegida.
+		cover := min(cardLeft, sale.Total)
+		cards, rest := splitGiftCardPayments(cardPayments, cover)
+		if rate != nil && sale.Total-cover > 0.005 {
+			sale.Currency = rate.Currency
+			sale.CurrencyAmount = rate.ToForeign(sale.Total - cover)
+			sale.ExchangeRate = rate.Rate
+		}
+		if !checkKitStock(reader, productRepo, *item.Product, locationID, item.Quantity) {
+			fmt.Printf("%s no se registró.\n", item.Product.Name)
+			continue
+		}
+		includeExpired, ok := checkExpiredLots(reader, lotRepo, *item.Product, locationID, item.Quantity, date)
+		if !ok {
+			fmt.Printf("%s no se registró.\n", item.Product.Name)
+			continue
+		}
+		id, err := saleRepo.RecordSale(sale, includeExpired, models.PointsRedemption{}, cards)
+		if err != nil {
+			fmt.Printf("Error al registrar %s: %v\n", item.Product.Name, err)
+			continue
+		}
+		fmt.Printf("#%-5d %-20s %10s x %8.2f = %10.2f\n", id, item.Product.Name, item.Product.FormatQuantity(item.Quantity), item.Product.Price, sale.Total)
+		saleIDs = append(saleIDs, int(id))
+		registered += sale.Total
+		cardPayments, cardLeft = rest, cardLeft-cover
+		paid = append(paid, cards...)
+		foreign += sale.CurrencyAmount
+		if inProgram && status == models.StatusPaid {
+			earned += earnPoints(loyaltyRepo, loyalty, customer, *item.Product, int(id), sale.Total, date)
+		}
+	}
+	fmt.Printf("TOTAL: %.2f (%s)\n", registered, status)
+	if rate != nil && foreign > 0 {
+		fmt.Printf("A cobrar: %s %.2f (cotización %.2f)\n", rate.Currency, foreign, rate.Rate)
+	}
+	printGiftCardPayments(paid)
+	if inProgram {
+		printPointsBalance(loyaltyRepo, loyalty, customer, earned)
+	}
+	// Todas las ventas del ticket viajan en un mismo envío.
+	if len(saleIDs) > 0 {
+		registerShipment(reader, shipmentRepo, saleIDs, client, date)
+	}
 }
 
 // ShowSales visualiza todas las ventas registradas o los detalles de una venta específica.
-func ShowSales(saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo) {
+func ShowSales(saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo, lotRepo *repository.LotRepo) {
 	reader := bufio.NewReader(os.Stdin)
 	
 	sales, err := saleRepo.GetAllSales()
@@ -119,7 +372,7 @@ func ShowSales(saleRepo *repository.SaleRepo, productRepo *repository.ProductRep
 	fmt.Printf("%-5s | %-12s | %-20s | %-10s | %-8s\n", "ID", "Fecha", "Cliente", "Cantidad", "Estado")
 	fmt.Println("------------------------------------------------------------------")
 	for _, sale := range sales {
-		fmt.Printf("%-5d | %-12s | %-20s | %-10d | %-8s\n", sale.ID, sale.Date.Format("02/01/2006"), sale.Client, sale.Quantity, sale.Status)
+		fmt.Printf("%-5d | %-12s | %-20s | %-10s | %-8s\n", sale.ID, sale.Date.Format("02/01/2006"), sale.Client, formatQuantity(sale.Quantity), sale.Status)
 	}
 
 	fmt.Print("\nIngrese el ID de la venta para ver detalles completos (o presione Enter para volver): ")
@@ -140,30 +393,60 @@ func ShowSales(saleRepo *repository.SaleRepo, productRepo *repository.ProductRep
 		}
 
 		product, _ := productRepo.GetProductByID(sale.ProductID)
-		productName := "N/A"
-		if product != nil {
-			productName = product.Name
-		}
 		
 		fmt.Println("\n--- Detalles de la Venta ---")
 		fmt.Println("ID:", sale.ID)
 		fmt.Println("Fecha:", sale.Date.Format("02/01/2006"))
 		fmt.Println("Cliente:", sale.Client)
 		fmt.Println("Producto ID:", sale.ProductID)
-		fmt.Println("Nombre del Producto:", productName)
-		fmt.Println("Cantidad:", sale.Quantity)
+		fmt.Println("Nombre del Producto:", sale.ProductName)
+		if sale.ProductSKU != "" {
+			fmt.Println("SKU:", sale.ProductSKU)
+		}
+		if product != nil && product.Archived {
+			fmt.Println("(Producto archivado)")
+		}
+		if product != nil {
+			fmt.Println("Cantidad:", product.FormatQuantity(sale.Quantity))
+		} else {
+			fmt.Println("Cantidad:", formatQuantity(sale.Quantity))
+		}
+		if names, err := locationRepo.GetLocationNames(); err == nil {
+			fmt.Println("Ubicación:", names[sale.LocationID])
+		}
+		if components, err := productRepo.GetSaleComponents(sale.ID); err == nil && len(components) > 0 {
+			fmt.Println("Componentes del kit:")
+			for _, c := range components {
+				name := "N/A"
+				if component, err := productRepo.GetProductByID(c.ComponentID); err == nil {
+					name = component.Name
+				}
+				fmt.Printf("  - %s: %s\n", name, formatQuantity(c.Quantity))
+			}
+		}
+		if saleLots, err := lotRepo.GetSaleLots(sale.ID); err == nil && len(saleLots) > 0 {
+			fmt.Println("Lotes:")
+			for _, sl := range saleLots {
+				fmt.Printf("  - %s (vence %s): %s\n", sl.Number, sl.Expiry.Format("02/01/2006"), formatQuantity(sl.Quantity))
+			}
+		}
 		fmt.Println("Precio Unitario:", sale.Price)
 		fmt.Println("Total:", sale.Total)
+		if sale.Currency != "" {
+			fmt.Printf("Cobrado en %s: %.2f (cotización %.2f)\n", sale.Currency, sale.CurrencyAmount, sale.ExchangeRate)
+		}
+		fmt.Printf("Costo: %.2f\n", sale.Cost)
+		fmt.Printf("Ganancia: %.2f (%.1f%%)\n", sale.Profit(), sale.Margin())
 		fmt.Println("Estatus:", sale.Status)
 	}
 }
 
 // EditSale maneja la edición de los datos de una venta.
-func EditSale(saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo) {
+func EditSale(saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo, lotRepo *repository.LotRepo, customerRepo *repository.CustomerRepo, loyaltyRepo *repository.LoyaltyRepo, sellerRepo *repository.SellerRepo) {
 	reader := bufio.NewReader(os.Stdin)
 
 	fmt.Println("\n--- Editar Venta ---")
-	ShowSales(saleRepo, productRepo)
+	ShowSales(saleRepo, productRepo, locationRepo, lotRepo)
 
 	fmt.Print("\nIngrese el ID de la venta a editar: ")
 	idStr, _ := reader.ReadString('\n')
@@ -178,6 +461,16 @@ func EditSale(saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo
 		fmt.Println("Venta no encontrada.")
 		return
 	}
+	if sale.Status == models.StatusLayaway {
+		fmt.Println("La venta pertenece a un apartado; se gestiona desde Apartados y Cuotas.")
+		return
+	}
+	if sale.IsGiftCard() {
+		fmt.Println("La venta es de una tarjeta de regalo y no se puede editar.")
+		return
+	}
+
+	oldStatus, oldTotal, oldClient := sale.Status, sale.Total, sale.Client
 
 	fmt.Println("\nDeje los campos en blanco para mantener el valor actual.")
 
@@ -196,26 +489,37 @@ func EditSale(saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo
 		sale.Client = strings.TrimSpace(clientStr)
 	}
 	
-	fmt.Printf("Cantidad (actual: %d): ", sale.Quantity)
+	product, err := productRepo.GetProductByID(sale.ProductID)
+	if err != nil {
+		product = &models.Product{ID: sale.ProductID, Decimals: 3}
+	}
+	oldQuantity := sale.Quantity
+	fmt.Printf("Cantidad (actual: %s): ", formatQuantity(sale.Quantity))
 	quantityStr, _ := reader.ReadString('\n')
 	if strings.TrimSpace(quantityStr) != "" {
-		newQuantity, err := strconv.Atoi(strings.TrimSpace(quantityStr))
-		if err == nil {
-			sale.Quantity = newQuantity
+		newQuantity, err := parseQuantity(quantityStr)
+		if err == nil && newQuantity >= 0 {
+			sale.Quantity = product.RoundQuantity(newQuantity)
 		}
 	}
 
+	oldPrice := sale.Price
 	fmt.Printf("Precio (actual: %.2f): ", sale.Price)
 	priceStr, _ := reader.ReadString('\n')
 	if strings.TrimSpace(priceStr) != "" {
-		newPrice, err := strconv.ParseFloat(strings.TrimSpace(priceStr), 64)
+		newPrice, err := parseAmount(priceStr)
 		if err == nil {
 			sale.Price = newPrice
 		}
 	}
 
-	// Recalcular el total si la cantidad o el precio cambiaron
-	sale.Total = float64(sale.Quantity) * sale.Price
+	// Recalcular el total si la cantidad o el precio cambiaron. El total
+	// nuevo ya no lleva el descuento por puntos canjeados, que se reintegran.
+	repriced := sale.Quantity != oldQuantity || sale.Price != oldPrice
+	if repriced {
+		sale.Total = sale.Quantity * sale.Price
+		sale.RescaleCurrency(oldTotal)
+	}
 
 	fmt.Printf("Estado (actual: %s - 1. Pagado, 2. Pendiente): ", sale.Status)
 	statusChoiceStr, _ := reader.ReadString('\n')
@@ -230,15 +534,68 @@ func EditSale(saleRepo *repository.SaleRepo, productRepo *repository.ProductRepo
 		}
 	}
 
-	err = saleRepo.UpdateSale(*sale)
+	current := "ninguno"
+	if seller, err := sellerRepo.GetSellerByID(sale.SellerID); err == nil {
+		current = seller.Name
+	}
+	if seller := readSeller(reader, sellerRepo, fmt.Sprintf("Vendedor (actual: %s): ", current)); seller != nil {
+		sale.SellerID = seller.ID
+	}
+
+	// Lo que la edición agrega a la deuda del cliente pasa por el control de crédito.
+	if sale.Status == models.StatusPending {
+		increase := sale.Total
+		if oldStatus == models.StatusPending && strings.EqualFold(sale.Client, oldClient) {
+			increase -= oldTotal
+		}
+		if increase > 0 && !checkCredit(reader, customerRepo, sale.Client, increase, time.Now()) {
+			fmt.Println("Operación cancelada.")
+			return
+		}
+	}
+
+	// El stock y el costo de lo vendido se ajustan si cambió la cantidad.
+	includeExpired := false
+	if delta := sale.Quantity - oldQuantity; delta > 0 {
+		if !checkKitStock(reader, productRepo, *product, sale.LocationID, delta) {
+			fmt.Println("Operación cancelada.")
+			return
+		}
+		var ok bool
+		includeExpired, ok = checkExpiredLots(reader, lotRepo, *product, sale.LocationID, delta, sale.Date)
+		if !ok {
+			fmt.Println("Operación cancelada.")
+			return
+		}
+	}
+
+	// Los puntos ganados se recalculan sobre el total, el estado y el cliente
+	// nuevos.
+	var points models.PointsReversal
+	rescore := repriced || sale.Status != oldStatus || !strings.EqualFold(sale.Client, oldClient)
+	if rescore {
+		points = models.PointsReversal{Fraction: 1, RestoreRedeemed: repriced}
+	}
+
+	reversed, restored, err := saleRepo.EditSale(*sale, oldQuantity, includeExpired, points, time.Now())
 	if err != nil {
 		fmt.Println("Error al actualizar la venta:", err)
 		return
 	}
 	fmt.Println("Venta actualizada con éxito.")
+	printPointsReversal(reversed, restored)
+
+	if rescore {
+		customer, loyalty, inProgram := loyaltyAccount(customerRepo, loyaltyRepo, sale.Client)
+		if inProgram && sale.Status == models.StatusPaid {
+			earned := earnPoints(loyaltyRepo, loyalty, customer, *product, sale.ID, sale.Total, sale.Date)
+			printPointsBalance(loyaltyRepo, loyalty, customer, earned)
+		}
+	}
 }
 
-// DeleteSale maneja la eliminación de una venta.
+// DeleteSale maneja la eliminación de una venta, reingresa su mercadería al
+// stock y anula los puntos que ganó o reintegra los que se canjearon en ella.
 func DeleteSale(saleRepo *repository.SaleRepo) {
 	reader := bufio.NewReader(os.Stdin)
 
@@ -270,10 +627,41 @@ func DeleteSale(saleRepo *repository.SaleRepo) {
 		return
 	}
 
-	err = saleRepo.DeleteSale(id)
+	sale, err := saleRepo.GetSaleByID(id)
+	if err != nil {
+		fmt.Println("Venta no encontrada.")
+		return
+	}
+	if sale.Status == models.StatusLayaway {
+		fmt.Println("La venta pertenece a un apartado; se gestiona desde Apartados y Cuotas.")
+		return
+	}
+	if sale.IsGiftCard() {
+		fmt.Println("La venta es de una tarjeta de regalo y no se puede eliminar.")
+		return
+	}
+
+	reversed, restored, cards, err := saleRepo.DeleteSale(*sale, time.Now())
 	if err != nil {
 		fmt.Println("Error al eliminar la venta:", err)
 		return
 	}
 	fmt.Println("Venta eliminada con éxito.")
-}
\ No newline at end of file
+	printPointsReversal(reversed, restored)
+	for _, c := range cards {
+		fmt.Printf("Se devolvieron %.2f a la tarjeta %s.\n", c.Amount, c.Code)
+	}
+}
+
+// hasVariants indica si el producto es un producto padre con variantes, que
+// no se vende directamente sino a través de sus variantes.
+func hasVariants(productRepo *repository.ProductRepo, productID int) bool {
+	variants, err := productRepo.GetVariants(productID)
+	return err == nil && len(variants) > 0
+}
+
+// formatQuantity muestra una cantidad sin decimales sobrantes cuando no se
+// conoce la precisión del producto.
+func formatQuantity(quantity float64) string {
+	return strconv.FormatFloat(models.RoundQuantity(quantity, 3), 'f', -1, 64)
+}
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RegisterSeller da de alta un vendedor.
+func RegisterSeller(sellerRepo *repository.SellerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Registrar Vendedor ---")
+	fmt.Print("Nombre: ")
+	name, _ := reader.ReadString('\n')
+	name = strings.TrimSpace(name)
+	if name == "" {
+		fmt.Println("El nombre es obligatorio.")
+		return
+	}
+	if _, err := sellerRepo.GetSellerByName(name); err == nil {
+		fmt.Println("Ya existe un vendedor con ese nombre.")
+		return
+	}
+
+	id, err := sellerRepo.CreateSeller(models.Seller{Name: name})
+	if err != nil {
+		fmt.Println("Error al registrar el vendedor:", err)
+		return
+	}
+	fmt.Printf("Vendedor registrado con éxito. ID: %d\n", id)
+}
+
+// ShowSellers lista los vendedores con sus reglas de comisión y permite dar
+// de baja o reactivar a uno.
+func ShowSellers(sellerRepo *repository.SellerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	sellers, err := sellerRepo.GetAllSellers()
+	if err != nil {
+		fmt.Println("Error al obtener los vendedores:", err)
+		return
+	}
+
+	fmt.Println("\n--- Vendedores ---")
+	if len(sellers) == 0 {
+		fmt.Println("No hay vendedores registrados.")
+		return
+	}
+	fmt.Printf("%-5s | %-20s | %-8s | %-50s\n", "ID", "Nombre", "Estado", "Comisión")
+	fmt.Println("------------------------------------------------------------------------------------------")
+	for _, s := range sellers {
+		plan, err := sellerRepo.GetPlan(s.ID)
+		if err != nil {
+			fmt.Println("Error al obtener las reglas de comisión:", err)
+			return
+		}
+		fmt.Printf("%-5d | %-20s | %-8s | %-50s\n", s.ID, s.Name, sellerState(s), planSummary(plan))
+	}
+
+	seller := readSeller(reader, sellerRepo, "\nID o nombre del vendedor para dar de baja o reactivar (o Enter para volver): ")
+	if seller == nil {
+		return
+	}
+	if err := sellerRepo.SetSellerActive(seller.ID, !seller.Active); err != nil {
+		fmt.Println("Error al actualizar el vendedor:", err)
+		return
+	}
+	if seller.Active {
+		fmt.Printf("%s fue dado de baja.\n", seller.Name)
+	} else {
+		fmt.Printf("%s fue reactivado.\n", seller.Name)
+	}
+}
+
+func sellerState(s models.Seller) string {
+	if s.Active {
+		return "Activo"
+	}
+	return "Baja"
+}
+
+// planSummary describe en una línea las reglas de comisión.
+func planSummary(p models.CommissionPlan) string {
+	summary := fmt.Sprintf("%s%% de %s", strconv.FormatFloat(p.Percent, 'f', -1, 64), strings.ToLower(p.Basis))
+	if len(p.Tiers) > 0 {
+		summary += fmt.Sprintf(", %d escalones", len(p.Tiers))
+	}
+	if len(p.CategoryRates) > 0 {
+		summary += fmt.Sprintf(", %d categorías", len(p.CategoryRates))
+	}
+	return summary
+}
+
+// ConfigureCommission carga las reglas de comisión de un vendedor.
+func ConfigureCommission(sellerRepo *repository.SellerRepo, categoryRepo *repository.CategoryRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Reglas de Comisión ---")
+	seller := readSeller(reader, sellerRepo, "ID o nombre del vendedor: ")
+	if seller == nil {
+		return
+	}
+	plan, err := sellerRepo.GetPlan(seller.ID)
+	if err != nil {
+		fmt.Println("Error al obtener las reglas de comisión:", err)
+		return
+	}
+	fmt.Printf("Reglas actuales: %s\n", planSummary(plan))
+
+	fmt.Printf("Base de cálculo (1. Venta, 2. Margen; actual: %s): ", plan.Basis)
+	basisStr, _ := reader.ReadString('\n')
+	switch strings.TrimSpace(basisStr) {
+	case "1":
+		plan.Basis = models.CommissionOnSale
+	case "2":
+		plan.Basis = models.CommissionOnMargin
+	}
+
+	fmt.Printf("Porcentaje general (actual: %s): ", strconv.FormatFloat(plan.Percent, 'f', -1, 64))
+	if value, ok := readOptionalFloat(reader); ok {
+		plan.Percent = value
+	}
+
+	fmt.Println("Escalones por ventas del mes, como ventas_desde:porcentaje separados por coma")
+	fmt.Printf("(ej. 500000:4,1000000:5; actual: %s; Enter = sin cambios, - = ninguno): ", formatTiers(plan.Tiers))
+	tiersStr, _ := reader.ReadString('\n')
+	tiersStr = strings.TrimSpace(tiersStr)
+	if tiersStr != "" {
+		pairs, err := parseRatePairs(tiersStr)
+		if err != nil {
+			fmt.Printf("%v. Operación cancelada.\n", err)
+			return
+		}
+		plan.Tiers = nil
+		for from, percent := range pairs {
+			plan.Tiers = append(plan.Tiers, models.CommissionTier{From: from, Percent: percent})
+		}
+		sort.Slice(plan.Tiers, func(i, j int) bool { return plan.Tiers[i].From < plan.Tiers[j].From })
+	}
+
+	ShowCategories(categoryRepo)
+	fmt.Println("Porcentajes por categoría, como id_categoría:porcentaje separados por coma")
+	fmt.Printf("(ej. 3:10,7:2; actual: %s; Enter = sin cambios, - = ninguno): ", formatCategoryRates(plan.CategoryRates))
+	ratesStr, _ := reader.ReadString('\n')
+	ratesStr = strings.TrimSpace(ratesStr)
+	if ratesStr != "" {
+		pairs, err := parseRatePairs(ratesStr)
+		if err != nil {
+			fmt.Printf("%v. Operación cancelada.\n", err)
+			return
+		}
+		plan.CategoryRates = make(map[int]float64)
+		for id, percent := range pairs {
+			if id != float64(int(id)) || id <= 0 {
+				fmt.Printf("ID de categoría inválido: %s. Operación cancelada.\n", strconv.FormatFloat(id, 'f', -1, 64))
+				return
+			}
+			plan.CategoryRates[int(id)] = percent
+		}
+	}
+
+	if err := sellerRepo.SavePlan(plan); err != nil {
+		fmt.Println("Error al guardar las reglas de comisión:", err)
+		return
+	}
+	fmt.Printf("Reglas de comisión de %s actualizadas: %s\n", seller.Name, planSummary(plan))
+}
+
+// parseRatePairs interpreta una lista "clave:porcentaje,clave:porcentaje".
+// Un guion devuelve una lista vacía.
+func parseRatePairs(input string) (map[float64]float64, error) {
+	pairs := make(map[float64]float64)
+	if input == "-" {
+		return pairs, nil
+	}
+	for _, part := range strings.Split(input, ",") {
+		keyStr, percentStr, found := strings.Cut(part, ":")
+		key, keyErr := strconv.ParseFloat(strings.TrimSpace(keyStr), 64)
+		percent, percentErr := strconv.ParseFloat(strings.TrimSpace(percentStr), 64)
+		if !found || keyErr != nil || percentErr != nil || key < 0 || percent < 0 {
+			return nil, fmt.Errorf("valor inválido: %q", strings.TrimSpace(part))
+		}
+		pairs[key] = percent
+	}
+	return pairs, nil
+}
+
+func formatTiers(tiers []models.CommissionTier) string {
+	if len(tiers) == 0 {
+		return "ninguno"
+	}
+	parts := make([]string, len(tiers))
+	for i, t := range tiers {
+		parts[i] = fmt.Sprintf("%s:%s", strconv.FormatFloat(t.From, 'f', -1, 64), strconv.FormatFloat(t.Percent, 'f', -1, 64))
+	}
+	return strings.Join(parts, ",")
+}
+
+func formatCategoryRates(rates map[int]float64) string {
+	if len(rates) == 0 {
+		return "ninguno"
+	}
+	ids := make([]int, 0, len(rates))
+	for id := range rates {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprintf("%d:%s", id, strconv.FormatFloat(rates[id], 'f', -1, 64))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ShowCommissionReport liquida las comisiones de todos los vendedores en un
+// mes y permite exportar una planilla de pago en PDF por vendedor.
+func ShowCommissionReport(sellerRepo *repository.SellerRepo, categoryRepo *repository.CategoryRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Reporte Mensual de Comisiones ---")
+	month, ok := readMonth(reader)
+	if !ok {
+		return
+	}
+	end := month.AddDate(0, 1, 0).Add(-time.Second)
+
+	sellers, err := sellerRepo.GetAllSellers()
+	if err != nil {
+		fmt.Println("Error al obtener los vendedores:", err)
+		return
+	}
+	categories, err := categoryRepo.GetAllCategories()
+	if err != nil {
+		fmt.Println("Error al obtener las categorías:", err)
+		return
+	}
+	parents := make(map[int]int, len(categories))
+	for _, c := range categories {
+		parents[c.ID] = c.ParentID
+	}
+
+	var statements []models.CommissionStatement
+	for _, s := range sellers {
+		sales, err := sellerRepo.GetSellerSales(s.ID, month, end)
+		if err != nil {
+			fmt.Println("Error al obtener las ventas:", err)
+			return
+		}
+		if len(sales) == 0 {
+			continue
+		}
+		plan, err := sellerRepo.GetPlan(s.ID)
+		if err != nil {
+			fmt.Println("Error al obtener las reglas de comisión:", err)
+			return
+		}
+		statements = append(statements, plan.Calculate(s, month, sales, parents))
+	}
+
+	fmt.Printf("\nComisiones de %s\n", month.Format("01/2006"))
+	if len(statements) == 0 {
+		fmt.Println("No hay ventas con vendedor asignado en el mes.")
+		return
+	}
+	fmt.Printf("%-20s | %-7s | %-12s | %-8s | %-9s | %-12s\n", "Vendedor", "Ventas", "Volumen", "Base", "% Gral.", "Comisión")
+	fmt.Println("--------------------------------------------------------------------------------")
+	total := 0.0
+	for _, st := range statements {
+		fmt.Printf("%-20s | %-7d | %12.2f | %-8s | %8.2f%% | %12.2f\n", st.Seller.Name, len(st.Lines), st.Volume, st.Basis, st.TierPercent, st.Total)
+		total += st.Total
+	}
+	fmt.Printf("Total a pagar: %.2f\n", total)
+
+	fmt.Print("\n¿Generar las liquidaciones en PDF? (s/n): ")
+	choice, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(choice)) != "s" {
+		return
+	}
+	for _, st := range statements {
+		fileName := fmt.Sprintf("Comisiones_%s_%s.pdf", strings.ReplaceAll(st.Seller.Name, " ", "_"), month.Format("2006-01"))
+		if err := ExportCommissionToPDF(st, fileName); err != nil {
+			fmt.Printf("Error al crear la liquidación de %s: %v\n", st.Seller.Name, err)
+			continue
+		}
+		fmt.Printf("Liquidación generada: %s\n", fileName)
+	}
+}
+
+// readSeller pide un vendedor por ID o nombre. Devuelve nil si se deja en
+// blanco o no existe.
+func readSeller(reader *bufio.Reader, sellerRepo *repository.SellerRepo, prompt string) *models.Seller {
+	fmt.Print(prompt)
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil
+	}
+	var seller *models.Seller
+	var err error
+	if id, convErr := strconv.Atoi(input); convErr == nil {
+		seller, err = sellerRepo.GetSellerByID(id)
+	} else {
+		seller, err = sellerRepo.GetSellerByName(input)
+	}
+	if err != nil {
+		fmt.Println("Vendedor no encontrado.")
+		return nil
+	}
+	return seller
+}
+
+// readSaleSeller pide el vendedor de una venta entre los vendedores activos.
+// Si no hay vendedores registrados no pregunta. Devuelve 0 si la venta queda
+// sin vendedor.
+func readSaleSeller(reader *bufio.Reader, sellerRepo *repository.SellerRepo) int {
+	sellers, err := sellerRepo.GetAllSellers()
+	if err != nil {
+		return 0
+	}
+	var names []string
+	for _, s := range sellers {
+		if s.Active {
+			names = append(names, fmt.Sprintf("%d. %s", s.ID, s.Name))
+		}
+	}
+	if len(names) == 0 {
+		return 0
+	}
+	fmt.Printf("Vendedores: %s\n", strings.Join(names, " | "))
+	seller := readSeller(reader, sellerRepo, "Vendedor (ID o nombre, Enter = sin vendedor): ")
+	if seller == nil {
+		return 0
+	}
+	if !seller.Active {
+		fmt.Println("El vendedor está dado de baja; la venta queda sin vendedor.")
+		return 0
+	}
+	return seller.ID
+}
\ No newline at end of file
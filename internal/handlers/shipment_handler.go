@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// registerShipment pregunta si las ventas de un ticket se envían a domicilio
+// y, si es así, registra un único envío para todas. Propone la dirección y la
+// zona del último envío del cliente. El costo de envío se cobra aparte del
+// total de la venta.
+func registerShipment(reader *bufio.Reader, shipmentRepo *repository.ShipmentRepo, saleIDs []int, client string, date time.Time) {
+	fmt.Print("¿Envío a domicilio? (s/n): ")
+	choice, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(choice)) != "s" {
+		return
+	}
+
+	shipment := models.Shipment{Client: client}
+	if last, err := shipmentRepo.GetLastShipment(client); err == nil {
+		shipment.Address, shipment.Zone = last.Address, last.Zone
+	}
+
+	shipment.Address = readWithDefault(reader, "Dirección", shipment.Address)
+	if shipment.Address == "" {
+		fmt.Println("La dirección es obligatoria. La venta queda sin envío.")
+		return
+	}
+	shipment.Zone = readWithDefault(reader, "Zona", shipment.Zone)
+
+	shipment.ScheduledDate = date
+	fmt.Printf("Fecha de entrega (DD/MM/YYYY, Enter = %s): ", date.Format("02/01/2006"))
+	dateStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(dateStr) != "" {
+		scheduled, err := time.Parse("02/01/2006", strings.TrimSpace(dateStr))
+		if err != nil {
+			fmt.Println("Formato de fecha inválido. Se programa para la fecha de la venta.")
+		} else {
+			shipment.ScheduledDate = scheduled
+		}
+	}
+
+	shipment.Courier = readWithDefault(reader, "Repartidor", "")
+
+	fmt.Print("Costo de envío (Enter = sin cargo): ")
+	feeStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(feeStr) != "" {
+		fee, err := parseAmount(feeStr)
+		if err != nil || fee < 0 {
+			fmt.Println("Costo inválido. El envío queda sin cargo.")
+		} else {
+			shipment.Fee = fee
+		}
+	}
+
+	id, err := shipmentRepo.CreateShipment(shipment, saleIDs)
+	if err != nil {
+		fmt.Println("Error al registrar el envío:", err)
+		return
+	}
+	fmt.Printf("Envío registrado. ID: %d, entrega el %s", id, shipment.ScheduledDate.Format("02/01/2006"))
+	if shipment.Fee > 0 {
+		fmt.Printf(", costo de envío %.2f (se cobra aparte)", shipment.Fee)
+	}
+	fmt.Println()
+}
+
+// readWithDefault pide un texto; si se deja en blanco devuelve el valor por
+// defecto.
+func readWithDefault(reader *bufio.Reader, label, current string) string {
+	if current != "" {
+		fmt.Printf("%s (Enter = %s): ", label, current)
+	} else {
+		fmt.Printf("%s: ", label)
+	}
+	input, _ := reader.ReadString('\n')
+	if strings.TrimSpace(input) == "" {
+		return current
+	}
+	return strings.TrimSpace(input)
+}
+
+// ShowShipments lista los envíos que todavía no se entregaron.
+func ShowShipments(shipmentRepo *repository.ShipmentRepo) {
+	shipments, err := shipmentRepo.GetOpenShipments()
+	if err != nil {
+		fmt.Println("Error al obtener los envíos:", err)
+		return
+	}
+
+	fmt.Println("\n--- Envíos Pendientes de Entrega ---")
+	if len(shipments) == 0 {
+		fmt.Println("No hay envíos pendientes.")
+		return
+	}
+	fmt.Printf("%-5s | %-7s | %-12s | %-12s | %-18s | %-30s | %-12s | %8s | %-10s\n", "ID", "Venta", "Fecha", "Zona", "Cliente", "Dirección", "Repartidor", "Envío", "Estado")
+	fmt.Println("------------------------------------------------------------------------------------------------------------------------------------------")
+	for _, s := range shipments {
+		fmt.Printf("%-5d | %-7s | %-12s | %-12s | %-18s | %-30s | %-12s | %8.2f | %-10s\n",
+			s.ID, fmt.Sprintf("#%d", s.SaleID), s.ScheduledDate.Format("02/01/2006"), s.Zone, s.Client, s.Address, s.Courier, s.Fee, s.Status)
+		if s.Note != "" {
+			fmt.Printf("      Nota: %s\n", s.Note)
+		}
+	}
+}
+
+// UpdateShipmentStatus avanza un envío en su recorrido: pendiente, en ruta y
+// entregado o fallido. Un envío fallido se reprograma para otra fecha y
+// vuelve a quedar pendiente.
+func UpdateShipmentStatus(shipmentRepo *repository.ShipmentRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Actualizar Estado de Envío ---")
+	fmt.Print("ID del envío: ")
+	idStr, _ := reader.ReadString('\n')
+	id, err := strconv.Atoi(strings.TrimSpace(idStr))
+	if err != nil {
+		fmt.Println("ID inválido.")
+		return
+	}
+	shipment, err := shipmentRepo.GetShipmentByID(id)
+	if err != nil {
+		fmt.Println("Envío no encontrado.")
+		return
+	}
+
+	fmt.Printf("Envío #%d de %s a %s (%s) - Estado: %s\n", shipment.ID, shipment.Client, shipment.Address, shipment.Zone, shipment.Status)
+	next := shipment.NextStatuses()
+	if len(next) == 0 {
+		fmt.Println("El envío ya fue entregado.")
+		return
+	}
+	for i, status := range next {
+		fmt.Printf("%d. %s\n", i+1, status)
+	}
+	fmt.Print("Nuevo estado: ")
+	choiceStr, _ := reader.ReadString('\n')
+	choice, err := strconv.Atoi(strings.TrimSpace(choiceStr))
+	if err != nil || choice < 1 || choice > len(next) {
+		fmt.Println("Opción no válida.")
+		return
+	}
+	status := next[choice-1]
+
+	switch status {
+	case models.ShipmentOnRoute:
+		shipment.Courier = readWithDefault(reader, "Repartidor", shipment.Courier)
+	case models.ShipmentFailed:
+		fmt.Print("Motivo: ")
+		reason, _ := reader.ReadString('\n')
+		shipment.Note = strings.TrimSpace(reason)
+	case models.ShipmentPending:
+		fmt.Print("Nueva fecha de entrega (DD/MM/YYYY): ")
+		dateStr, _ := reader.ReadString('\n')
+		date, err := time.Parse("02/01/2006", strings.TrimSpace(dateStr))
+		if err != nil {
+			fmt.Println("Formato de fecha inválido. Operación cancelada.")
+			return
+		}
+		shipment.ScheduledDate = date
+	}
+	shipment.Status = status
+
+	if err := shipmentRepo.UpdateShipment(*shipment); err != nil {
+		fmt.Println("Error al actualizar el envío:", err)
+		return
+	}
+	fmt.Printf("Envío #%d: %s\n", shipment.ID, status)
+}
+
+// ShowDispatchList muestra los envíos a despachar en un día agrupados por
+// zona y permite exportar la hoja de ruta en PDF.
+func ShowDispatchList(shipmentRepo *repository.ShipmentRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Hoja de Ruta ---")
+	date := time.Now()
+	fmt.Printf("Fecha (DD/MM/YYYY, Enter = %s): ", date.Format("02/01/2006"))
+	dateStr, _ := reader.ReadString('\n')
+	if strings.TrimSpace(dateStr) != "" {
+		parsed, err := time.Parse("02/01/2006", strings.TrimSpace(dateStr))
+		if err != nil {
+			fmt.Println("Formato de fecha inválido.")
+			return
+		}
+		date = parsed
+	}
+
+	all, err := shipmentRepo.GetShipmentsByDate(date)
+	if err != nil {
+		fmt.Println("Error al obtener los envíos:", err)
+		return
+	}
+	// Solo se despachan los envíos que todavía no salieron o están en ruta.
+	var shipments []models.Shipment
+	for _, s := range all {
+		if s.Status == models.ShipmentPending || s.Status == models.ShipmentOnRoute {
+			shipments = append(shipments, s)
+		}
+	}
+
+	fmt.Printf("\nEnvíos del %s\n", date.Format("02/01/2006"))
+	if len(shipments) == 0 {
+		fmt.Println("No hay envíos para despachar.")
+		return
+	}
+	zone := ""
+	for i, s := range shipments {
+		if i == 0 || !strings.EqualFold(shipmentZone(s), zone) {
+			zone = shipmentZone(s)
+			fmt.Printf("\nZona: %s\n", zone)
+		}
+		fmt.Printf("  #%-4d %-18s %-30s %-14s Envío: %.2f\n", s.ID, s.Client, s.Address, s.Phone, s.Fee)
+		for _, item := range s.Items {
+			fmt.Printf("        %-20s x%s\n", item.ProductName, formatQuantity(item.Quantity))
+		}
+	}
+
+	fmt.Print("\n¿Generar la hoja de ruta en PDF? (s/n): ")
+	choice, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(choice)) != "s" {
+		return
+	}
+	fileName := fmt.Sprintf("Hoja_de_Ruta_%s.pdf", date.Format("2006-01-02"))
+	if err := ExportDispatchListToPDF(date, shipments, fileName); err != nil {
+		fmt.Println("Error al crear el archivo PDF:", err)
+		return
+	}
+	fmt.Printf("Hoja de ruta generada: %s\n", fileName)
+}
+
+// shipmentZone devuelve la zona del envío o una etiqueta si no se indicó.
+func shipmentZone(s models.Shipment) string {
+	if s.Zone == "" {
+		return "Sin zona"
+	}
+	return s.Zone
+}
\ No newline at end of file
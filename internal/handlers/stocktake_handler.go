@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StartStocktake abre una nueva toma de inventario físico en una ubicación.
+func StartStocktake(stocktakeRepo *repository.StocktakeRepo, locationRepo *repository.LocationRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	locationID := readLocation(reader, locationRepo, "Ubicación a contar")
+	id, err := stocktakeRepo.CreateStocktake(time.Now(), locationID)
+	if err != nil {
+		fmt.Println("Error al crear la toma de inventario:", err)
+		return
+	}
+	fmt.Printf("Toma de inventario #%d abierta. Cargue los conteos desde 'Cargar Conteo'.\n", id)
+}
+
+// CountStocktake carga cantidades contadas en una toma abierta. Se puede
+// escanear el código de barras o tipear el SKU o el #ID; un producto ya
+// contado se puede sumar (por ejemplo, otra estantería), reemplazar o quitar
+// de la toma.
+func CountStocktake(stocktakeRepo *repository.StocktakeRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Cargar Conteo ---")
+	stocktake, err := readStocktake(reader, stocktakeRepo, locationRepo, true)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	counted := make(map[int]float64, len(stocktake.Counts))
+	for _, c := range stocktake.Counts {
+		counted[c.ProductID] = c.Counted
+	}
+
+	fmt.Println("Ingrese el #ID, SKU o código de barras de cada producto. Línea vacía para terminar.")
+	saved := 0
+	for {
+		fmt.Print("> ")
+		code, _ := reader.ReadString('\n')
+		if strings.TrimSpace(code) == "" {
+			break
+		}
+		product, err := productRepo.GetProductByCode(code)
+		if err != nil {
+			fmt.Printf("  Código '%s' no encontrado.\n", strings.TrimSpace(code))
+			continue
+		}
+		if product.IsKit {
+			fmt.Println("  Los kits no tienen stock propio; cuente sus componentes.")
+			continue
+		}
+
+		previous, ok := counted[product.ID]
+		if ok {
+			fmt.Printf("  %s - ya contado: %s. Cantidad (+N suma, N reemplaza, - quita el conteo): ", product.Name, product.FormatQuantity(previous))
+		} else {
+			fmt.Printf("  %s - cantidad contada (%s): ", product.Name, product.Unit)
+		}
+		quantityStr, _ := reader.ReadString('\n')
+		quantityStr = strings.TrimSpace(quantityStr)
+		if ok && quantityStr == "-" {
+			if err := stocktakeRepo.DeleteCount(stocktake.ID, product.ID); err != nil {
+				fmt.Println("  Error al quitar el conteo:", err)
+				continue
+			}
+			delete(counted, product.ID)
+			fmt.Println("  Conteo quitado; el producto no se ajustará.")
+			continue
+		}
+		add := strings.HasPrefix(quantityStr, "+")
+		quantity, err := parseQuantity(strings.TrimPrefix(quantityStr, "+"))
+		if err != nil || quantity < 0 {
+			fmt.Println("  Cantidad inválida, se ignora el conteo.")
+			continue
+		}
+		if add {
+			quantity += previous
+		}
+		quantity = product.RoundQuantity(quantity)
+
+		if err := stocktakeRepo.SaveCount(*stocktake, *product, quantity); err != nil {
+			fmt.Println("  Error al guardar el conteo:", err)
+			continue
+		}
+		counted[product.ID] = quantity
+		saved++
+	}
+	fmt.Printf("%d conteo(s) guardados. Productos contados en la toma: %d.\n", saved, len(counted))
+}
+
+// ShowStocktakeVariance muestra el informe de diferencias de una toma: lo
+// esperado, lo contado y el impacto valorizado al costo.
+func ShowStocktakeVariance(stocktakeRepo *repository.StocktakeRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Informe de Diferencias ---")
+	stocktake, err := readStocktake(reader, stocktakeRepo, locationRepo, false)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	printStocktakeVariance(stocktake, productRepo)
+}
+
+// ApplyStocktake convierte las diferencias de una toma en movimientos de
+// ajuste, previa confirmación de un supervisor.
+func ApplyStocktake(stocktakeRepo *repository.StocktakeRepo, productRepo *repository.ProductRepo, locationRepo *repository.LocationRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Aplicar Toma de Inventario ---")
+	stocktake, err := readStocktake(reader, stocktakeRepo, locationRepo, true)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if len(stocktake.Counts) == 0 {
+		fmt.Println("La toma no tiene conteos cargados.")
+		return
+	}
+	printStocktakeVariance(stocktake, productRepo)
+
+	supervisor, ok := confirmSupervisor(reader, "aplicar los ajustes de stock")
+	if !ok {
+		fmt.Println("Operación cancelada.")
+		return
+	}
+
+	// Los sobrantes de productos con control de lotes entran en un lote.
+	lots := make(map[int]models.Lot)
+	for _, c := range stocktake.Counts {
+		if c.Variance() <= 0 || !c.HasVariance() {
+			continue
+		}
+		if product, err := productRepo.GetProductByID(c.ProductID); err == nil {
+			lots[c.ProductID] = readLot(reader, *product)
+		}
+	}
+
+	if err := stocktakeRepo.ApplyStocktake(stocktake.ID, supervisor, lots, time.Now()); err != nil {
+		fmt.Println("Error al aplicar la toma de inventario:", err)
+		return
+	}
+	fmt.Printf("Toma de inventario #%d aplicada con éxito.\n", stocktake.ID)
+}
+
+// CancelStocktake descarta una toma abierta sin modificar el stock.
+func CancelStocktake(stocktakeRepo *repository.StocktakeRepo, locationRepo *repository.LocationRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Cancelar Toma de Inventario ---")
+	stocktake, err := readStocktake(reader, stocktakeRepo, locationRepo, true)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("¿Está seguro de que desea cancelar la toma #%d? (s/n): ", stocktake.ID)
+	confirmation, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(confirmation)) != "s" {
+		fmt.Println("Operación cancelada.")
+		return
+	}
+	if err := stocktakeRepo.CancelStocktake(stocktake.ID); err != nil {
+		fmt.Println("Error al cancelar la toma de inventario:", err)
+		return
+	}
+	fmt.Println("Toma de inventario cancelada.")
+}
+
+// printStocktakeVariance imprime las diferencias de cada producto contado,
+// los totales de sobrantes y faltantes y cuántos productos quedaron sin contar.
+func printStocktakeVariance(stocktake *models.Stocktake, productRepo *repository.ProductRepo) {
+	products, err := productRepo.GetAllProducts()
+	if err != nil {
+		fmt.Println("Error al obtener productos:", err)
+		return
+	}
+	byID := make(map[int]models.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	fmt.Printf("\nToma #%d del %s - %s", stocktake.ID, stocktake.Date.Format("02/01/2006"), stocktake.Status)
+	if stocktake.Status == models.StocktakeApplied {
+		fmt.Printf(" el %s por %s", stocktake.AppliedDate.Format("02/01/2006"), stocktake.Supervisor)
+	}
+	fmt.Println()
+	fmt.Printf("%-5s | %-20s | %-12s | %-12s | %-12s | %-8s | %-10s\n", "ID", "Producto", "Sistema", "Contado", "Diferencia", "Costo", "Valor")
+	fmt.Println("---------------------------------------------------------------------------------------------------")
+
+	var surplus, shortage float64
+	differences := 0
+	for _, c := range stocktake.Counts {
+		p, ok := byID[c.ProductID]
+		if !ok {
+			p = models.Product{ID: c.ProductID, Name: "N/A", Decimals: 3}
+		}
+		if c.HasVariance() {
+			differences++
+		}
+		value := c.VarianceValue()
+		if value > 0 {
+			surplus += value
+		} else {
+			shortage += value
+		}
+		fmt.Printf("%-5d | %-20s | %-12s | %-12s | %-12s | %-8.2f | %-10.2f\n", p.ID, p.Name, p.FormatQuantity(c.Expected), p.FormatQuantity(c.Counted), fmt.Sprintf("%+.*f", p.Decimals, c.Variance()), c.UnitCost, value)
+	}
+
+	fmt.Printf("\nProductos contados: %d (con diferencias: %d)\n", len(stocktake.Counts), differences)
+	fmt.Printf("Productos sin contar: %d (no se ajustan)\n", len(products)-len(stocktake.Counts))
+	fmt.Printf("Sobrantes: %.2f\n", surplus)
+	fmt.Printf("Faltantes: %.2f\n", shortage)
+	fmt.Printf("Impacto neto: %.2f\n", surplus+shortage)
+}
+
+// readStocktake lista las tomas de inventario y pide una. Si solo se admiten
+// tomas abiertas y hay una sola, se elige directamente.
+func readStocktake(reader *bufio.Reader, stocktakeRepo *repository.StocktakeRepo, locationRepo *repository.LocationRepo, onlyOpen bool) (*models.Stocktake, error) {
+	stocktakes, err := stocktakeRepo.GetAllStocktakes()
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las tomas de inventario: %w", err)
+	}
+	names, err := locationRepo.GetLocationNames()
+	if err != nil {
+		return nil, fmt.Errorf("Error al obtener las ubicaciones: %w", err)
+	}
+
+	var listed []models.Stocktake
+	for _, t := range stocktakes {
+		if !onlyOpen || t.Status == models.StocktakeOpen {
+			listed = append(listed, t)
+		}
+	}
+	if len(listed) == 0 {
+		if onlyOpen {
+			return nil, fmt.Errorf("No hay tomas de inventario abiertas.")
+		}
+		return nil, fmt.Errorf("No hay tomas de inventario registradas.")
+	}
+
+	id := listed[0].ID
+	if !onlyOpen || len(listed) > 1 {
+		fmt.Printf("%-5s | %-12s | %-15s | %-10s | %-15s\n", "ID", "Fecha", "Ubicación", "Estado", "Supervisor")
+		fmt.Println("------------------------------------------------------------------")
+		for _, t := range listed {
+			fmt.Printf("%-5d | %-12s | %-15s | %-10s | %-15s\n", t.ID, t.Date.Format("02/01/2006"), names[t.LocationID], t.Status, t.Supervisor)
+		}
+		fmt.Printf("ID de la toma (Enter = %d): ", id)
+		idStr, _ := reader.ReadString('\n')
+		if strings.TrimSpace(idStr) != "" {
+			id, err = strconv.Atoi(strings.TrimSpace(idStr))
+			if err != nil {
+				return nil, fmt.Errorf("ID inválido.")
+			}
+		}
+	}
+
+	stocktake, err := stocktakeRepo.GetStocktakeByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("Toma de inventario no encontrada.")
+	}
+	if onlyOpen && stocktake.Status != models.StocktakeOpen {
+		return nil, fmt.Errorf("La toma de inventario #%d está %s.", id, strings.ToLower(stocktake.Status))
+	}
+	fmt.Printf("Toma de inventario #%d - %s\n", stocktake.ID, names[stocktake.LocationID])
+	return stocktake, nil
+}
+
+// confirmSupervisor pide el nombre y la clave del supervisor para autorizar
+// una operación. La clave se configura con la variable de entorno
+// SUPERVISOR_PIN; si no está definida ninguna operación que requiera
+// supervisor puede autorizarse.
+func confirmSupervisor(reader *bufio.Reader, action string) (string, bool) {
+	fmt.Printf("\nSe requiere un supervisor para %s.\n", action)
+	pin := os.Getenv("SUPERVISOR_PIN")
+	if pin == "" {
+		fmt.Println("No hay clave de supervisor configurada. Defina la variable de entorno SUPERVISOR_PIN para autorizar la operación.")
+		return "", false
+	}
+
+	fmt.Print("Nombre del supervisor: ")
+	name, _ := reader.ReadString('\n')
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", false
+	}
+
+	fmt.Print("Clave del supervisor: ")
+	input, _ := reader.ReadString('\n')
+	if strings.TrimSpace(input) != pin {
+		fmt.Println("Clave incorrecta.")
+		return "", false
+	}
+	return name, true
+}
\ No newline at end of file
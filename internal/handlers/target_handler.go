@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"sales-system/internal/utils"
+	"strings"
+	"time"
+)
+
+// SetSalesTarget define, cambia o elimina la meta de ventas de un mes para
+// un local, una categoría o un vendedor.
+func SetSalesTarget(targetRepo *repository.TargetRepo, locationRepo *repository.LocationRepo, categoryRepo *repository.CategoryRepo, sellerRepo *repository.SellerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Definir Meta de Ventas ---")
+	month, ok := readMonth(reader)
+	if !ok {
+		return
+	}
+
+	fmt.Println("Tipo de meta:")
+	fmt.Println("1. Por local")
+	fmt.Println("2. Por categoría")
+	fmt.Println("3. Por vendedor")
+	fmt.Print("Seleccione una opción: ")
+	choiceStr, _ := reader.ReadString('\n')
+
+	target := models.SalesTarget{Month: month}
+	switch strings.TrimSpace(choiceStr) {
+	case "1":
+		target.Scope = models.TargetScopeStore
+		target.RefID = readLocation(reader, locationRepo, "Local")
+	case "2":
+		target.Scope = models.TargetScopeCategory
+		target.RefID = readCategory(reader, categoryRepo, 0)
+		paths, err := categoryRepo.GetCategoryPaths()
+		if err != nil {
+			fmt.Println("Error al obtener las categorías:", err)
+			return
+		}
+		if _, ok := paths[target.RefID]; !ok {
+			fmt.Println("Categoría no encontrada.")
+			return
+		}
+	case "3":
+		target.Scope = models.TargetScopeSeller
+		seller := readSeller(reader, sellerRepo, "Vendedor (ID o nombre): ")
+		if seller == nil {
+			return
+		}
+		target.RefID = seller.ID
+	default:
+		fmt.Println("Opción no válida.")
+		return
+	}
+
+	fmt.Print("Meta de ventas del mes (0 = eliminar la meta): ")
+	amountStr, _ := reader.ReadString('\n')
+	amount, err := parseAmount(amountStr)
+	if err != nil || amount < 0 {
+		fmt.Println("Importe inválido. Operación cancelada.")
+		return
+	}
+	target.Amount = amount
+
+	if err := targetRepo.SetTarget(target); err != nil {
+		fmt.Println("Error al guardar la meta:", err)
+		return
+	}
+	if amount == 0 {
+		fmt.Println("Meta eliminada.")
+		return
+	}
+	fmt.Printf("Meta de %s guardada: %.2f\n", month.Format("01/2006"), amount)
+}
+
+// LoadTargetProgress calcula el avance de las metas del mes a la fecha now,
+// con el nombre del local, la categoría o el vendedor de cada una.
+func LoadTargetProgress(targetRepo *repository.TargetRepo, locationRepo *repository.LocationRepo, categoryRepo *repository.CategoryRepo, sellerRepo *repository.SellerRepo, month, now time.Time) ([]models.TargetProgress, error) {
+	targets, err := targetRepo.GetTargets(month)
+	if err != nil || len(targets) == 0 {
+		return nil, err
+	}
+	sales, err := targetRepo.GetTargetSales(month, month.AddDate(0, 1, 0).Add(-time.Second))
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := categoryRepo.GetAllCategories()
+	if err != nil {
+		return nil, err
+	}
+	parents := make(map[int]int, len(categories))
+	for _, c := range categories {
+		parents[c.ID] = c.ParentID
+	}
+	categoryNames, err := categoryRepo.GetCategoryPaths()
+	if err != nil {
+		return nil, err
+	}
+	locationNames, err := locationRepo.GetLocationNames()
+	if err != nil {
+		return nil, err
+	}
+	sellers, err := sellerRepo.GetAllSellers()
+	if err != nil {
+		return nil, err
+	}
+	sellerNames := make(map[int]string, len(sellers))
+	for _, s := range sellers {
+		sellerNames[s.ID] = s.Name
+	}
+	names := map[string]map[int]string{
+		models.TargetScopeStore:    locationNames,
+		models.TargetScopeCategory: categoryNames,
+		models.TargetScopeSeller:   sellerNames,
+	}
+
+	progress := make([]models.TargetProgress, 0, len(targets))
+	for _, t := range targets {
+		p := t.Progress(sales, parents, now)
+		p.Name = names[t.Scope][t.RefID]
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("#%d", t.RefID)
+		}
+		progress = append(progress, p)
+	}
+	return progress, nil
+}
+
+// ShowTargetProgress muestra lo vendido contra la meta de cada local,
+// categoría y vendedor en un mes, con la proyección de cierre según el ritmo
+// de venta de los días transcurridos.
+func ShowTargetProgress(targetRepo *repository.TargetRepo, locationRepo *repository.LocationRepo, categoryRepo *repository.CategoryRepo, sellerRepo *repository.SellerRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Progreso de Metas ---")
+	month, ok := readMonth(reader)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	progress, err := LoadTargetProgress(targetRepo, locationRepo, categoryRepo, sellerRepo, month, now)
+	if err != nil {
+		fmt.Println("Error al calcular el avance de las metas:", err)
+		return
+	}
+
+	fmt.Printf("\nMetas de %s", month.Format("01/2006"))
+	end := month.AddDate(0, 1, 0)
+	if !now.Before(month) && now.Before(end) {
+		fmt.Printf(" (día %d de %d)", now.Day(), end.AddDate(0, 0, -1).Day())
+	}
+	fmt.Println()
+	if len(progress) == 0 {
+		fmt.Println("No hay metas definidas para el mes.")
+		return
+	}
+	fmt.Printf("%-10s | %-20s | %12s | %12s | %-22s | %7s | %12s | %7s\n", "Tipo", "Nombre", "Meta", "Vendido", "Avance", "%", "Proyección", "% Proy.")
+	fmt.Println("----------------------------------------------------------------------------------------------------------------------")
+	for _, p := range progress {
+		fmt.Printf("%-10s | %-20s | %12.2f | %12.2f | %-22s | %6.1f%% | %12.2f | %6.1f%%\n",
+			p.Scope, p.Name, p.Amount, p.Actual, utils.ProgressBar(p.Percent(), 20), p.Percent(), p.Projected, p.ProjectedPercent())
+	}
+}
+
+// readMonth pide un mes con el formato MM/YYYY; si se deja en blanco usa el
+// mes actual. Devuelve el primer día del mes.
+func readMonth(reader *bufio.Reader) (time.Time, bool) {
+	fmt.Print("Mes (MM/YYYY, Enter = mes actual): ")
+	monthStr, _ := reader.ReadString('\n')
+	now := time.Now()
+	if strings.TrimSpace(monthStr) == "" {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), true
+	}
+	month, err := time.ParseInLocation("01/2006", strings.TrimSpace(monthStr), now.Location())
+	if err != nil {
+		fmt.Println("Formato de mes inválido.")
+		return time.Time{}, false
+	}
+	return month, true
+}
\ No newline at end of file
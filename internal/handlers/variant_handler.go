@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sales-system/internal/models"
+	"sales-system/internal/repository"
+	"sort"
+	"strings"
+	"time"
+)
+
+// variantAttribute es un atributo con sus valores posibles, por ejemplo Talle: S, M, L.
+type variantAttribute struct {
+	Name   string
+	Values []string
+}
+
+// GenerateVariants crea las variantes de un producto padre combinando los
+// valores de cada atributo (por ejemplo 5 talles x 3 colores = 15 variantes).
+// Las combinaciones que ya existen se omiten.
+func GenerateVariants(productRepo *repository.ProductRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Generar Variantes ---")
+	PreviewProducts(productRepo)
+
+	parent, err := readProduct(reader, productRepo, "#ID, SKU o código del producto padre: ")
+	if err != nil {
+		fmt.Println("Producto no encontrado.")
+		return
+	}
+	if parent.IsVariant() {
+		fmt.Println("El producto elegido ya es una variante. Seleccione el producto padre.")
+		return
+	}
+
+	var attributes []variantAttribute
+	for {
+		fmt.Print("Nombre del atributo (ej. Talle, Color; Enter para terminar): ")
+		name, _ := reader.ReadString('\n')
+		name = strings.TrimSpace(name)
+		if name == "" {
+			break
+		}
+		fmt.Printf("Valores de %s (separados por comas): ", name)
+		valuesStr, _ := reader.ReadString('\n')
+		values := models.ParseTags(valuesStr)
+		if len(values) == 0 {
+			fmt.Println("Debe ingresar al menos un valor.")
+			continue
+		}
+		attributes = append(attributes, variantAttribute{Name: name, Values: values})
+	}
+	if len(attributes) == 0 {
+		fmt.Println("No se ingresaron atributos. Operación cancelada.")
+		return
+	}
+
+	fmt.Printf("Precio de las variantes (Enter = %.2f, el del producto padre): ", parent.Price)
+	priceStr, _ := reader.ReadString('\n')
+	price, override := parent.Price, false
+	if strings.TrimSpace(priceStr) != "" {
+		if newPrice, err := parseAmount(priceStr); err == nil {
+			price, override = newPrice, true
+		}
+	}
+
+	existing, err := productRepo.GetVariants(parent.ID)
+	if err != nil {
+		fmt.Println("Error al obtener las variantes:", err)
+		return
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, v := range existing {
+		exists[models.FormatAttributes(v.Attributes)] = true
+	}
+
+	created := 0
+	for _, combination := range combineAttributes(attributes) {
+		if exists[models.FormatAttributes(combination)] {
+			continue
+		}
+
+		var values []string
+		for _, a := range attributes {
+			values = append(values, combination[a.Name])
+		}
+		variant := models.Product{
+			Date:       time.Now(),
+			Name:       parent.Name + " - " + strings.Join(values, " / "),
+			Price:      price,
+			Cost:       parent.Cost,
+			CostMethod: parent.CostMethod,
+			CategoryID: parent.CategoryID,
+			Brand:      parent.Brand,
+			Tags:       parent.Tags,
+			ParentID:   parent.ID,
+			Attributes: combination,
+
+			PriceOverride:  override,
+			Unit:           parent.Unit,
+			Decimals:       parent.Decimals,
+			PurchaseUnit:   parent.PurchaseUnit,
+			PurchaseFactor: parent.PurchaseFactor,
+		}
+		if parent.SKU != "" {
+			variant.SKU = strings.ToUpper(parent.SKU + "-" + strings.Join(values, "-"))
+		}
+
+		if _, err := productRepo.CreateProduct(variant, models.Lot{}); err != nil {
+			fmt.Printf("Error al crear la variante %s: %v\n", variant.Name, err)
+			continue
+		}
+		created++
+	}
+	fmt.Printf("%d variante(s) creadas con éxito.\n", created)
+}
+
+// combineAttributes devuelve todas las combinaciones posibles de valores.
+func combineAttributes(attributes []variantAttribute) []map[string]string {
+	combinations := []map[string]string{{}}
+	for _, a := range attributes {
+		var next []map[string]string
+		for _, c := range combinations {
+			for _, v := range a.Values {
+				combination := make(map[string]string, len(c)+1)
+				for k, existing := range c {
+					combination[k] = existing
+				}
+				combination[a.Name] = v
+				next = append(next, combination)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// ShowVariantMatrix muestra el stock de las variantes de un producto en una
+// matriz, con un atributo en las filas y otro en las columnas.
+func ShowVariantMatrix(productRepo *repository.ProductRepo) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n--- Matriz de Stock por Variante ---")
+	parent, err := readProduct(reader, productRepo, "#ID, SKU o código del producto padre: ")
+	if err != nil {
+		fmt.Println("Producto no encontrado.")
+		return
+	}
+	if parent.IsVariant() {
+		parent, err = productRepo.GetProductByID(parent.ParentID)
+		if err != nil {
+			fmt.Println("Producto padre no encontrado.")
+			return
+		}
+	}
+
+	variants, err := productRepo.GetVariants(parent.ID)
+	if err != nil {
+		fmt.Println("Error al obtener las variantes:", err)
+		return
+	}
+	if len(variants) == 0 {
+		fmt.Println("El producto no tiene variantes.")
+		return
+	}
+
+	fmt.Printf("\n%s - variantes:\n", parent.Name)
+	fmt.Printf("%-5s | %-15s | %-30s | %-8s | %-8s\n", "ID", "SKU", "Atributos", "Stock", "Precio")
+	fmt.Println("--------------------------------------------------------------------------------")
+	for _, v := range variants {
+		fmt.Printf("%-5d | %-15s | %-30s | %-8s | %-8.2f\n", v.ID, v.SKU, models.FormatAttributes(v.Attributes), v.FormatQuantity(v.Quantity), v.Price)
+	}
+
+	// Atributos presentes en las variantes, en orden alfabético.
+	seen := make(map[string]bool)
+	var keys []string
+	for _, v := range variants {
+		for k := range v.Attributes {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		return
+	}
+
+	rowKey := keys[0]
+	colKey := ""
+	if len(keys) > 1 {
+		fmt.Printf("\nAtributos: %s\n", strings.Join(keys, ", "))
+		fmt.Printf("Atributo para las filas (Enter = %s): ", keys[0])
+		rowStr, _ := reader.ReadString('\n')
+		if seen[strings.TrimSpace(rowStr)] {
+			rowKey = strings.TrimSpace(rowStr)
+		}
+		for _, k := range keys {
+			if k != rowKey {
+				colKey = k
+				break
+			}
+		}
+		fmt.Printf("Atributo para las columnas (Enter = %s): ", colKey)
+		colStr, _ := reader.ReadString('\n')
+		if c := strings.TrimSpace(colStr); seen[c] && c != rowKey {
+			colKey = c
+		}
+	}
+
+	// Los valores se muestran en el orden en que aparecen las variantes.
+	rowValues := distinctValues(variants, rowKey)
+	colValues := []string{""}
+	if colKey != "" {
+		colValues = distinctValues(variants, colKey)
+	}
+	stock := make(map[[2]string]float64)
+	for _, v := range variants {
+		stock[[2]string{v.Attributes[rowKey], v.Attributes[colKey]}] += v.Quantity
+	}
+
+	fmt.Printf("\n%-16s", rowKey+" \\ "+colKey)
+	for _, c := range colValues {
+		if c == "" {
+			c = "Stock"
+		}
+		fmt.Printf(" | %8s", c)
+	}
+	fmt.Printf(" | %8s\n", "Total")
+	fmt.Println(strings.Repeat("-", 16+11*(len(colValues)+1)))
+
+	colTotals := make([]float64, len(colValues))
+	grandTotal := 0.0
+	for _, r := range rowValues {
+		fmt.Printf("%-16s", r)
+		rowTotal := 0.0
+		for i, c := range colValues {
+			n := stock[[2]string{r, c}]
+			fmt.Printf(" | %8s", formatQuantity(n))
+			rowTotal += n
+			colTotals[i] += n
+		}
+		grandTotal += rowTotal
+		fmt.Printf(" | %8s\n", formatQuantity(rowTotal))
+	}
+	fmt.Printf("%-16s", "Total")
+	for _, t := range colTotals {
+		fmt.Printf(" | %8s", formatQuantity(t))
+	}
+	fmt.Printf(" | %8s\n", formatQuantity(grandTotal))
+}
+
+func distinctValues(variants []models.Product, key string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, v := range variants {
+		value := v.Attributes[key]
+		if !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+	}
+	return values
+}
\ No newline at end of file
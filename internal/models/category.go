@@ -0,0 +1,9 @@
+package models
+
+// Category es una categoría de productos. Las subcategorías apuntan a su
+// categoría padre; ParentID es 0 para las categorías raíz.
+type Category struct {
+	ID       int
+	Name     string
+	ParentID int
+}
\ No newline at end of file
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// ExchangeRate es la cotización de una moneda extranjera en un día: cuántas
+// unidades de la moneda local vale una unidad de Currency.
+type ExchangeRate struct {
+	Date     time.Time
+	Currency string
+	Rate     float64
+}
+
+// ToForeign convierte un importe en moneda local a la moneda de la cotización.
+func (r ExchangeRate) ToForeign(amount float64) float64 {
+	return math.Round(amount/r.Rate*100) / 100
+}
+
+// ToBase convierte un importe en la moneda de la cotización a moneda local.
+func (r ExchangeRate) ToBase(amount float64) float64 {
+	return math.Round(amount*r.Rate*100) / 100
+}
+
+// CurrencyTotal es lo cobrado en una moneda extranjera, con su equivalente
+// en moneda local según la cotización de cada venta.
+type CurrencyTotal struct {
+	Currency   string
+	Amount     float64
+	BaseAmount float64
+}
\ No newline at end of file
@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// Customer es un cliente con cuenta corriente. Las ventas se asocian por el
+// nombre del cliente. CreditLimit es el máximo que puede deber en ventas
+// pendientes (0 = solo contado) y PaymentTerms los días que tiene para pagar
+// cada venta antes de que quede vencida.
+type Customer struct {
+	ID           int
+	Name         string
+	Phone        string
+	CreditLimit  float64
+	PaymentTerms int
+}
+
+// CreditStatus es la situación de la cuenta de un cliente en un momento dado.
+type CreditStatus struct {
+	Balance      float64
+	Overdue      float64
+	OverdueCount int
+}
+
+// Available devuelve cuánto crédito le queda al cliente.
+func (c Customer) Available(status CreditStatus) float64 {
+	return c.CreditLimit - status.Balance
+}
+
+// CreditOverride registra la autorización de un supervisor para vender a
+// crédito por encima del límite o con facturas vencidas.
+type CreditOverride struct {
+	ID         int
+	Date       time.Time
+	Client     string
+	Supervisor string
+	Amount     float64
+	Reason     string
+}
+
+const (
+	PaymentTypePayment = "Pago"
+	PaymentTypeCredit  = "Nota de crédito"
+)
+
+// CustomerPayment es un pago a cuenta o una nota de crédito a favor del
+// cliente. Se imputa a sus ventas pendientes de la más antigua a la más nueva.
+type CustomerPayment struct {
+	ID     int
+	Date   time.Time
+	Client string
+	Amount float64
+	Type   string
+	Note   string
+}
+
+// StatementLine es un movimiento del estado de cuenta. Debit es lo que suma a
+// la deuda del cliente (ventas) y Credit lo que resta (pagos y créditos).
+type StatementLine struct {
+	Date        time.Time
+	Description string
+	Debit       float64
+	Credit      float64
+	Balance     float64
+}
+
+// Statement es el estado de cuenta de un cliente en un período.
+type Statement struct {
+	Customer Customer
+	Start    time.Time
+	End      time.Time
+	Opening  float64
+	Lines    []StatementLine
+}
+
+// Closing devuelve el saldo al final del período, que es lo que el cliente adeuda.
+func (s Statement) Closing() float64 {
+	if len(s.Lines) == 0 {
+		return s.Opening
+	}
+	return s.Lines[len(s.Lines)-1].Balance
+}
\ No newline at end of file
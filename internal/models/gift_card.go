@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+const (
+	// GiftCardKindGift es una tarjeta de regalo vendida en el local.
+	GiftCardKindGift = "Tarjeta de regalo"
+	// GiftCardKindCredit es crédito en tienda entregado por una devolución.
+	GiftCardKindCredit = "Crédito en tienda"
+)
+
+const (
+	GiftCardIssue  = "Emisión"
+	GiftCardTopUp  = "Recarga"
+	GiftCardRedeem = "Consumo"
+	GiftCardReturn = "Devolución"
+	// GiftCardVoid devuelve a la tarjeta lo que consumió una venta eliminada.
+	GiftCardVoid = "Anulación"
+)
+
+// GiftCard es una tarjeta de regalo o un crédito en tienda. El saldo surge de
+// sus movimientos: las emisiones, recargas y devoluciones suman y los
+// consumos restan.
+type GiftCard struct {
+	ID       int
+	Code     string
+	Kind     string
+	Client   string
+	IssuedAt time.Time
+	Balance  float64
+}
+
+// GiftCardMovement es un movimiento del saldo de una tarjeta. SaleID es la
+// venta con la que se emitió, recargó o consumió, o la venta devuelta en el
+// caso del crédito en tienda.
+type GiftCardMovement struct {
+	ID     int
+	CardID int
+	Date   time.Time
+	Amount float64
+	Type   string
+	SaleID int
+	Note   string
+}
+
+// GiftCardPayment es la parte de una venta que se paga con una tarjeta de
+// regalo o un crédito en tienda.
+type GiftCardPayment struct {
+	CardID int
+	Code   string
+	Amount float64
+}
+
+// IsGiftCard indica si la venta es la emisión o recarga de una tarjeta de
+// regalo. Esas ventas no tienen producto ni mueven stock.
+func (s Sale) IsGiftCard() bool {
+	return s.ProductID == 0
+}
\ No newline at end of file
@@ -0,0 +1,42 @@
+package models
+
+import "math"
+
+// KitComponent es un renglón de la lista de materiales de un kit: cuánto de
+// un producto lleva cada unidad del kit.
+type KitComponent struct {
+	KitID       int
+	ComponentID int
+	Quantity    float64
+}
+
+// SaleComponent es lo que se descontó de un componente al vender un kit. El
+// precio de lista del componente queda congelado para repartir el importe de
+// la venta entre los componentes en los reportes.
+type SaleComponent struct {
+	SaleID      int
+	ComponentID int
+	PerKit      float64
+	Quantity    float64
+	Price       float64
+	Cost        float64
+}
+
+// KitAvailability calcula cuántos kits completos se pueden armar con el stock
+// de sus componentes.
+func KitAvailability(components []KitComponent, stock map[int]float64) float64 {
+	if len(components) == 0 {
+		return 0
+	}
+	available := math.Inf(1)
+	for _, c := range components {
+		if c.Quantity <= 0 {
+			continue
+		}
+		available = math.Min(available, math.Floor(stock[c.ComponentID]/c.Quantity+1e-9))
+	}
+	if math.IsInf(available, 1) {
+		return 0
+	}
+	return math.Max(available, 0)
+}
\ No newline at end of file
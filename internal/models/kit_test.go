@@ -0,0 +1,28 @@
+package models
+
+import "testing"
+
+func TestKitAvailability(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []KitComponent
+		stock      map[int]float64
+		want       float64
+	}{
+		{"sin componentes", nil, map[int]float64{1: 10}, 0},
+		{"un componente", []KitComponent{{ComponentID: 1, Quantity: 2}}, map[int]float64{1: 7}, 3},
+		{"limita el componente más escaso", []KitComponent{{ComponentID: 1, Quantity: 1}, {ComponentID: 2, Quantity: 3}}, map[int]float64{1: 10, 2: 7}, 2},
+		{"componente sin stock", []KitComponent{{ComponentID: 1, Quantity: 1}, {ComponentID: 2, Quantity: 1}}, map[int]float64{1: 5}, 0},
+		{"stock negativo", []KitComponent{{ComponentID: 1, Quantity: 1}}, map[int]float64{1: -2}, 0},
+		{"cantidades decimales", []KitComponent{{ComponentID: 1, Quantity: 0.1}}, map[int]float64{1: 0.3}, 3},
+		{"ignora cantidades no positivas", []KitComponent{{ComponentID: 1, Quantity: 0}, {ComponentID: 2, Quantity: 2}}, map[int]float64{2: 4}, 2},
+		{"todas las cantidades no positivas", []KitComponent{{ComponentID: 1, Quantity: 0}}, map[int]float64{1: 4}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KitAvailability(tt.components, tt.stock); got != tt.want {
+				t.Errorf("KitAvailability = %g, se esperaba %g", got, tt.want)
+			}
+		})
+	}
+}
\ No newline at end of file
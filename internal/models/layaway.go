@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+const (
+	LayawayActive    = "Activo"
+	LayawayCompleted = "Completado"
+	LayawayCancelled = "Cancelado"
+)
+
+// Layaway es un plan de apartado: la mercadería queda reservada en una venta
+// con estado Apartado y el cliente la paga con una seña y cuotas. Si una
+// cuota queda impaga más de GraceDays días después de su vencimiento, el plan
+// se cancela y la mercadería vuelve al stock.
+type Layaway struct {
+	ID            int
+	SaleID        int
+	Date          time.Time
+	Client        string
+	ProductName   string
+	Total         float64
+	Paid          float64
+	Status        string
+	GraceDays     int
+	CancelledDate time.Time
+	Installments  []Installment
+}
+
+// Installment es una cuota del plan. Paid es lo que se imputó de los pagos.
+type Installment struct {
+	ID        int
+	LayawayID int
+	Number    int
+	DueDate   time.Time
+	Amount    float64
+	Paid      float64
+}
+
+// Balance devuelve lo que falta pagar del plan.
+func (l Layaway) Balance() float64 {
+	return l.Total - l.Paid
+}
+
+// Pending devuelve lo que falta pagar de la cuota.
+func (i Installment) Pending() float64 {
+	return i.Amount - i.Paid
+}
+
+// IsOverdue indica si la cuota tiene saldo y su vencimiento ya pasó.
+func (i Installment) IsOverdue(now time.Time) bool {
+	return i.Pending() > 0.005 && i.DueDate.Format("2006-01-02") < now.Format("2006-01-02")
+}
+
+// InstallmentSchedule reparte un saldo en cuotas iguales cada intervalDays
+// días a partir de start. La última cuota absorbe el redondeo.
+func InstallmentSchedule(balance float64, count, intervalDays int, start time.Time) []Installment {
+	if count <= 0 {
+		return nil
+	}
+	amount := float64(int(balance/float64(count)*100)) / 100
+	installments := make([]Installment, count)
+	for i := range installments {
+		installments[i] = Installment{
+			Number:  i + 1,
+			DueDate: start.AddDate(0, 0, intervalDays*(i+1)),
+			Amount:  amount,
+		}
+	}
+	installments[count-1].Amount = balance - amount*float64(count-1)
+	return installments
+}
\ No newline at end of file
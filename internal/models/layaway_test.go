@@ -0,0 +1,49 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestInstallmentSchedule(t *testing.T) {
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name     string
+		balance  float64
+		count    int
+		interval int
+		amounts  []float64
+		dueDates []string
+	}{
+		{"sin cuotas", 100, 0, 30, nil, nil},
+		{"cuota única", 100, 1, 15, []float64{100}, []string{"2024-02-15"}},
+		{"la última absorbe el redondeo", 100, 3, 30, []float64{33.33, 33.33, 33.34}, []string{"2024-03-01", "2024-03-31", "2024-04-30"}},
+		{"división exacta", 90, 3, 7, []float64{30, 30, 30}, []string{"2024-02-07", "2024-02-14", "2024-02-21"}},
+		{"trunca a centavos", 10, 3, 1, []float64{3.33, 3.33, 3.34}, []string{"2024-02-01", "2024-02-02", "2024-02-03"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := InstallmentSchedule(tt.balance, tt.count, tt.interval, start)
+			if len(got) != len(tt.amounts) {
+				t.Fatalf("se obtuvieron %d cuotas, se esperaban %d", len(got), len(tt.amounts))
+			}
+			var total float64
+			for i, inst := range got {
+				if inst.Number != i+1 {
+					t.Errorf("cuota %d: número %d", i+1, inst.Number)
+				}
+				if math.Abs(inst.Amount-tt.amounts[i]) > 1e-9 {
+					t.Errorf("cuota %d: importe %.2f, se esperaba %.2f", i+1, inst.Amount, tt.amounts[i])
+				}
+				if due := inst.DueDate.Format("2006-01-02"); due != tt.dueDates[i] {
+					t.Errorf("cuota %d: vence %s, se esperaba %s", i+1, due, tt.dueDates[i])
+				}
+				total += inst.Amount
+			}
+			if len(got) > 0 && math.Abs(total-tt.balance) > 1e-9 {
+				t.Errorf("las cuotas suman %.2f, se esperaba %.2f", total, tt.balance)
+			}
+		})
+	}
+}
\ No newline at end of file
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// DefaultLocationID es la ubicación que se crea con la base de datos; recibe
+// el stock existente y es la ubicación por defecto de ventas y entradas.
+const DefaultLocationID = 1
+
+const (
+	TransferInTransit = "En tránsito"
+	TransferReceived  = "Recibida"
+)
+
+// Location es un local o depósito donde se guarda mercadería.
+type Location struct {
+	ID   int
+	Name string
+}
+
+// Transfer es un envío de mercadería entre dos ubicaciones. Mientras está en
+// tránsito el stock ya salió del origen pero todavía no llegó al destino.
+type Transfer struct {
+	ID           int
+	Date         time.Time
+	FromID       int
+	ToID         int
+	Status       string
+	ReceivedDate time.Time
+	Items        []TransferItem
+}
+
+// TransferItem es una línea de la transferencia. Los productos con control de
+// lotes llevan una línea por lote, para reingresarlo igual en el destino.
+type TransferItem struct {
+	ID         int
+	TransferID int
+	ProductID  int
+	Quantity   float64
+	LotNumber  string
+	LotExpiry  time.Time
+}
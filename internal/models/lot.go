@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Lot es un lote de un producto en una ubicación, con su fecha de vencimiento.
+type Lot struct {
+	ID         int
+	ProductID  int
+	LocationID int
+	Number     string
+	Expiry     time.Time
+	Quantity   float64
+}
+
+// IsExpired indica si el lote ya venció en la fecha dada. Un lote vence al
+// terminar el día de su fecha de vencimiento.
+func (l Lot) IsExpired(now time.Time) bool {
+	return l.DaysToExpiry(now) < 0
+}
+
+// DaysToExpiry devuelve los días que faltan para el vencimiento; es negativo
+// si el lote ya venció.
+func (l Lot) DaysToExpiry(now time.Time) int {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	expiry := time.Date(l.Expiry.Year(), l.Expiry.Month(), l.Expiry.Day(), 0, 0, 0, 0, time.UTC)
+	return int(expiry.Sub(today).Hours() / 24)
+}
+
+// SaleLot indica de qué lote salió la mercadería de una venta, para poder
+// rastrearla ante un retiro del mercado.
+type SaleLot struct {
+	SaleID   int
+	LotID    int
+	Number   string
+	Expiry   time.Time
+	Quantity float64
+}
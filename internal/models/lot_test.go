@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLotDaysToExpiry(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.Local)
+	tests := []struct {
+		name    string
+		expiry  time.Time
+		days    int
+		expired bool
+	}{
+		{"vence hoy", time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), 0, false},
+		{"vence mañana", time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), 1, false},
+		{"venció ayer", time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), -1, true},
+		{"hora del vencimiento ignorada", time.Date(2024, 3, 10, 23, 59, 0, 0, time.UTC), 0, false},
+		{"dentro de un mes", time.Date(2024, 4, 10, 0, 0, 0, 0, time.UTC), 31, false},
+		{"año bisiesto", time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC), -11, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lot := Lot{Expiry: tt.expiry}
+			if got := lot.DaysToExpiry(now); got != tt.days {
+				t.Errorf("DaysToExpiry = %d, se esperaba %d", got, tt.days)
+			}
+			if got := lot.IsExpired(now); got != tt.expired {
+				t.Errorf("IsExpired = %v, se esperaba %v", got, tt.expired)
+			}
+		})
+	}
+}
\ No newline at end of file
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	PointsEarned   = "Acumulación"
+	PointsRedeemed = "Canje"
+	PointsExpired  = "Vencimiento"
+	// PointsReversed descuenta los puntos ganados por una venta que se
+	// eliminó, se editó o se devolvió.
+	PointsReversed = "Anulación"
+	// PointsRestored devuelve los puntos canjeados en una venta que se
+	// eliminó, se editó o se devolvió.
+	PointsRestored = "Reintegro"
+)
+
+// LoyaltySettings son las reglas del programa de puntos. Se gana un punto
+// cada AmountPerPoint de compra (0 = programa desactivado) y cada punto vale
+// PointValue al canjearlo. Los puntos vencen ExpiryDays días después de
+// ganarse (0 = no vencen). Los productos de las categorías excluidas (y sus
+// subcategorías) no suman puntos, y BonusDays multiplica los puntos de los
+// días de la semana indicados.
+type LoyaltySettings struct {
+	AmountPerPoint     float64
+	PointValue         float64
+	ExpiryDays         int
+	ExcludedCategories []int
+	BonusDays          map[time.Weekday]float64
+}
+
+// Enabled indica si el programa de puntos está activo.
+func (s LoyaltySettings) Enabled() bool {
+	return s.AmountPerPoint > 0
+}
+
+// PointsFor calcula los puntos que gana una compra por el importe indicado en
+// la fecha dada, con la bonificación del día si corresponde.
+func (s LoyaltySettings) PointsFor(amount float64, date time.Time) int {
+	if !s.Enabled() || amount <= 0 {
+		return 0
+	}
+	multiplier := 1.0
+	if bonus, ok := s.BonusDays[date.Weekday()]; ok && bonus > 0 {
+		multiplier = bonus
+	}
+	return int(math.Floor(amount / s.AmountPerPoint * multiplier))
+}
+
+// RedeemValue devuelve el importe que representan los puntos al canjearlos.
+func (s LoyaltySettings) RedeemValue(points int) float64 {
+	return float64(points) * s.PointValue
+}
+
+// LoyaltyEntry es un movimiento de la cuenta de puntos de un cliente. Las
+// acumulaciones son positivas y guardan en Remaining lo que todavía no se
+// canjeó ni venció; los canjes y vencimientos son negativos.
+type LoyaltyEntry struct {
+	ID         int
+	CustomerID int
+	Date       time.Time
+	Points     int
+	Type       string
+	SaleID     int
+	ExpiresAt  time.Time
+	Remaining  int
+}
+
+// PointsRedemption son los puntos que un cliente canjea en una venta.
+type PointsRedemption struct {
+	CustomerID int
+	Points     int
+}
+
+// PointsReversal es la parte (de 0 a 1) de los puntos ganados con una venta
+// que se anula al eliminarla, editarla o devolverla. Si RestoreRedeemed es
+// verdadero, también se reintegra esa parte de los puntos canjeados en ella.
+type PointsReversal struct {
+	Fraction        float64
+	RestoreRedeemed bool
+}
+
+// WeekdayNames son los nombres de los días de la semana, empezando por el domingo.
+var WeekdayNames = []string{"Domingo", "Lunes", "Martes", "Miércoles", "Jueves", "Viernes", "Sábado"}
\ No newline at end of file
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+const (
+	PriceChangeScheduled = "Programado"
+	PriceChangeApplied   = "Aplicado"
+	PriceChangeCancelled = "Cancelado"
+)
+
+// PriceChange es un cambio en el precio de venta de un producto. Los cambios
+// aplicados forman el historial de precios; los programados se aplican solos
+// cuando llega su fecha de vigencia.
+type PriceChange struct {
+	ID            int
+	ProductID     int
+	OldPrice      float64
+	NewPrice      float64
+	EffectiveDate time.Time
+	Status        string
+	Reason        string
+}
+
+// Percent devuelve la variación del precio en porcentaje. Devuelve 0 si no
+// había un precio anterior.
+func (c PriceChange) Percent() float64 {
+	if c.OldPrice == 0 {
+		return 0
+	}
+	return (c.NewPrice - c.OldPrice) / c.OldPrice * 100
+}
\ No newline at end of file
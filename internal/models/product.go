@@ -1,11 +1,112 @@
 package models
 
-import "time"
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	CostMethodAverage = "Promedio"
+	CostMethodFIFO    = "FIFO"
+)
 
 type Product struct {
-	ID       int
-	Date     time.Time
-	Name     string
-	Quantity int
-	Price    float64
+	ID         int
+	Date       time.Time
+	Name       string
+	Quantity   float64
+	Price      float64
+	Cost       float64
+	CostMethod string
+	MinStock   float64
+	ReorderQty float64
+	CategoryID int
+	Brand      string
+	Tags       []string
+	SKU        string
+	Barcode    string
+	// ParentID es el producto padre cuando el producto es una variante (talle,
+	// color, etc.); 0 si es un producto independiente o un producto padre.
+	ParentID   int
+	Attributes map[string]string
+	// PriceOverride indica que la variante tiene un precio propio; si no, sigue
+	// el precio del producto padre.
+	PriceOverride bool
+	// Unit es la unidad en la que se vende y se lleva el stock; Decimals la
+	// precisión con que se registran las cantidades.
+	Unit     string
+	Decimals int
+	// PurchaseUnit es la unidad en la que se compra (por ejemplo "caja") y
+	// PurchaseFactor cuántas unidades de venta contiene.
+	PurchaseUnit   string
+	PurchaseFactor float64
+	// TrackLots indica si el stock se lleva por lote y vencimiento.
+	TrackLots bool
+	// IsKit indica que el producto es un kit (por ejemplo una canasta de
+	// regalo): no tiene stock propio y al venderse descuenta sus componentes.
+	IsKit bool
+	// Archived indica que el producto ya no se vende ni aparece en los
+	// listados; se conserva porque tiene ventas registradas.
+	Archived bool
+}
+
+// FormatQuantity muestra una cantidad del producto con su precisión y unidad.
+func (p Product) FormatQuantity(quantity float64) string {
+	return FormatQuantity(quantity, p.Decimals) + " " + p.Unit
+}
+
+// RoundQuantity redondea una cantidad a la precisión del producto.
+func (p Product) RoundQuantity(quantity float64) float64 {
+	return RoundQuantity(quantity, p.Decimals)
+}
+
+// IsVariant indica si el producto es una variante de otro.
+func (p Product) IsVariant() bool {
+	return p.ParentID != 0
+}
+
+// IsLowStock indica si el stock está en o por debajo del mínimo configurado.
+// Los productos sin mínimo (0) nunca se consideran con stock bajo.
+func (p Product) IsLowStock() bool {
+	return p.MinStock > 0 && p.Quantity <= p.MinStock
+}
+
+// ParseTags convierte una lista de etiquetas separadas por comas en un slice,
+// descartando espacios y etiquetas vacías.
+func ParseTags(s string) []string {
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// FormatAttributes convierte los atributos de una variante en texto con el
+// formato "Color=Rojo;Talle=M", ordenados por nombre de atributo.
+func FormatAttributes(attributes map[string]string) string {
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + attributes[k]
+	}
+	return strings.Join(parts, ";")
+}
+
+// ParseAttributes es la inversa de FormatAttributes.
+func ParseAttributes(s string) map[string]string {
+	attributes := make(map[string]string)
+	for _, part := range strings.Split(s, ";") {
+		if k, v, ok := strings.Cut(part, "="); ok && strings.TrimSpace(k) != "" {
+			attributes[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return attributes
 }
\ No newline at end of file
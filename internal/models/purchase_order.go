@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	PurchaseDraft    = "Borrador"
+	PurchaseReceived = "Recibida"
+)
+
+type PurchaseOrder struct {
+	ID       int
+	Date     time.Time
+	Supplier string
+	Status   string
+	Items    []PurchaseOrderItem
+}
+
+type PurchaseOrderItem struct {
+	ID        int
+	OrderID   int
+	ProductID int
+	Quantity  float64
+	UnitCost  float64
+}
+
+// Total devuelve el costo total de la orden de compra.
+func (o PurchaseOrder) Total() float64 {
+	var total float64
+	for _, item := range o.Items {
+		total += item.Quantity * item.UnitCost
+	}
+	return total
+}
\ No newline at end of file
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+const (
+	QuoteDraft    = "Borrador"
+	QuoteSent     = "Enviada"
+	QuoteAccepted = "Aceptada"
+	QuoteExpired  = "Vencida"
+)
+
+// Quote es un presupuesto para un cliente. Tiene las mismas líneas que una
+// venta y, al aceptarse, se convierte en una venta por línea.
+type Quote struct {
+	ID         int
+	Date       time.Time
+	Client     string
+	ValidUntil time.Time
+	Status     string
+	LocationID int
+	Lines      []QuoteLine
+}
+
+// QuoteLine es un producto presupuestado. El nombre y el precio quedan
+// congelados al momento del presupuesto; SaleID es la venta en que se
+// convirtió la línea, o 0 si todavía no se vendió.
+type QuoteLine struct {
+	ID          int
+	QuoteID     int
+	ProductID   int
+	ProductName string
+	Quantity    float64
+	Price       float64
+	// Discount es el descuento de la línea en porcentaje.
+	Discount float64
+	SaleID   int
+}
+
+// Total devuelve el importe de la línea con el descuento aplicado.
+func (l QuoteLine) Total() float64 {
+	return DiscountedTotal(l.Quantity, l.Price, l.Discount)
+}
+
+// Total devuelve el importe total del presupuesto.
+func (q Quote) Total() float64 {
+	var total float64
+	for _, l := range q.Lines {
+		total += l.Total()
+	}
+	return total
+}
+
+// IsOpen indica si el presupuesto todavía se puede enviar o convertir en venta.
+func (q Quote) IsOpen() bool {
+	return q.Status == QuoteDraft || q.Status == QuoteSent
+}
+
+// DiscountedTotal calcula cantidad por precio menos un descuento en porcentaje.
+func DiscountedTotal(quantity, price, discount float64) float64 {
+	return quantity * price * (1 - discount/100)
+}
\ No newline at end of file
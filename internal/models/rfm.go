@@ -0,0 +1,115 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// CustomerActivity resume las compras de un cliente. Orders cuenta los días
+// distintos en que compró, porque una compra con varios productos se guarda
+// como una venta por producto.
+type CustomerActivity struct {
+	Client        string
+	FirstPurchase time.Time
+	LastPurchase  time.Time
+	Orders        int
+	Total         float64
+}
+
+// RFMScore es la puntuación de un cliente de 1 a 5 en recencia, frecuencia y
+// valor monetario, con el segmento que le corresponde.
+type RFMScore struct {
+	CustomerActivity
+	DaysSinceLast int
+	Recency       int
+	Frequency     int
+	Monetary      int
+	Segment       string
+}
+
+// Segmentos RFM, del mejor al peor.
+const (
+	SegmentChampions      = "Campeones"
+	SegmentLoyal          = "Leales"
+	SegmentPotentialLoyal = "Potenciales leales"
+	SegmentNew            = "Nuevos"
+	SegmentNeedsAttention = "Necesitan atención"
+	SegmentCannotLose     = "No se pueden perder"
+	SegmentAtRisk         = "En riesgo"
+	SegmentHibernating    = "Hibernando"
+	SegmentLost           = "Perdidos"
+)
+
+// RFMSegments es el orden en que se muestran los segmentos.
+var RFMSegments = []string{
+	SegmentChampions, SegmentLoyal, SegmentPotentialLoyal, SegmentNew, SegmentNeedsAttention,
+	SegmentCannotLose, SegmentAtRisk, SegmentHibernating, SegmentLost,
+}
+
+// ScoreRFM puntúa a los clientes por quintiles: cada dimensión va de 1 (el
+// 20% peor) a 5 (el 20% mejor) respecto del resto de los clientes. En la
+// recencia, menos días desde la última compra es mejor.
+func ScoreRFM(activity []CustomerActivity, now time.Time) []RFMScore {
+	scores := make([]RFMScore, len(activity))
+	days := make([]float64, len(activity))
+	orders := make([]float64, len(activity))
+	totals := make([]float64, len(activity))
+	for i, a := range activity {
+		scores[i].CustomerActivity = a
+		scores[i].DaysSinceLast = int(now.Sub(a.LastPurchase).Hours() / 24)
+		days[i] = -float64(scores[i].DaysSinceLast)
+		orders[i] = float64(a.Orders)
+		totals[i] = a.Total
+	}
+	recency, frequency, monetary := quintiles(days), quintiles(orders), quintiles(totals)
+	for i := range scores {
+		scores[i].Recency, scores[i].Frequency, scores[i].Monetary = recency[i], frequency[i], monetary[i]
+		scores[i].Segment = RFMSegment(recency[i], frequency[i], monetary[i])
+	}
+	return scores
+}
+
+// RFMSegment asigna el segmento según las puntuaciones de recencia,
+// frecuencia y valor monetario.
+func RFMSegment(r, f, m int) string {
+	switch {
+	case r >= 4 && f >= 4:
+		return SegmentChampions
+	case r >= 3 && f >= 4:
+		return SegmentLoyal
+	case r >= 4 && f >= 2:
+		return SegmentPotentialLoyal
+	case r >= 4:
+		return SegmentNew
+	case r == 3:
+		return SegmentNeedsAttention
+	case r == 1 && f >= 4 && m >= 4:
+		return SegmentCannotLose
+	case f >= 3 || m >= 4:
+		return SegmentAtRisk
+	case r == 2:
+		return SegmentHibernating
+	default:
+		return SegmentLost
+	}
+}
+
+// quintiles devuelve para cada valor su quintil (1 a 5) dentro del conjunto.
+// Los valores iguales reciben el mismo quintil.
+func quintiles(values []float64) []int {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return values[order[a]] < values[order[b]] })
+
+	result := make([]int, len(values))
+	rank := 0
+	for pos, i := range order {
+		if pos == 0 || values[i] != values[order[pos-1]] {
+			rank = pos
+		}
+		result[i] = rank*5/len(values) + 1
+	}
+	return result
+}
\ No newline at end of file
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQuintiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   []int
+	}{
+		{"vacío", nil, []int{}},
+		{"un valor", []float64{7}, []int{1}},
+		{"cinco valores distintos", []float64{50, 10, 40, 20, 30}, []int{5, 1, 4, 2, 3}},
+		{"todos iguales", []float64{5, 5, 5}, []int{1, 1, 1}},
+		{"dos valores", []float64{20, 10}, []int{3, 1}},
+		{"empates comparten quintil", []float64{3, 1, 2, 2}, []int{4, 1, 2, 2}},
+		{"diez valores", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, []int{1, 1, 2, 2, 3, 3, 4, 4, 5, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quintiles(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("quintiles(%v) = %v, se esperaba %v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRFMSegment(t *testing.T) {
+	tests := []struct {
+		r, f, m int
+		want    string
+	}{
+		{5, 5, 5, SegmentChampions},
+		{4, 4, 1, SegmentChampions},
+		{3, 5, 2, SegmentLoyal},
+		{5, 2, 1, SegmentPotentialLoyal},
+		{4, 1, 5, SegmentNew},
+		{3, 1, 1, SegmentNeedsAttention},
+		{1, 4, 4, SegmentCannotLose},
+		{1, 4, 3, SegmentAtRisk},
+		{2, 1, 4, SegmentAtRisk},
+		{2, 2, 2, SegmentHibernating},
+		{1, 2, 3, SegmentLost},
+	}
+	for _, tt := range tests {
+		if got := RFMSegment(tt.r, tt.f, tt.m); got != tt.want {
+			t.Errorf("RFMSegment(%d, %d, %d) = %q, se esperaba %q", tt.r, tt.f, tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestScoreRFM(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.Local)
+	activity := []CustomerActivity{
+		{Client: "Ana", LastPurchase: now.AddDate(0, 0, -1), Orders: 20, Total: 5000},
+		{Client: "Beto", LastPurchase: now.AddDate(0, 0, -10), Orders: 12, Total: 3000},
+		{Client: "Carla", LastPurchase: now.AddDate(0, 0, -30), Orders: 6, Total: 1500},
+		{Client: "Dario", LastPurchase: now.AddDate(0, 0, -90), Orders: 3, Total: 800},
+		{Client: "Eva", LastPurchase: now.AddDate(0, 0, -365), Orders: 1, Total: 100},
+	}
+	tests := []struct {
+		client        string
+		daysSinceLast int
+		r, f, m       int
+		segment       string
+	}{
+		{"Ana", 1, 5, 5, 5, SegmentChampions},
+		{"Beto", 10, 4, 4, 4, SegmentChampions},
+		{"Carla", 30, 3, 3, 3, SegmentNeedsAttention},
+		{"Dario", 90, 2, 2, 2, SegmentHibernating},
+		{"Eva", 365, 1, 1, 1, SegmentLost},
+	}
+	scores := ScoreRFM(activity, now)
+	if len(scores) != len(tests) {
+		t.Fatalf("se obtuvieron %d puntuaciones, se esperaban %d", len(scores), len(tests))
+	}
+	for i, tt := range tests {
+		s := scores[i]
+		if s.Client != tt.client {
+			t.Fatalf("puntuación %d: cliente %q, se esperaba %q", i, s.Client, tt.client)
+		}
+		if s.DaysSinceLast != tt.daysSinceLast {
+			t.Errorf("%s: %d días desde la última compra, se esperaban %d", tt.client, s.DaysSinceLast, tt.daysSinceLast)
+		}
+		if s.Recency != tt.r || s.Frequency != tt.f || s.Monetary != tt.m {
+			t.Errorf("%s: RFM %d-%d-%d, se esperaba %d-%d-%d", tt.client, s.Recency, s.Frequency, s.Monetary, tt.r, tt.f, tt.m)
+		}
+		if s.Segment != tt.segment {
+			t.Errorf("%s: segmento %q, se esperaba %q", tt.client, s.Segment, tt.segment)
+		}
+	}
+}
\ No newline at end of file
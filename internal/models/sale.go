@@ -1,10 +1,15 @@
 package models
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 const (
 	StatusPaid    = "Pagado"
 	StatusPending = "Pendiente"
+	// StatusLayaway es una venta apartada con seña que se paga en cuotas.
+	StatusLayaway = "Apartado"
 )
 
 type Sale struct {
@@ -12,8 +17,51 @@ type Sale struct {
 	Date      time.Time
 	Client    string
 	ProductID int
-	Quantity  int
+	Quantity  float64
 	Price     float64
 	Total     float64
 	Status    string
+	Cost      float64
+	// LocationID es el local o depósito desde el que se vendió.
+	LocationID int
+	// ProductName y ProductSKU guardan los datos del producto al momento de
+	// la venta, para que renombrarlo o archivarlo no cambie el historial.
+	ProductName string
+	ProductSKU  string
+	// Currency es la moneda extranjera en que se cobró la venta (vacía si se
+	// cobró en moneda local). CurrencyAmount es lo cobrado en esa moneda y
+	// ExchangeRate la cotización usada; Total queda siempre en moneda local.
+	Currency       string
+	CurrencyAmount float64
+	ExchangeRate   float64
+	// SellerID es el vendedor que hizo la venta (0 si no se indicó).
+	SellerID int
+}
+
+// RescaleCurrency ajusta lo cobrado en moneda extranjera cuando cambia el
+// total de la venta, manteniendo la proporción original.
+func (s *Sale) RescaleCurrency(oldTotal float64) {
+	if s.Currency == "" || oldTotal == 0 {
+		return
+	}
+	s.CurrencyAmount = math.Round(s.CurrencyAmount*s.Total/oldTotal*100) / 100
+}
+
+// Profit devuelve la ganancia bruta de la venta (total menos costo de lo vendido).
+func (s Sale) Profit() float64 {
+	return s.Total - s.Cost
+}
+
+// Margin devuelve el margen bruto de la venta como porcentaje del total.
+func (s Sale) Margin() float64 {
+	return MarginPercent(s.Total, s.Cost)
+}
+
+// MarginPercent calcula el margen bruto en porcentaje a partir de un importe
+// de venta y su costo. Devuelve 0 si no hubo ventas.
+func MarginPercent(total, cost float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return (total - cost) / total * 100
 }
\ No newline at end of file
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// CommissionOnSale calcula la comisión sobre el total de la venta.
+	CommissionOnSale = "Venta"
+	// CommissionOnMargin calcula la comisión sobre la ganancia bruta.
+	CommissionOnMargin = "Margen"
+)
+
+type Seller struct {
+	ID     int
+	Name   string
+	Active bool
+}
+
+// CommissionTier sube el porcentaje de comisión del mes cuando las ventas
+// del vendedor en ese mes alcanzan From.
+type CommissionTier struct {
+	From    float64
+	Percent float64
+}
+
+// CommissionPlan son las reglas de comisión de un vendedor. Se cobra Percent
+// sobre la base (venta o margen), salvo que el volumen del mes alcance un
+// escalón, en cuyo caso rige el porcentaje del escalón más alto alcanzado.
+// Las ventas de las categorías de CategoryRates (y sus subcategorías) usan
+// siempre la tasa de su categoría.
+type CommissionPlan struct {
+	SellerID      int
+	Basis         string
+	Percent       float64
+	Tiers         []CommissionTier
+	CategoryRates map[int]float64
+}
+
+// TierPercent devuelve el porcentaje general que corresponde a un volumen
+// mensual de ventas.
+func (p CommissionPlan) TierPercent(volume float64) float64 {
+	percent := p.Percent
+	tiers := append([]CommissionTier(nil), p.Tiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].From < tiers[j].From })
+	for _, t := range tiers {
+		if volume >= t.From {
+			percent = t.Percent
+		}
+	}
+	return percent
+}
+
+// categoryRate busca la tasa de la categoría o de la categoría superior más
+// cercana que tenga una. parents vincula cada categoría con su padre.
+func (p CommissionPlan) categoryRate(categoryID int, parents map[int]int) (float64, bool) {
+	seen := make(map[int]bool)
+	for id := categoryID; id != 0 && !seen[id]; id = parents[id] {
+		if rate, ok := p.CategoryRates[id]; ok {
+			return rate, true
+		}
+		seen[id] = true
+	}
+	return 0, false
+}
+
+// SellerSale es una venta de un vendedor con la categoría de su producto.
+type SellerSale struct {
+	SaleID      int
+	Date        time.Time
+	ProductName string
+	CategoryID  int
+	Total       float64
+	Cost        float64
+}
+
+// CommissionLine es la comisión de una venta.
+type CommissionLine struct {
+	SellerSale
+	Base       float64
+	Percent    float64
+	Commission float64
+}
+
+// CommissionStatement es la liquidación mensual de comisiones de un vendedor.
+type CommissionStatement struct {
+	Seller      Seller
+	Month       time.Time
+	Basis       string
+	Volume      float64
+	TierPercent float64
+	Lines       []CommissionLine
+	Total       float64
+}
+
+// Calculate liquida las comisiones de las ventas del mes según el plan.
+func (p CommissionPlan) Calculate(seller Seller, month time.Time, sales []SellerSale, parents map[int]int) CommissionStatement {
+	statement := CommissionStatement{Seller: seller, Month: month, Basis: p.Basis}
+	for _, s := range sales {
+		statement.Volume += s.Total
+	}
+	statement.TierPercent = p.TierPercent(statement.Volume)
+
+	for _, s := range sales {
+		line := CommissionLine{SellerSale: s, Base: s.Total, Percent: statement.TierPercent}
+		if p.Basis == CommissionOnMargin {
+			line.Base = s.Total - s.Cost
+		}
+		if rate, ok := p.categoryRate(s.CategoryID, parents); ok {
+			line.Percent = rate
+		}
+		line.Commission = line.Base * line.Percent / 100
+		statement.Lines = append(statement.Lines, line)
+		statement.Total += line.Commission
+	}
+	return statement
+}
\ No newline at end of file
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCommissionPlanTierPercent(t *testing.T) {
+	// Los escalones se cargan desordenados a propósito.
+	plan := CommissionPlan{Percent: 2, Tiers: []CommissionTier{{From: 5000, Percent: 4}, {From: 1000, Percent: 3}}}
+	tests := []struct {
+		name   string
+		plan   CommissionPlan
+		volume float64
+		want   float64
+	}{
+		{"sin escalones", CommissionPlan{Percent: 2}, 100000, 2},
+		{"debajo del primer escalón", plan, 999.99, 2},
+		{"justo en el primer escalón", plan, 1000, 3},
+		{"entre escalones", plan, 4999, 3},
+		{"justo en el segundo escalón", plan, 5000, 4},
+		{"sobre el último escalón", plan, 20000, 4},
+		{"sin ventas", plan, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.plan.TierPercent(tt.volume); got != tt.want {
+				t.Errorf("TierPercent(%g) = %g, se esperaba %g", tt.volume, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommissionPlanCalculate(t *testing.T) {
+	month := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	// Categorías: 2 es hija de 1 y 3 no tiene tasa propia ni padre con tasa.
+	parents := map[int]int{1: 0, 2: 1, 3: 0}
+	sales := []SellerSale{
+		{SaleID: 1, CategoryID: 3, Total: 600, Cost: 400},
+		{SaleID: 2, CategoryID: 2, Total: 500, Cost: 300},
+	}
+	tests := []struct {
+		name        string
+		plan        CommissionPlan
+		tierPercent float64
+		percents    []float64
+		commissions []float64
+		total       float64
+	}{
+		{
+			name:        "sobre la venta",
+			plan:        CommissionPlan{Basis: CommissionOnSale, Percent: 2},
+			tierPercent: 2,
+			percents:    []float64{2, 2},
+			commissions: []float64{12, 10},
+			total:       22,
+		},
+		{
+			name:        "escalón alcanzado",
+			plan:        CommissionPlan{Basis: CommissionOnSale, Percent: 2, Tiers: []CommissionTier{{From: 1000, Percent: 3}}},
+			tierPercent: 3,
+			percents:    []float64{3, 3},
+			commissions: []float64{18, 15},
+			total:       33,
+		},
+		{
+			name:        "tasa heredada de la categoría padre",
+			plan:        CommissionPlan{Basis: CommissionOnSale, Percent: 2, Tiers: []CommissionTier{{From: 1000, Percent: 3}}, CategoryRates: map[int]float64{1: 10}},
+			tierPercent: 3,
+			percents:    []float64{3, 10},
+			commissions: []float64{18, 50},
+			total:       68,
+		},
+		{
+			name:        "sobre el margen",
+			plan:        CommissionPlan{Basis: CommissionOnMargin, Percent: 5, CategoryRates: map[int]float64{2: 10}},
+			tierPercent: 5,
+			percents:    []float64{5, 10},
+			commissions: []float64{10, 20},
+			total:       30,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := tt.plan.Calculate(Seller{ID: 1, Name: "Vendedor"}, month, sales, parents)
+			if st.Volume != 1100 {
+				t.Errorf("volumen %g, se esperaba 1100", st.Volume)
+			}
+			if st.TierPercent != tt.tierPercent {
+				t.Errorf("porcentaje del mes %g, se esperaba %g", st.TierPercent, tt.tierPercent)
+			}
+			if len(st.Lines) != len(sales) {
+				t.Fatalf("se obtuvieron %d líneas, se esperaban %d", len(st.Lines), len(sales))
+			}
+			for i, line := range st.Lines {
+				if line.Percent != tt.percents[i] {
+					t.Errorf("venta #%d: porcentaje %g, se esperaba %g", line.SaleID, line.Percent, tt.percents[i])
+				}
+				if math.Abs(line.Commission-tt.commissions[i]) > 1e-9 {
+					t.Errorf("venta #%d: comisión %g, se esperaba %g", line.SaleID, line.Commission, tt.commissions[i])
+				}
+			}
+			if math.Abs(st.Total-tt.total) > 1e-9 {
+				t.Errorf("total %g, se esperaba %g", st.Total, tt.total)
+			}
+		})
+	}
+}
\ No newline at end of file
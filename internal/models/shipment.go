@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+const (
+	ShipmentPending   = "Pendiente"
+	ShipmentOnRoute   = "En ruta"
+	ShipmentDelivered = "Entregado"
+	ShipmentFailed    = "Fallido"
+)
+
+// shipmentTransitions son los cambios de estado permitidos de un envío. Un
+// envío fallido vuelve a quedar pendiente cuando se reprograma.
+var shipmentTransitions = map[string][]string{
+	ShipmentPending: {ShipmentOnRoute},
+	ShipmentOnRoute: {ShipmentDelivered, ShipmentFailed},
+	ShipmentFailed:  {ShipmentPending},
+}
+
+// Shipment es el envío a domicilio de un ticket. SaleID es la primera venta
+// del ticket e Items todas las que viajan en el envío. El costo de envío (Fee)
+// se cobra aparte y no forma parte del total de la venta. Zone agrupa los
+// envíos en la hoja de ruta.
+type Shipment struct {
+	ID            int
+	SaleID        int
+	Client        string
+	Address       string
+	Zone          string
+	ScheduledDate time.Time
+	Courier       string
+	Fee           float64
+	Status        string
+	Note          string
+	// SaleStatus es el estado de pago de la venta y Phone el teléfono del
+	// cliente, para la hoja de ruta.
+	SaleStatus string
+	Phone      string
+	Items      []ShipmentItem
+}
+
+// ShipmentItem es una venta (una línea del ticket) que viaja en un envío.
+type ShipmentItem struct {
+	SaleID      int
+	ProductName string
+	Quantity    float64
+}
+
+// NextStatuses devuelve los estados a los que puede pasar el envío.
+func (s Shipment) NextStatuses() []string {
+	return shipmentTransitions[s.Status]
+}
+
+// CanMoveTo indica si el envío puede pasar al estado indicado.
+func (s Shipment) CanMoveTo(status string) bool {
+	for _, next := range s.NextStatuses() {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
\ No newline at end of file
@@ -0,0 +1,30 @@
+package models
+
+import "testing"
+
+func TestShipmentCanMoveTo(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{ShipmentPending, ShipmentOnRoute, true},
+		{ShipmentPending, ShipmentDelivered, false},
+		{ShipmentPending, ShipmentFailed, false},
+		{ShipmentOnRoute, ShipmentDelivered, true},
+		{ShipmentOnRoute, ShipmentFailed, true},
+		{ShipmentOnRoute, ShipmentPending, false},
+		{ShipmentFailed, ShipmentPending, true},
+		{ShipmentFailed, ShipmentDelivered, false},
+		{ShipmentDelivered, ShipmentPending, false},
+		{ShipmentDelivered, ShipmentFailed, false},
+		{"", ShipmentOnRoute, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+" a "+tt.to, func(t *testing.T) {
+			s := Shipment{Status: tt.from}
+			if got := s.CanMoveTo(tt.to); got != tt.want {
+				t.Errorf("CanMoveTo(%q) desde %q = %v, se esperaba %v", tt.to, tt.from, got, tt.want)
+			}
+		})
+	}
+}
\ No newline at end of file
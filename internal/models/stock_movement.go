@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+const (
+	MovementReceipt    = "Entrada"
+	MovementSale       = "Venta"
+	MovementReturn     = "Devolución"
+	MovementAdjustment = "Ajuste"
+	MovementTransfer   = "Transferencia"
+)
+
+// StockMovement registra cada cambio en el stock de un producto. La cantidad
+// es positiva para entradas y negativa para salidas.
+type StockMovement struct {
+	ID         int
+	Date       time.Time
+	ProductID  int
+	LocationID int
+	Type       string
+	Quantity   float64
+	UnitCost   float64
+	Reference  string
+}
\ No newline at end of file
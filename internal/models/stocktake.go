@@ -0,0 +1,53 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	StocktakeOpen      = "Abierta"
+	StocktakeApplied   = "Aplicada"
+	StocktakeCancelled = "Cancelada"
+)
+
+// Stocktake es una toma de inventario físico de una ubicación. Los conteos se
+// cargan en una o varias sesiones y recién se aplican al stock cuando un
+// supervisor los confirma.
+type Stocktake struct {
+	ID          int
+	Date        time.Time
+	LocationID  int
+	Status      string
+	Supervisor  string
+	AppliedDate time.Time
+	Counts      []StocktakeCount
+}
+
+// StocktakeCount es la cantidad contada de un producto. Expected guarda el
+// stock del sistema en el momento del primer conteo, de modo que las ventas
+// posteriores no se tomen como faltante.
+type StocktakeCount struct {
+	ID          int
+	StocktakeID int
+	ProductID   int
+	Expected    float64
+	Counted     float64
+	UnitCost    float64
+}
+
+// Variance devuelve la diferencia entre lo contado y lo esperado; es negativa
+// si falta mercadería.
+func (c StocktakeCount) Variance() float64 {
+	return c.Counted - c.Expected
+}
+
+// VarianceValue valoriza la diferencia al costo unitario del producto.
+func (c StocktakeCount) VarianceValue() float64 {
+	return c.Variance() * c.UnitCost
+}
+
+// HasVariance indica si lo contado difiere de lo esperado.
+func (c StocktakeCount) HasVariance() bool {
+	return math.Abs(c.Variance()) > 1e-9
+}
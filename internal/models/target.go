@@ -0,0 +1,100 @@
+package models
+
+import "time"
+
+const (
+	TargetScopeStore    = "Local"
+	TargetScopeCategory = "Categoría"
+	TargetScopeSeller   = "Vendedor"
+)
+
+// SalesTarget es la meta de ventas de un mes para un local, una categoría o
+// un vendedor. RefID es el ID de la ubicación, la categoría o el vendedor.
+// La meta de una categoría incluye las ventas de sus subcategorías.
+type SalesTarget struct {
+	Month  time.Time
+	Scope  string
+	RefID  int
+	Amount float64
+}
+
+// TargetSale es una venta con los datos que se usan para asignarla a las
+// metas.
+type TargetSale struct {
+	Date       time.Time
+	LocationID int
+	CategoryID int
+	SellerID   int
+	Total      float64
+}
+
+// TargetProgress es el avance de una meta: lo vendido hasta ahora y lo que se
+// proyecta vender a fin de mes si se mantiene el ritmo.
+type TargetProgress struct {
+	SalesTarget
+	Name      string
+	Actual    float64
+	Projected float64
+}
+
+// Percent devuelve lo vendido como porcentaje de la meta.
+func (p TargetProgress) Percent() float64 {
+	if p.Amount == 0 {
+		return 0
+	}
+	return p.Actual / p.Amount * 100
+}
+
+// ProjectedPercent devuelve la proyección como porcentaje de la meta.
+func (p TargetProgress) ProjectedPercent() float64 {
+	if p.Amount == 0 {
+		return 0
+	}
+	return p.Projected / p.Amount * 100
+}
+
+// Matches indica si la venta cuenta para la meta. parents vincula cada
+// categoría con su padre.
+func (t SalesTarget) Matches(s TargetSale, parents map[int]int) bool {
+	switch t.Scope {
+	case TargetScopeStore:
+		return s.LocationID == t.RefID
+	case TargetScopeSeller:
+		return s.SellerID == t.RefID
+	case TargetScopeCategory:
+		seen := make(map[int]bool)
+		for id := s.CategoryID; id != 0 && !seen[id]; id = parents[id] {
+			if id == t.RefID {
+				return true
+			}
+			seen[id] = true
+		}
+	}
+	return false
+}
+
+// Progress suma las ventas del mes que cuentan para la meta y proyecta el
+// cierre del mes a la fecha now.
+func (t SalesTarget) Progress(sales []TargetSale, parents map[int]int, now time.Time) TargetProgress {
+	progress := TargetProgress{SalesTarget: t}
+	for _, s := range sales {
+		if t.Matches(s, parents) {
+			progress.Actual += s.Total
+		}
+	}
+	progress.Projected = ProjectRunRate(progress.Actual, t.Month, now)
+	return progress
+}
+
+// ProjectRunRate estima lo que se venderá en el mes si se mantiene el
+// promedio diario de los días transcurridos (contando el día de hoy). Para
+// un mes cerrado devuelve lo vendido.
+func ProjectRunRate(actual float64, month, now time.Time) float64 {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, now.Location())
+	end := start.AddDate(0, 1, 0)
+	if !now.Before(end) || now.Before(start) {
+		return actual
+	}
+	days := end.AddDate(0, 0, -1).Day()
+	return actual / float64(now.Day()) * float64(days)
+}
\ No newline at end of file
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestProjectRunRate(t *testing.T) {
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	february := time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name   string
+		actual float64
+		month  time.Time
+		now    time.Time
+		want   float64
+	}{
+		{"primer día", 100, march, time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local), 3100},
+		{"a mitad de mes", 100, march, time.Date(2024, 3, 10, 18, 0, 0, 0, time.Local), 310},
+		{"último día", 100, march, time.Date(2024, 3, 31, 23, 0, 0, 0, time.Local), 100},
+		{"febrero bisiesto", 100, february, time.Date(2024, 2, 10, 12, 0, 0, 0, time.Local), 290},
+		{"mes cerrado", 100, february, time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), 100},
+		{"mes futuro", 100, march, time.Date(2024, 2, 20, 0, 0, 0, 0, time.Local), 100},
+		{"sin ventas", 0, march, time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProjectRunRate(tt.actual, tt.month, tt.now); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ProjectRunRate = %g, se esperaba %g", got, tt.want)
+			}
+		})
+	}
+}
\ No newline at end of file
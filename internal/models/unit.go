@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Unidades de medida en las que se puede vender un producto.
+const (
+	UnitPiece      = "unidad"
+	UnitKilogram   = "kg"
+	UnitGram       = "g"
+	UnitLiter      = "l"
+	UnitMilliliter = "ml"
+	UnitMeter      = "m"
+	UnitCentimeter = "cm"
+)
+
+// Units es la lista de unidades en el orden en que se ofrecen al usuario.
+var Units = []string{UnitPiece, UnitKilogram, UnitGram, UnitLiter, UnitMilliliter, UnitMeter, UnitCentimeter}
+
+// DefaultDecimals es la cantidad de decimales con que se maneja cada unidad
+// si el producto no indica otra precisión.
+var DefaultDecimals = map[string]int{
+	UnitPiece:      0,
+	UnitKilogram:   3,
+	UnitGram:       0,
+	UnitLiter:      3,
+	UnitMilliliter: 0,
+	UnitMeter:      2,
+	UnitCentimeter: 0,
+}
+
+// unitBase indica, para las unidades físicas, su magnitud y cuántas unidades
+// base representan (la base es g, ml o cm).
+var unitBase = map[string]struct {
+	dimension string
+	factor    float64
+}{
+	UnitKilogram:   {"masa", 1000},
+	UnitGram:       {"masa", 1},
+	UnitLiter:      {"volumen", 1000},
+	UnitMilliliter: {"volumen", 1},
+	UnitMeter:      {"longitud", 100},
+	UnitCentimeter: {"longitud", 1},
+}
+
+// ConvertQuantity convierte una cantidad entre unidades de la misma magnitud
+// (por ejemplo g a kg). Devuelve error si las unidades no son compatibles.
+func ConvertQuantity(quantity float64, from, to string) (float64, error) {
+	if from == to {
+		return quantity, nil
+	}
+	f, okFrom := unitBase[from]
+	t, okTo := unitBase[to]
+	if !okFrom || !okTo || f.dimension != t.dimension {
+		return 0, fmt.Errorf("no se puede convertir de %s a %s", from, to)
+	}
+	return quantity * f.factor / t.factor, nil
+}
+
+// RoundQuantity redondea una cantidad a la cantidad de decimales indicada.
+func RoundQuantity(quantity float64, decimals int) float64 {
+	pow := math.Pow(10, float64(decimals))
+	return math.Round(quantity*pow) / pow
+}
+
+// FormatQuantity muestra una cantidad con la precisión indicada, sin
+// decimales sobrantes para las cantidades enteras.
+func FormatQuantity(quantity float64, decimals int) string {
+	if decimals <= 0 {
+		return fmt.Sprintf("%.0f", quantity)
+	}
+	return fmt.Sprintf("%.*f", decimals, quantity)
+}
+
+// NormalizeUnit devuelve la unidad conocida que corresponde al texto
+// ingresado, o "unidad" si no se reconoce.
+func NormalizeUnit(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, u := range Units {
+		if s == u {
+			return u
+		}
+	}
+	return UnitPiece
+}
\ No newline at end of file
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvertQuantity(t *testing.T) {
+	tests := []struct {
+		quantity float64
+		from, to string
+		want     float64
+		wantErr  bool
+	}{
+		{1500, UnitGram, UnitKilogram, 1.5, false},
+		{0.25, UnitLiter, UnitMilliliter, 250, false},
+		{2.5, UnitMeter, UnitCentimeter, 250, false},
+		{3, UnitPiece, UnitPiece, 3, false},
+		{1, UnitKilogram, UnitLiter, 0, true},
+		{1, UnitPiece, UnitGram, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+" a "+tt.to, func(t *testing.T) {
+			got, err := ConvertQuantity(tt.quantity, tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ConvertQuantity(%g) = %g, se esperaba %g", tt.quantity, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundQuantity(t *testing.T) {
+	tests := []struct {
+		quantity float64
+		decimals int
+		want     float64
+	}{
+		{2.4, 0, 2},
+		{2.5, 0, 3},
+		{1.23456, 3, 1.235},
+		{0.1 + 0.2, 3, 0.3},
+		{-0.75, 1, -0.8},
+	}
+	for _, tt := range tests {
+		if got := RoundQuantity(tt.quantity, tt.decimals); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("RoundQuantity(%g, %d) = %g, se esperaba %g", tt.quantity, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestFormatQuantity(t *testing.T) {
+	tests := []struct {
+		quantity float64
+		decimals int
+		want     string
+	}{
+		{3, 0, "3"},
+		{2.6, 0, "3"},
+		{1.5, 3, "1.500"},
+		{7, 2, "7.00"},
+	}
+	for _, tt := range tests {
+		if got := FormatQuantity(tt.quantity, tt.decimals); got != tt.want {
+			t.Errorf("FormatQuantity(%g, %d) = %q, se esperaba %q", tt.quantity, tt.decimals, got, tt.want)
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"database/sql"
+	"sales-system/internal/models"
+)
+
+type CategoryRepo struct {
+	db *sql.DB
+}
+
+func NewCategoryRepo(db *sql.DB) *CategoryRepo {
+	return &CategoryRepo{db: db}
+}
+
+func (r *CategoryRepo) CreateCategory(c models.Category) (int64, error) {
+	res, err := r.db.Exec("INSERT INTO categories (name, parent_id) VALUES (?, ?)", c.Name, c.ParentID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return id, err
+}
+
+func (r *CategoryRepo) GetAllCategories() ([]models.Category, error) {
+	rows, err := r.db.Query("SELECT id, name, parent_id FROM categories ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		var c models.Category
+		if err := rows.Scan(&c.ID, &c.Name, &c.ParentID); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, nil
+}
+
+// DeleteCategory elimina la categoría; sus subcategorías pasan a depender de
+// la categoría padre y sus productos quedan sin categoría.
+func (r *CategoryRepo) DeleteCategory(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var parentID int
+	if err := tx.QueryRow("SELECT parent_id FROM categories WHERE id = ?", id).Scan(&parentID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE categories SET parent_id = ? WHERE parent_id = ?", parentID, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE products SET category_id = 0 WHERE category_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM categories WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetCategoryPaths devuelve el nombre completo de cada categoría incluyendo
+// sus ancestros, por ejemplo "Bebidas > Gaseosas".
+func (r *CategoryRepo) GetCategoryPaths() (map[int]string, error) {
+	categories, err := r.GetAllCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]models.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	paths := make(map[int]string, len(categories))
+	for _, c := range categories {
+		path := c.Name
+		seen := map[int]bool{c.ID: true}
+		for parent, ok := byID[c.ParentID]; ok && !seen[parent.ID]; parent, ok = byID[parent.ParentID] {
+			seen[parent.ID] = true
+			path = parent.Name + " > " + path
+		}
+		paths[c.ID] = path
+	}
+	return paths, nil
+}
+
+// GetDescendantIDs devuelve el ID de la categoría junto con los de todas sus subcategorías.
+func (r *CategoryRepo) GetDescendantIDs(id int) ([]int, error) {
+	categories, err := r.GetAllCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []int{id}
+	for i := 0; i < len(ids); i++ {
+		for _, c := range categories {
+			if c.ParentID == ids[i] && c.ID != id {
+				ids = append(ids, c.ID)
+			}
+		}
+	}
+	return ids, nil
+}
\ No newline at end of file
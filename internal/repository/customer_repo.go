@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"sales-system/internal/models"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type CustomerRepo struct {
+	db *sql.DB
+}
+
+func NewCustomerRepo(db *sql.DB) *CustomerRepo {
+	return &CustomerRepo{db: db}
+}
+
+const customerColumns = "id, name, phone, credit_limit, payment_terms"
+
+func scanCustomer(row scanner) (*models.Customer, error) {
+	var c models.Customer
+	if err := row.Scan(&c.ID, &c.Name, &c.Phone, &c.CreditLimit, &c.PaymentTerms); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *CustomerRepo) CreateCustomer(c models.Customer) (int64, error) {
+	res, err := r.db.Exec("INSERT INTO customers (name, phone, credit_limit, payment_terms) VALUES (?, ?, ?, ?)", c.Name, c.Phone, c.CreditLimit, c.PaymentTerms)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// UpdateCustomer actualiza los datos de cuenta del cliente. El nombre no se
+// cambia porque es el que vincula al cliente con sus ventas.
+func (r *CustomerRepo) UpdateCustomer(c models.Customer) error {
+	_, err := r.db.Exec("UPDATE customers SET phone = ?, credit_limit = ?, payment_terms = ? WHERE id = ?", c.Phone, c.CreditLimit, c.PaymentTerms, c.ID)
+	return err
+}
+
+func (r *CustomerRepo) GetCustomerByID(id int) (*models.Customer, error) {
+	return scanCustomer(r.db.QueryRow("SELECT "+customerColumns+" FROM customers WHERE id = ?", id))
+}
+
+// GetCustomerByName busca un cliente por nombre sin distinguir mayúsculas.
+// Devuelve sql.ErrNoRows si no está registrado.
+func (r *CustomerRepo) GetCustomerByName(name string) (*models.Customer, error) {
+	return scanCustomer(r.db.QueryRow("SELECT "+customerColumns+" FROM customers WHERE name = ?", name))
+}
+
+func (r *CustomerRepo) GetAllCustomers() ([]models.Customer, error) {
+	rows, err := r.db.Query("SELECT " + customerColumns + " FROM customers ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var customers []models.Customer
+	for rows.Next() {
+		c, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, *c)
+	}
+	return customers, rows.Err()
+}
+
+// GetCreditStatus calcula lo que debe el cliente (ventas pendientes menos los
+// pagos a cuenta que todavía no alcanzaron para cancelar una venta) y cuánto
+// de eso está vencido según sus días de pago.
+func (r *CustomerRepo) GetCreditStatus(c models.Customer, now time.Time) (models.CreditStatus, error) {
+	var status models.CreditStatus
+	unapplied, err := unappliedCredit(r.db, c.Name)
+	if err != nil {
+		return status, err
+	}
+
+	rows, err := r.db.Query("SELECT date, total FROM sales WHERE client = ? COLLATE NOCASE AND status = ? ORDER BY date, id", c.Name, models.StatusPending)
+	if err != nil {
+		return status, err
+	}
+	defer rows.Close()
+
+	// El crédito sin imputar cubre primero las ventas más antiguas.
+	today := now.Format(effectiveDateLayout)
+	cover := unapplied
+	for rows.Next() {
+		var dateStr string
+		var total float64
+		if err := rows.Scan(&dateStr, &total); err != nil {
+			return status, err
+		}
+		status.Balance += total
+		owed := total - min(cover, total)
+		cover -= total - owed
+		date, _ := time.Parse(time.RFC3339, dateStr)
+		if owed > 0.005 && date.AddDate(0, 0, c.PaymentTerms).Format(effectiveDateLayout) < today {
+			status.Overdue += owed
+			status.OverdueCount++
+		}
+	}
+	status.Balance -= unapplied
+	return status, rows.Err()
+}
+
+// unappliedCredit devuelve lo pagado a cuenta por el cliente que no se usó
+// para cancelar ventas.
+func unappliedCredit(q queryRower, client string) (float64, error) {
+	var paid, settled float64
+	if err := q.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM customer_payments WHERE client = ? COLLATE NOCASE", client).Scan(&paid); err != nil {
+		return 0, err
+	}
+	if err := q.QueryRow("SELECT COALESCE(SUM(total), 0) FROM sales WHERE client = ? COLLATE NOCASE AND payment_id > 0", client).Scan(&settled); err != nil {
+		return 0, err
+	}
+	return paid - settled, nil
+}
+
+// RegisterPayment registra un pago o una nota de crédito del cliente y marca
+// como pagadas sus ventas pendientes, de la más antigua a la más nueva,
+// mientras el crédito disponible alcance para cancelarlas completas. Devuelve
+// cuántas ventas se cancelaron.
+func (r *CustomerRepo) RegisterPayment(p models.CustomerPayment) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO customer_payments (date, client, amount, type, note) VALUES (?, ?, ?, ?, ?)", p.Date.Format(time.RFC3339), p.Client, p.Amount, p.Type, p.Note)
+	if err != nil {
+		return 0, err
+	}
+	paymentID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	available, err := unappliedCredit(tx, p.Client)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.Query("SELECT id, total FROM sales WHERE client = ? COLLATE NOCASE AND status = ? ORDER BY date, id", p.Client, models.StatusPending)
+	if err != nil {
+		return 0, err
+	}
+	var settle []int
+	for rows.Next() {
+		var id int
+		var total float64
+		if err := rows.Scan(&id, &total); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if total > available+0.005 {
+			break
+		}
+		available -= total
+		settle = append(settle, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range settle {
+		if _, err := tx.Exec("UPDATE sales SET status = ?, payment_id = ? WHERE id = ?", models.StatusPaid, paymentID, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(settle), tx.Commit()
+}
+
+// GetStatement arma el estado de cuenta del cliente entre dos fechas. Cada
+// venta suma a la deuda; las pagadas en el momento llevan además su pago, y
+// los pagos a cuenta y las notas de crédito la restan. Las ventas apartadas
+// se siguen en su plan de cuotas y las tarjetas de regalo se pagan al
+// comprarlas, por lo que no forman parte de la cuenta.
+func (r *CustomerRepo) GetStatement(c models.Customer, start, end time.Time) (models.Statement, error) {
+	statement := models.Statement{Customer: c, Start: start, End: end}
+
+	var lines []models.StatementLine
+	rows, err := r.db.Query("SELECT id, date, product_name, quantity, total, status, payment_id FROM sales WHERE client = ? COLLATE NOCASE AND status <> ? AND product_id <> 0 AND date <= ?",
+		c.Name, models.StatusLayaway, end.Format(time.RFC3339))
+	if err != nil {
+		return statement, err
+	}
+	for rows.Next() {
+		var id, paymentID int
+		var dateStr, name, status string
+		var quantity, total float64
+		if err := rows.Scan(&id, &dateStr, &name, &quantity, &total, &status, &paymentID); err != nil {
+			rows.Close()
+			return statement, err
+		}
+		date, _ := time.Parse(time.RFC3339, dateStr)
+		lines = append(lines, models.StatementLine{Date: date, Description: fmt.Sprintf("Venta #%d - %s x%s", id, name, strconv.FormatFloat(quantity, 'f', -1, 64)), Debit: total})
+		if status == models.StatusPaid && paymentID == 0 {
+			lines = append(lines, models.StatementLine{Date: date, Description: fmt.Sprintf("Pago contado venta #%d", id), Credit: total})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return statement, err
+	}
+
+	rows, err = r.db.Query("SELECT id, date, amount, type, note FROM customer_payments WHERE client = ? COLLATE NOCASE AND date <= ?", c.Name, end.Format(time.RFC3339))
+	if err != nil {
+		return statement, err
+	}
+	for rows.Next() {
+		var id int
+		var dateStr, paymentType, note string
+		var amount float64
+		if err := rows.Scan(&id, &dateStr, &amount, &paymentType, &note); err != nil {
+			rows.Close()
+			return statement, err
+		}
+		date, _ := time.Parse(time.RFC3339, dateStr)
+		description := fmt.Sprintf("%s #%d", paymentType, id)
+		if note != "" {
+			description += " - " + note
+		}
+		lines = append(lines, models.StatementLine{Date: date, Description: description, Credit: amount})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return statement, err
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Date.Before(lines[j].Date) })
+	balance := 0.0
+	for _, l := range lines {
+		balance += l.Debit - l.Credit
+		if l.Date.Before(start) {
+			statement.Opening = balance
+			continue
+		}
+		l.Balance = balance
+		statement.Lines = append(statement.Lines, l)
+	}
+	return statement, nil
+}
+
+// RecordCreditOverride deja constancia de una venta a crédito autorizada por
+// un supervisor.
+func (r *CustomerRepo) RecordCreditOverride(o models.CreditOverride) error {
+	_, err := r.db.Exec("INSERT INTO credit_overrides (date, client, supervisor, amount, reason) VALUES (?, ?, ?, ?, ?)", o.Date.Format(time.RFC3339), o.Client, o.Supervisor, o.Amount, o.Reason)
+	return err
+}
+
+// GetCreditOverrides devuelve las autorizaciones de crédito de un cliente, de
+// la más reciente a la más antigua.
+func (r *CustomerRepo) GetCreditOverrides(client string) ([]models.CreditOverride, error) {
+	rows, err := r.db.Query("SELECT id, date, client, supervisor, amount, reason FROM credit_overrides WHERE client = ? COLLATE NOCASE ORDER BY id DESC", client)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var overrides []models.CreditOverride
+	for rows.Next() {
+		var o models.CreditOverride
+		var dateStr string
+		if err := rows.Scan(&o.ID, &dateStr, &o.Client, &o.Supervisor, &o.Amount, &o.Reason); err != nil {
+			return nil, err
+		}
+		o.Date, _ = time.Parse(time.RFC3339, dateStr)
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
\ No newline at end of file
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+	"sales-system/internal/models"
+	"strings"
+	"time"
+)
+
+type ExchangeRateRepo struct {
+	db *sql.DB
+}
+
+func NewExchangeRateRepo(db *sql.DB) *ExchangeRateRepo {
+	return &ExchangeRateRepo{db: db}
+}
+
+// SetRates guarda las cotizaciones. Si ya había una para la misma moneda y
+// día, se reemplaza.
+func (r *ExchangeRateRepo) SetRates(rates []models.ExchangeRate) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, rate := range rates {
+		_, err := tx.Exec("INSERT OR REPLACE INTO exchange_rates (date, currency, rate) VALUES (?, ?, ?)",
+			rate.Date.Format(effectiveDateLayout), strings.ToUpper(rate.Currency), rate.Rate)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetRate devuelve la cotización vigente de la moneda en la fecha: la del
+// mismo día o, si no se cargó, la última anterior. Devuelve sql.ErrNoRows si
+// no hay ninguna.
+func (r *ExchangeRateRepo) GetRate(currency string, date time.Time) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	var dateStr string
+	err := r.db.QueryRow("SELECT date, currency, rate FROM exchange_rates WHERE currency = ? AND date <= ? ORDER BY date DESC LIMIT 1",
+		strings.ToUpper(currency), date.Format(effectiveDateLayout)).Scan(&dateStr, &rate.Currency, &rate.Rate)
+	if err != nil {
+		return nil, err
+	}
+	rate.Date, _ = time.Parse(effectiveDateLayout, dateStr)
+	return &rate, nil
+}
+
+// GetRecentRates devuelve las últimas cotizaciones cargadas, de la más nueva
+// a la más antigua.
+func (r *ExchangeRateRepo) GetRecentRates(limit int) ([]models.ExchangeRate, error) {
+	rows, err := r.db.Query("SELECT date, currency, rate FROM exchange_rates ORDER BY date DESC, currency LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.ExchangeRate
+	for rows.Next() {
+		var rate models.ExchangeRate
+		var dateStr string
+		if err := rows.Scan(&dateStr, &rate.Currency, &rate.Rate); err != nil {
+			return nil, err
+		}
+		rate.Date, _ = time.Parse(effectiveDateLayout, dateStr)
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
\ No newline at end of file
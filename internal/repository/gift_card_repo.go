@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"sales-system/internal/models"
+	"strings"
+	"time"
+)
+
+type GiftCardRepo struct {
+	db *sql.DB
+}
+
+func NewGiftCardRepo(db *sql.DB) *GiftCardRepo {
+	return &GiftCardRepo{db: db}
+}
+
+// giftCardAlphabet deja afuera las letras y números que se confunden al
+// dictar o leer un código (0/O, 1/I).
+const giftCardAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// newGiftCardCode genera un código al azar con el formato PRE-XXXX-XXXX-XXXX.
+func newGiftCardCode(prefix string) (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(prefix)
+	for i, c := range buf {
+		if i%4 == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(giftCardAlphabet[int(c)%len(giftCardAlphabet)])
+	}
+	return b.String(), nil
+}
+
+// giftCardColumns lleva al final el saldo de la tarjeta, calculado con una
+// subconsulta sobre sus movimientos.
+const giftCardColumns = "g.id, g.code, g.kind, g.client, g.issued_at"
+
+func scanGiftCard(row scanner) (*models.GiftCard, error) {
+	var c models.GiftCard
+	var issuedStr string
+	if err := row.Scan(&c.ID, &c.Code, &c.Kind, &c.Client, &issuedStr, &c.Balance); err != nil {
+		return nil, err
+	}
+	c.IssuedAt, _ = time.Parse(time.RFC3339, issuedStr)
+	return &c, nil
+}
+
+// SellCard registra en una sola transacción la venta de una tarjeta de
+// regalo. Si card no tiene ID se da de alta una tarjeta nueva y el importe de
+// la venta se carga como emisión; si no, como recarga. La venta lleva el
+// código de la tarjeta como SKU. Devuelve la tarjeta con su saldo nuevo y el
+// ID de la venta.
+func (r *GiftCardRepo) SellCard(card models.GiftCard, s models.Sale) (*models.GiftCard, int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	movementType := models.GiftCardTopUp
+	if card.ID == 0 {
+		created, err := createCard(tx, card)
+		if err != nil {
+			return nil, 0, err
+		}
+		card, movementType = *created, models.GiftCardIssue
+	}
+	s.ProductSKU = card.Code
+	saleID, err := insertSale(tx, s)
+	if err != nil {
+		return nil, 0, err
+	}
+	movement := models.GiftCardMovement{CardID: card.ID, Date: s.Date, Amount: s.Total, Type: movementType, SaleID: int(saleID)}
+	if err := addCardMovement(tx, movement); err != nil {
+		return nil, 0, err
+	}
+	card.Balance += s.Total
+	return &card, saleID, tx.Commit()
+}
+
+// createCard da de alta una tarjeta sin saldo con un código nuevo y único.
+// El saldo inicial se carga con addCardMovement.
+func createCard(q execQueryRower, c models.GiftCard) (*models.GiftCard, error) {
+	prefix := "TR"
+	if c.Kind == models.GiftCardKindCredit {
+		prefix = "CT"
+	}
+	for {
+		code, err := newGiftCardCode(prefix)
+		if err != nil {
+			return nil, err
+		}
+		var exists bool
+		if err := q.QueryRow("SELECT EXISTS (SELECT 1 FROM gift_cards WHERE code = ?)", code).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if exists {
+			continue
+		}
+		res, err := q.Exec("INSERT INTO gift_cards (code, kind, client, issued_at) VALUES (?, ?, ?, ?)", code, c.Kind, c.Client, c.IssuedAt.Format(time.RFC3339))
+		if err != nil {
+			return nil, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		c.ID, c.Code = int(id), code
+		return &c, nil
+	}
+}
+
+// GetCardByCode busca una tarjeta por su código, con el saldo actual.
+// Devuelve sql.ErrNoRows si el código no existe.
+func (r *GiftCardRepo) GetCardByCode(code string) (*models.GiftCard, error) {
+	return scanGiftCard(r.db.QueryRow("SELECT "+giftCardColumns+`,
+			COALESCE((SELECT SUM(m.amount) FROM gift_card_movements m WHERE m.card_id = g.id), 0)
+		FROM gift_cards g WHERE g.code = ?`, strings.TrimSpace(code)))
+}
+
+// GetMovements devuelve los movimientos de una tarjeta en orden.
+func (r *GiftCardRepo) GetMovements(cardID int) ([]models.GiftCardMovement, error) {
+	rows, err := r.db.Query("SELECT id, card_id, date, amount, type, sale_id, note FROM gift_card_movements WHERE card_id = ? ORDER BY date, id", cardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []models.GiftCardMovement
+	for rows.Next() {
+		var m models.GiftCardMovement
+		var dateStr string
+		if err := rows.Scan(&m.ID, &m.CardID, &dateStr, &m.Amount, &m.Type, &m.SaleID, &m.Note); err != nil {
+			return nil, err
+		}
+		m.Date, _ = time.Parse(time.RFC3339, dateStr)
+		movements = append(movements, m)
+	}
+	return movements, rows.Err()
+}
+
+// addCardMovement suma saldo a una tarjeta: emisión, recarga o devolución.
+func addCardMovement(e execer, m models.GiftCardMovement) error {
+	_, err := e.Exec("INSERT INTO gift_card_movements (card_id, date, amount, type, sale_id, note) VALUES (?, ?, ?, ?, ?, ?)",
+		m.CardID, m.Date.Format(time.RFC3339), m.Amount, m.Type, m.SaleID, m.Note)
+	return err
+}
+
+// redeemCard descuenta de la tarjeta lo que se usó para pagar una venta.
+// Falla si el saldo no alcanza.
+func redeemCard(tx *sql.Tx, cardID, saleID int, amount float64, date time.Time) error {
+	var balance float64
+	if err := tx.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM gift_card_movements WHERE card_id = ?", cardID).Scan(&balance); err != nil {
+		return err
+	}
+	if amount > balance+0.005 {
+		return fmt.Errorf("saldo insuficiente: la tarjeta tiene %.2f", balance)
+	}
+	_, err := tx.Exec("INSERT INTO gift_card_movements (card_id, date, amount, type, sale_id) VALUES (?, ?, ?, ?, ?)",
+		cardID, date.Format(time.RFC3339), -amount, models.GiftCardRedeem, saleID)
+	return err
+}
+
+// voidCardRedemptions devuelve a cada tarjeta lo que se consumió al pagar la
+// venta y todavía no se le devolvió. Devuelve lo reintegrado a cada una.
+func voidCardRedemptions(tx *sql.Tx, saleID int, date time.Time) ([]models.GiftCardPayment, error) {
+	rows, err := tx.Query(`SELECT m.card_id, g.code, -SUM(m.amount)
+		FROM gift_card_movements m
+		JOIN gift_cards g ON g.id = m.card_id
+		WHERE m.sale_id = ? AND m.type IN (?, ?)
+		GROUP BY m.card_id, g.code
+		HAVING -SUM(m.amount) > 0.005
+		ORDER BY m.card_id`, saleID, models.GiftCardRedeem, models.GiftCardVoid)
+	if err != nil {
+		return nil, err
+	}
+	var voided []models.GiftCardPayment
+	for rows.Next() {
+		var p models.GiftCardPayment
+		if err := rows.Scan(&p.CardID, &p.Code, &p.Amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		voided = append(voided, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, p := range voided {
+		movement := models.GiftCardMovement{CardID: p.CardID, Date: date, Amount: p.Amount, Type: models.GiftCardVoid, SaleID: saleID, Note: fmt.Sprintf("Venta #%d eliminada", saleID)}
+		if err := addCardMovement(tx, movement); err != nil {
+			return nil, err
+		}
+	}
+	return voided, nil
+}
+
+// GetOutstanding devuelve las tarjetas que tenían saldo al final del día
+// indicado, que es lo que el negocio debe a sus clientes a esa fecha.
+func (r *GiftCardRepo) GetOutstanding(at time.Time) ([]models.GiftCard, error) {
+	until := time.Date(at.Year(), at.Month(), at.Day(), 23, 59, 59, 0, at.Location()).Format(time.RFC3339)
+	rows, err := r.db.Query("SELECT "+giftCardColumns+`,
+			COALESCE((SELECT SUM(m.amount) FROM gift_card_movements m WHERE m.card_id = g.id AND m.date <= ?), 0)
+		FROM gift_cards g WHERE g.issued_at <= ? ORDER BY g.issued_at, g.id`, until, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []models.GiftCard
+	for rows.Next() {
+		c, err := scanGiftCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		if c.Balance > 0.005 {
+			cards = append(cards, *c)
+		}
+	}
+	return cards, rows.Err()
+}
+
+// GetRedeemedTotal devuelve lo cobrado con tarjetas de regalo y crédito en
+// tienda en el rango de fechas, descontando lo devuelto a las tarjetas por
+// ventas eliminadas.
+func (r *GiftCardRepo) GetRedeemedTotal(start, end time.Time) (float64, error) {
+	var total float64
+	err := r.db.QueryRow("SELECT COALESCE(-SUM(amount), 0) FROM gift_card_movements WHERE type IN (?, ?) AND date BETWEEN ? AND ?",
+		models.GiftCardRedeem, models.GiftCardVoid, start.Format(time.RFC3339), end.Format(time.RFC3339)).Scan(&total)
+	return total, err
+}
+
+// GetSoldTotal devuelve lo cobrado por emisiones y recargas de tarjetas de
+// regalo en el rango de fechas. Es dinero que entra pero no es venta: queda
+// como deuda hasta que se consume.
+func (r *GiftCardRepo) GetSoldTotal(start, end time.Time) (float64, error) {
+	var total float64
+	err := r.db.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM gift_card_movements WHERE type IN (?, ?) AND date BETWEEN ? AND ?",
+		models.GiftCardIssue, models.GiftCardTopUp, start.Format(time.RFC3339), end.Format(time.RFC3339)).Scan(&total)
+	return total, err
+}
\ No newline at end of file
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"database/sql"
+	"math"
+	"sales-system/internal/models"
+	"testing"
+)
+
+// testGiftCard vende una tarjeta de regalo con el saldo indicado.
+func testGiftCard(t *testing.T, db *sql.DB, balance float64) models.GiftCard {
+	t.Helper()
+	sale := models.Sale{Date: testDate, Quantity: 1, Price: balance, Total: balance, Status: models.StatusPaid, ProductName: "Tarjeta de regalo"}
+	card, _, err := NewGiftCardRepo(db).SellCard(models.GiftCard{Kind: models.GiftCardKindGift, IssuedAt: testDate}, sale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *card
+}
+
+// assertCardBalance verifica el saldo de la tarjeta.
+func assertCardBalance(t *testing.T, db *sql.DB, card models.GiftCard, want float64) {
+	t.Helper()
+	got, err := NewGiftCardRepo(db).GetCardByCode(card.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(got.Balance-want) > 1e-9 {
+		t.Errorf("saldo de %s %.2f, se esperaba %.2f", card.Code, got.Balance, want)
+	}
+}
+
+func TestRecordSaleRedeemsGiftCards(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      float64
+		wantErr     bool
+		wantBalance float64
+		wantStock   float64
+	}{
+		{"saldo suficiente", 60, false, 40, 8},
+		{"saldo insuficiente", 120, true, 100, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDatabase(t)
+			saleRepo := NewSaleRepo(db)
+			productID := testProduct(t, db, models.Product{Name: "Queso", Price: 50})
+			if err := NewProductRepo(db).ReceiveStock(productID, models.DefaultLocationID, 10, 5, testDate, "Compra", models.Lot{}); err != nil {
+				t.Fatal(err)
+			}
+			card := testGiftCard(t, db, 100)
+
+			sale := models.Sale{Date: testDate, LocationID: models.DefaultLocationID, ProductID: productID, Quantity: 2, Price: 50, Total: 100, Status: models.StatusPaid}
+			cards := []models.GiftCardPayment{{CardID: card.ID, Code: card.Code, Amount: tt.amount}}
+			_, err := saleRepo.RecordSale(sale, false, models.PointsRedemption{}, cards)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, se esperaba error: %v", err, tt.wantErr)
+			}
+			if sales, _ := saleRepo.GetAllSales(); tt.wantErr && len(sales) != 1 {
+				t.Errorf("quedaron %d ventas registradas, se esperaba solo la de la tarjeta", len(sales))
+			}
+			assertCardBalance(t, db, card, tt.wantBalance)
+			assertStock(t, db, productID, tt.wantStock)
+		})
+	}
+}
+
+func TestDeleteSaleVoidsGiftCardRedemptions(t *testing.T) {
+	db := testDatabase(t)
+	saleRepo, giftCardRepo := NewSaleRepo(db), NewGiftCardRepo(db)
+	productID := testProduct(t, db, models.Product{Name: "Queso", Price: 50})
+	if err := NewProductRepo(db).ReceiveStock(productID, models.DefaultLocationID, 10, 5, testDate, "Compra", models.Lot{}); err != nil {
+		t.Fatal(err)
+	}
+	gift, credit := testGiftCard(t, db, 100), testGiftCard(t, db, 30)
+
+	sale := models.Sale{Date: testDate, LocationID: models.DefaultLocationID, ProductID: productID, Quantity: 2, Price: 50, Total: 100, Status: models.StatusPaid}
+	cards := []models.GiftCardPayment{{CardID: credit.ID, Code: credit.Code, Amount: 30}, {CardID: gift.ID, Code: gift.Code, Amount: 50}}
+	saleID, err := saleRepo.RecordSale(sale, false, models.PointsRedemption{}, cards)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCardBalance(t, db, gift, 50)
+	assertCardBalance(t, db, credit, 0)
+
+	recorded, err := saleRepo.GetSaleByID(int(saleID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, voided, err := saleRepo.DeleteSale(*recorded, testDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(voided) != 2 {
+		t.Fatalf("se devolvió a %d tarjetas, se esperaban 2", len(voided))
+	}
+	assertCardBalance(t, db, gift, 100)
+	assertCardBalance(t, db, credit, 30)
+	redeemed, err := giftCardRepo.GetRedeemedTotal(testDate.AddDate(0, 0, -1), testDate.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(redeemed) > 1e-9 {
+		t.Errorf("cobrado con tarjetas %.2f, se esperaba 0 tras eliminar la venta", redeemed)
+	}
+	assertStock(t, db, productID, 10)
+}
+
+func TestSellCard(t *testing.T) {
+	db := testDatabase(t)
+	saleRepo, giftCardRepo := NewSaleRepo(db), NewGiftCardRepo(db)
+	card := testGiftCard(t, db, 100)
+	if card.Balance != 100 || card.Code == "" {
+		t.Fatalf("tarjeta %q con saldo %.2f, se esperaba un código y saldo 100", card.Code, card.Balance)
+	}
+
+	sale := models.Sale{Date: testDate, Quantity: 1, Price: 40, Total: 40, Status: models.StatusPaid, ProductName: "Recarga tarjeta de regalo"}
+	toppedUp, saleID, err := giftCardRepo.SellCard(card, sale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if toppedUp.ID != card.ID || toppedUp.Balance != 140 {
+		t.Errorf("recarga en la tarjeta #%d con saldo %.2f, se esperaba la #%d con 140", toppedUp.ID, toppedUp.Balance, card.ID)
+	}
+	assertCardBalance(t, db, card, 140)
+	recorded, err := saleRepo.GetSaleByID(int(saleID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded.ProductSKU != card.Code || !recorded.IsGiftCard() {
+		t.Errorf("venta con SKU %q, se esperaba la venta de la tarjeta %s", recorded.ProductSKU, card.Code)
+	}
+	movements, err := giftCardRepo.GetMovements(card.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movements) != 2 || movements[0].Type != models.GiftCardIssue || movements[1].Type != models.GiftCardTopUp || movements[1].SaleID != int(saleID) {
+		t.Errorf("movimientos %+v, se esperaba la emisión y la recarga de la venta #%d", movements, saleID)
+	}
+}
+
+func TestReturnSale(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		wantCredit bool
+	}{
+		{"venta pagada", models.StatusPaid, true},
+		{"venta pendiente", models.StatusPending, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDatabase(t)
+			saleRepo, loyaltyRepo := NewSaleRepo(db), NewLoyaltyRepo(db)
+			productID := testProduct(t, db, models.Product{Name: "Queso", Price: 50})
+			if err := NewProductRepo(db).ReceiveStock(productID, models.DefaultLocationID, 10, 5, testDate, "Compra", models.Lot{}); err != nil {
+				t.Fatal(err)
+			}
+			sale := models.Sale{Date: testDate, LocationID: models.DefaultLocationID, Client: "Cliente", ProductID: productID, Quantity: 4, Price: 50, Total: 200, Status: tt.status}
+			saleID, err := saleRepo.RecordSale(sale, false, models.PointsRedemption{}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := loyaltyRepo.EarnPoints(1, int(saleID), 20, testDate, 365); err != nil {
+				t.Fatal(err)
+			}
+
+			returned, err := saleRepo.GetSaleByID(int(saleID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			returned.Quantity, returned.Total, returned.Cost = 3, 150, 15
+			card, reversed, _, err := saleRepo.ReturnSale(*returned, 1, 5, 50, testDate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reversed != 5 {
+				t.Errorf("%d puntos anulados, se esperaba 5", reversed)
+			}
+			if (card != nil) != tt.wantCredit {
+				t.Fatalf("crédito emitido: %v, se esperaba: %v", card != nil, tt.wantCredit)
+			}
+			if card != nil {
+				assertCardBalance(t, db, *card, 50)
+			}
+			recorded, err := saleRepo.GetSaleByID(int(saleID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recorded.Quantity != 3 || recorded.Total != 150 || recorded.Cost != 15 {
+				t.Errorf("venta de %g unidades por %.2f con costo %.2f, se esperaban 3 por 150 con costo 15", recorded.Quantity, recorded.Total, recorded.Cost)
+			}
+			assertStock(t, db, productID, 7)
+			assertPointsBalance(t, loyaltyRepo, 1, 15)
+		})
+	}
+}
\ No newline at end of file
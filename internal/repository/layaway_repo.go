@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sales-system/internal/models"
+	"time"
+)
+
+// ErrLayawayNotActive indica que el apartado ya se completó o se canceló.
+var ErrLayawayNotActive = errors.New("el apartado no está activo")
+
+type LayawayRepo struct {
+	db *sql.DB
+}
+
+func NewLayawayRepo(db *sql.DB) *LayawayRepo {
+	return &LayawayRepo{db: db}
+}
+
+// DueInstallment es una cuota con saldo junto con los datos de su plan, para
+// el informe de cuotas.
+type DueInstallment struct {
+	models.Installment
+	Client      string
+	ProductName string
+	GraceDays   int
+}
+
+const layawayColumns = "l.id, l.sale_id, l.date, l.client, l.product_name, l.total, l.status, l.grace_days, l.cancelled_date, (SELECT COALESCE(SUM(amount), 0) FROM layaway_payments p WHERE p.layaway_id = l.id)"
+
+const installmentColumns = "id, layaway_id, number, due_date, amount, paid"
+
+func scanLayaway(row scanner) (*models.Layaway, error) {
+	var l models.Layaway
+	var dateStr, cancelledStr string
+	err := row.Scan(&l.ID, &l.SaleID, &dateStr, &l.Client, &l.ProductName, &l.Total, &l.Status, &l.GraceDays, &cancelledStr, &l.Paid)
+	if err != nil {
+		return nil, err
+	}
+	l.Date, _ = time.Parse(time.RFC3339, dateStr)
+	l.CancelledDate, _ = time.Parse(time.RFC3339, cancelledStr)
+	return &l, nil
+}
+
+func scanInstallments(rows *sql.Rows) ([]models.Installment, error) {
+	defer rows.Close()
+
+	var installments []models.Installment
+	for rows.Next() {
+		var i models.Installment
+		var dueStr string
+		if err := rows.Scan(&i.ID, &i.LayawayID, &i.Number, &dueStr, &i.Amount, &i.Paid); err != nil {
+			return nil, err
+		}
+		i.DueDate, _ = time.Parse(effectiveDateLayout, dueStr)
+		installments = append(installments, i)
+	}
+	return installments, rows.Err()
+}
+
+// CreateLayaway registra un apartado en una sola transacción: la venta con
+// estado Apartado, que descuenta el stock para reservarlo, el plan con sus
+// cuotas y la seña como primer pago. Si la ubicación no tiene stock
+// suficiente no se registra nada y se devuelve un *InsufficientStockError.
+// Devuelve el ID de la venta y el del plan.
+func (r *LayawayRepo) CreateLayaway(s models.Sale, includeExpired bool, l models.Layaway, deposit float64) (int64, int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	saleID, err := recordSale(tx, s, includeExpired, models.PointsRedemption{}, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	l.SaleID = int(saleID)
+	id, err := createLayaway(tx, l, deposit)
+	if err != nil {
+		return 0, 0, err
+	}
+	return saleID, id, tx.Commit()
+}
+
+func createLayaway(e execer, l models.Layaway, deposit float64) (int64, error) {
+	res, err := e.Exec("INSERT INTO layaways (sale_id, date, client, product_name, total, status, grace_days) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		l.SaleID, l.Date.Format(time.RFC3339), l.Client, l.ProductName, l.Total, models.LayawayActive, l.GraceDays)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, i := range l.Installments {
+		_, err := e.Exec("INSERT INTO layaway_installments (layaway_id, number, due_date, amount) VALUES (?, ?, ?, ?)", id, i.Number, i.DueDate.Format(effectiveDateLayout), i.Amount)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if deposit > 0 {
+		if _, err := e.Exec("INSERT INTO layaway_payments (layaway_id, date, amount) VALUES (?, ?, ?)", id, l.Date.Format(time.RFC3339), deposit); err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+func (r *LayawayRepo) GetLayawayByID(id int) (*models.Layaway, error) {
+	l, err := scanLayaway(r.db.QueryRow("SELECT "+layawayColumns+" FROM layaways l WHERE l.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query("SELECT "+installmentColumns+" FROM layaway_installments WHERE layaway_id = ? ORDER BY number", id)
+	if err != nil {
+		return nil, err
+	}
+	l.Installments, err = scanInstallments(rows)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// GetAllLayaways devuelve los planes sin sus cuotas, del más reciente al más antiguo.
+func (r *LayawayRepo) GetAllLayaways() ([]models.Layaway, error) {
+	rows, err := r.db.Query("SELECT " + layawayColumns + " FROM layaways l ORDER BY l.id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var layaways []models.Layaway
+	for rows.Next() {
+		l, err := scanLayaway(rows)
+		if err != nil {
+			return nil, err
+		}
+		layaways = append(layaways, *l)
+	}
+	return layaways, rows.Err()
+}
+
+// RegisterPayment registra un pago del plan y lo imputa a las cuotas en orden
+// de vencimiento. Si con el pago se cancela el saldo, el plan se completa y su
+// venta pasa a pagada. Devuelve si el plan quedó completado.
+func (r *LayawayRepo) RegisterPayment(id int, amount float64, date time.Time) (bool, error) {
+	l, err := r.GetLayawayByID(id)
+	if err != nil {
+		return false, err
+	}
+	if l.Status != models.LayawayActive {
+		return false, fmt.Errorf("el plan #%d está %s", id, l.Status)
+	}
+	if amount <= 0 {
+		return false, fmt.Errorf("el importe debe ser mayor a cero")
+	}
+	if amount > l.Balance()+0.005 {
+		return false, fmt.Errorf("el importe %.2f supera el saldo de %.2f", amount, l.Balance())
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO layaway_payments (layaway_id, date, amount) VALUES (?, ?, ?)", id, date.Format(time.RFC3339), amount); err != nil {
+		return false, err
+	}
+	remaining := amount
+	for _, i := range l.Installments {
+		if remaining <= 0 {
+			break
+		}
+		applied := math.Min(remaining, i.Pending())
+		if applied <= 0 {
+			continue
+		}
+		if _, err := tx.Exec("UPDATE layaway_installments SET paid = paid + ? WHERE id = ?", applied, i.ID); err != nil {
+			return false, err
+		}
+		remaining -= applied
+	}
+
+	completed := l.Balance()-amount < 0.005
+	if completed {
+		if _, err := tx.Exec("UPDATE layaways SET status = ?, completed_date = ? WHERE id = ?", models.LayawayCompleted, date.Format(time.RFC3339), id); err != nil {
+			return false, err
+		}
+		if _, err := tx.Exec("UPDATE sales SET status = ? WHERE id = ?", models.StatusPaid, l.SaleID); err != nil {
+			return false, err
+		}
+	}
+	return completed, tx.Commit()
+}
+
+// GetDueInstallments devuelve las cuotas con saldo de los planes activos que
+// vencen hasta la fecha indicada, incluidas las ya vencidas.
+func (r *LayawayRepo) GetDueInstallments(until time.Time) ([]DueInstallment, error) {
+	rows, err := r.db.Query(`SELECT i.id, i.layaway_id, i.number, i.due_date, i.amount, i.paid, l.client, l.product_name, l.grace_days
+		FROM layaway_installments i
+		JOIN layaways l ON l.id = i.layaway_id
+		WHERE l.status = ? AND i.amount - i.paid > 0.005 AND i.due_date <= ?
+		ORDER BY i.due_date, i.layaway_id`, models.LayawayActive, until.Format(effectiveDateLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []DueInstallment
+	for rows.Next() {
+		var d DueInstallment
+		var dueStr string
+		if err := rows.Scan(&d.ID, &d.LayawayID, &d.Number, &dueStr, &d.Amount, &d.Paid, &d.Client, &d.ProductName, &d.GraceDays); err != nil {
+			return nil, err
+		}
+		d.DueDate, _ = time.Parse(effectiveDateLayout, dueStr)
+		due = append(due, d)
+	}
+	return due, rows.Err()
+}
+
+// GetLayawaysToCancel devuelve los planes activos con alguna cuota impaga
+// cuyo vencimiento, más los días de gracia del plan, ya pasó.
+func (r *LayawayRepo) GetLayawaysToCancel(now time.Time) ([]models.Layaway, error) {
+	rows, err := r.db.Query(`SELECT `+layawayColumns+` FROM layaways l
+		WHERE l.status = ? AND EXISTS (
+			SELECT 1 FROM layaway_installments i
+			WHERE i.layaway_id = l.id AND i.amount - i.paid > 0.005
+			AND date(i.due_date, '+' || l.grace_days || ' days') < ?)
+		ORDER BY l.id`, models.LayawayActive, now.Format(effectiveDateLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var layaways []models.Layaway
+	for rows.Next() {
+		l, err := scanLayaway(rows)
+		if err != nil {
+			return nil, err
+		}
+		layaways = append(layaways, *l)
+	}
+	return layaways, rows.Err()
+}
+
+// CancelLayaway cancela un plan activo en una sola transacción: la mercadería
+// reservada vuelve al stock, se elimina la venta y lo que el cliente ya pagó
+// (la seña y las cuotas) se le entrega como crédito en tienda. Devuelve el
+// crédito emitido, o nil si no había pagos. Si el plan ya no está activo no
+// hace nada y devuelve ErrLayawayNotActive.
+func (r *LayawayRepo) CancelLayaway(id int, date time.Time) (*models.GiftCard, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	l, err := scanLayaway(tx.QueryRow("SELECT "+layawayColumns+" FROM layaways l WHERE l.id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	if l.Status != models.LayawayActive {
+		return nil, ErrLayawayNotActive
+	}
+	if _, err := tx.Exec("UPDATE layaways SET status = ?, cancelled_date = ? WHERE id = ?", models.LayawayCancelled, date.Format(time.RFC3339), id); err != nil {
+		return nil, err
+	}
+
+	sale, err := scanSale(tx.QueryRow("SELECT "+saleColumns+" FROM sales WHERE id = ?", l.SaleID))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if sale != nil {
+		if sale.Quantity > 0 {
+			unitCost := sale.Cost / sale.Quantity
+			if err := returnSale(tx, *sale, sale.Quantity, unitCost, date, fmt.Sprintf("Apartado #%d cancelado", id)); err != nil {
+				return nil, err
+			}
+		}
+		if err := deleteSale(tx, sale.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	var card *models.GiftCard
+	if l.Paid > 0.005 {
+		card, err = createCard(tx, models.GiftCard{Kind: models.GiftCardKindCredit, Client: l.Client, IssuedAt: date})
+		if err != nil {
+			return nil, err
+		}
+		movement := models.GiftCardMovement{CardID: card.ID, Date: date, Amount: l.Paid, Type: models.GiftCardReturn, Note: fmt.Sprintf("Pagos del apartado #%d cancelado", id)}
+		if err := addCardMovement(tx, movement); err != nil {
+			return nil, err
+		}
+		card.Balance = l.Paid
+	}
+	return card, tx.Commit()
+}
\ No newline at end of file
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"errors"
+	"sales-system/internal/models"
+	"testing"
+	"time"
+)
+
+func TestCreateLayaway(t *testing.T) {
+	tests := []struct {
+		name      string
+		quantity  float64
+		wantErr   bool
+		wantStock float64
+	}{
+		{"con stock", 4, false, 6},
+		{"sin stock suficiente", 12, true, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDatabase(t)
+			layawayRepo, saleRepo := NewLayawayRepo(db), NewSaleRepo(db)
+			productID := testProduct(t, db, models.Product{Name: "Queso", Price: 50})
+			if err := NewProductRepo(db).ReceiveStock(productID, models.DefaultLocationID, 10, 5, testDate, "Compra", models.Lot{}); err != nil {
+				t.Fatal(err)
+			}
+
+			total := 50 * tt.quantity
+			sale := models.Sale{Date: testDate, LocationID: models.DefaultLocationID, Client: "Cliente", ProductID: productID, Quantity: tt.quantity, Price: 50, Total: total, Status: models.StatusLayaway}
+			layaway := models.Layaway{Date: testDate, Client: "Cliente", ProductName: "Queso", Total: total, GraceDays: 7, Installments: models.InstallmentSchedule(total-20, 4, 7, testDate)}
+			saleID, id, err := layawayRepo.CreateLayaway(sale, false, layaway, 20)
+
+			var stockErr *InsufficientStockError
+			if tt.wantErr {
+				if !errors.As(err, &stockErr) {
+					t.Fatalf("error %v, se esperaba falta de stock", err)
+				}
+				if sales, _ := saleRepo.GetAllSales(); len(sales) != 0 {
+					t.Errorf("quedaron %d ventas registradas, se esperaba ninguna", len(sales))
+				}
+				if layaways, _ := layawayRepo.GetAllLayaways(); len(layaways) != 0 {
+					t.Errorf("quedaron %d apartados registrados, se esperaba ninguno", len(layaways))
+				}
+				assertStock(t, db, productID, tt.wantStock)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorded, err := saleRepo.GetSaleByID(int(saleID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recorded.Status != models.StatusLayaway || recorded.Cost != 5*tt.quantity {
+				t.Errorf("venta %s con costo %.2f, se esperaba %s con costo %.2f", recorded.Status, recorded.Cost, models.StatusLayaway, 5*tt.quantity)
+			}
+			got, err := layawayRepo.GetLayawayByID(int(id))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.SaleID != int(saleID) || got.Paid != 20 || len(got.Installments) != 4 {
+				t.Errorf("apartado de la venta #%d con %.2f pagado y %d cuotas, se esperaba venta #%d, 20 pagado y 4 cuotas", got.SaleID, got.Paid, len(got.Installments), saleID)
+			}
+			assertStock(t, db, productID, tt.wantStock)
+		})
+	}
+}
+
+func TestReportCountsLayawaysWhenCompleted(t *testing.T) {
+	db := testDatabase(t)
+	layawayRepo, saleRepo := NewLayawayRepo(db), NewSaleRepo(db)
+	productID := testProduct(t, db, models.Product{Name: "Queso", Price: 50})
+	if err := NewProductRepo(db).ReceiveStock(productID, models.DefaultLocationID, 10, 5, testDate, "Compra", models.Lot{}); err != nil {
+		t.Fatal(err)
+	}
+	sale := models.Sale{Date: testDate, LocationID: models.DefaultLocationID, Client: "Cliente", ProductID: productID, Quantity: 2, Price: 50, Total: 100, Status: models.StatusLayaway}
+	layaway := models.Layaway{Date: testDate, Client: "Cliente", ProductName: "Queso", Total: 100, Installments: models.InstallmentSchedule(80, 1, 7, testDate)}
+	saleID, id, err := layawayRepo.CreateLayaway(sale, false, layaway, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reported := func(start, end time.Time) []int {
+		t.Helper()
+		var ids []int
+		err := saleRepo.EachReportSale(start, end, false, func(row ReportRow) error {
+			ids = append(ids, row.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ids
+	}
+	day := func(d time.Time) (time.Time, time.Time) {
+		start := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, d.Location())
+		return start, start.AddDate(0, 0, 1).Add(-time.Second)
+	}
+
+	if ids := reported(day(testDate)); len(ids) != 0 {
+		t.Errorf("el apartado en curso figura en el reporte: %v", ids)
+	}
+	completedDate := testDate.AddDate(0, 0, 10)
+	if _, err := layawayRepo.RegisterPayment(int(id), 80, completedDate); err != nil {
+		t.Fatal(err)
+	}
+	if ids := reported(day(testDate)); len(ids) != 0 {
+		t.Errorf("el apartado completado figura en el día en que se apartó: %v", ids)
+	}
+	if ids := reported(day(completedDate)); len(ids) != 1 || ids[0] != int(saleID) {
+		t.Errorf("ventas %v en el día en que se completó el apartado, se esperaba la #%d", ids, saleID)
+	}
+}
\ No newline at end of file
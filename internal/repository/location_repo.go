@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"sales-system/internal/models"
+	"time"
+)
+
+type LocationRepo struct {
+	db *sql.DB
+}
+
+func NewLocationRepo(db *sql.DB) *LocationRepo {
+	return &LocationRepo{db: db}
+}
+
+func (r *LocationRepo) CreateLocation(name string) (int64, error) {
+	res, err := r.db.Exec("INSERT INTO locations (name) VALUES (?)", name)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *LocationRepo) GetAllLocations() ([]models.Location, error) {
+	rows, err := r.db.Query("SELECT id, name FROM locations ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locations []models.Location
+	for rows.Next() {
+		var l models.Location
+		if err := rows.Scan(&l.ID, &l.Name); err != nil {
+			return nil, err
+		}
+		locations = append(locations, l)
+	}
+	return locations, nil
+}
+
+// GetLocationNames devuelve el nombre de cada ubicación indexado por ID.
+func (r *LocationRepo) GetLocationNames() (map[int]string, error) {
+	locations, err := r.GetAllLocations()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(locations))
+	for _, l := range locations {
+		names[l.ID] = l.Name
+	}
+	return names, nil
+}
+
+// GetStockByLocation devuelve el stock de cada producto en cada ubicación,
+// indexado por producto y luego por ubicación.
+func (r *LocationRepo) GetStockByLocation() (map[int]map[int]float64, error) {
+	rows, err := r.db.Query("SELECT product_id, location_id, quantity FROM location_stock")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stock := make(map[int]map[int]float64)
+	for rows.Next() {
+		var productID, locationID int
+		var quantity float64
+		if err := rows.Scan(&productID, &locationID, &quantity); err != nil {
+			return nil, err
+		}
+		if stock[productID] == nil {
+			stock[productID] = make(map[int]float64)
+		}
+		stock[productID][locationID] = quantity
+	}
+	return stock, nil
+}
+
+// GetLocationQuantity devuelve el stock de un producto en una ubicación.
+func (r *LocationRepo) GetLocationQuantity(productID, locationID int) (float64, error) {
+	return locationQuantity(r.db, productID, locationID)
+}
+
+// GetInTransitByProduct devuelve la cantidad de cada producto que viaja en
+// transferencias todavía no recibidas.
+func (r *LocationRepo) GetInTransitByProduct() (map[int]float64, error) {
+	rows, err := r.db.Query("SELECT i.product_id, SUM(i.quantity) FROM transfer_items i JOIN transfers t ON t.id = i.transfer_id WHERE t.status = ? GROUP BY i.product_id", models.TransferInTransit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inTransit := make(map[int]float64)
+	for rows.Next() {
+		var productID int
+		var quantity float64
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		inTransit[productID] = quantity
+	}
+	return inTransit, nil
+}
+
+// CreateTransfer registra una transferencia y descuenta la mercadería del
+// origen; queda en tránsito hasta que se recibe en el destino.
+func (r *LocationRepo) CreateTransfer(t models.Transfer) (int64, error) {
+	if t.FromID == t.ToID {
+		return 0, fmt.Errorf("el origen y el destino deben ser distintos")
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO transfers (date, from_location_id, to_location_id, status) VALUES (?, ?, ?, ?)", t.Date.Format(time.RFC3339), t.FromID, t.ToID, models.TransferInTransit)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	reference := fmt.Sprintf("Transferencia #%d", id)
+	for _, item := range t.Items {
+		available, err := locationQuantity(tx, item.ProductID, t.FromID)
+		if err != nil {
+			return 0, err
+		}
+		if item.Quantity > available+quantityEpsilon {
+			return 0, fmt.Errorf("stock insuficiente del producto %d en el origen (disponible: %g)", item.ProductID, available)
+		}
+		// Los lotes viajan con la mercadería: una línea por lote y el resto sin lote.
+		allocations, err := consumeLots(tx, item.ProductID, t.FromID, item.Quantity, true, t.Date)
+		if err != nil {
+			return 0, err
+		}
+		withoutLot := item.Quantity
+		for _, a := range allocations {
+			if _, err := tx.Exec("INSERT INTO transfer_items (transfer_id, product_id, quantity, lot_number, lot_expiry) VALUES (?, ?, ?, ?, ?)", id, item.ProductID, a.Quantity, a.Number, a.Expiry.Format(expiryLayout)); err != nil {
+				return 0, err
+			}
+			withoutLot -= a.Quantity
+		}
+		if withoutLot > quantityEpsilon {
+			if _, err := tx.Exec("INSERT INTO transfer_items (transfer_id, product_id, quantity) VALUES (?, ?, ?)", id, item.ProductID, withoutLot); err != nil {
+				return 0, err
+			}
+		}
+		if err := addLocationStock(tx, item.ProductID, t.FromID, -item.Quantity); err != nil {
+			return 0, err
+		}
+		if err := insertMovement(tx, models.StockMovement{Date: t.Date, ProductID: item.ProductID, LocationID: t.FromID, Type: models.MovementTransfer, Quantity: -item.Quantity, Reference: reference}); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// ReceiveTransfer ingresa en el destino la mercadería de una transferencia en tránsito.
+func (r *LocationRepo) ReceiveTransfer(id int, date time.Time) error {
+	t, err := r.GetTransferByID(id)
+	if err != nil {
+		return err
+	}
+	if t.Status != models.TransferInTransit {
+		return fmt.Errorf("la transferencia #%d ya fue recibida", id)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// La transferencia se marca recibida antes de ingresar la mercadería, para
+	// que el stock total no la cuente en tránsito y en el destino a la vez.
+	if _, err := tx.Exec("UPDATE transfers SET status = ?, received_date = ? WHERE id = ?", models.TransferReceived, date.Format(time.RFC3339), id); err != nil {
+		return err
+	}
+	reference := fmt.Sprintf("Transferencia #%d", id)
+	for _, item := range t.Items {
+		if err := addLocationStock(tx, item.ProductID, t.ToID, item.Quantity); err != nil {
+			return err
+		}
+		if item.LotNumber != "" {
+			if err := addLot(tx, item.ProductID, t.ToID, item.LotNumber, item.LotExpiry, item.Quantity); err != nil {
+				return err
+			}
+		}
+		if err := insertMovement(tx, models.StockMovement{Date: date, ProductID: item.ProductID, LocationID: t.ToID, Type: models.MovementTransfer, Quantity: item.Quantity, Reference: reference}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *LocationRepo) GetTransferByID(id int) (*models.Transfer, error) {
+	var t models.Transfer
+	var dateStr, receivedStr string
+	err := r.db.QueryRow("SELECT id, date, from_location_id, to_location_id, status, received_date FROM transfers WHERE id = ?", id).Scan(&t.ID, &dateStr, &t.FromID, &t.ToID, &t.Status, &receivedStr)
+	if err != nil {
+		return nil, err
+	}
+	t.Date, _ = time.Parse(time.RFC3339, dateStr)
+	t.ReceivedDate, _ = time.Parse(time.RFC3339, receivedStr)
+
+	rows, err := r.db.Query("SELECT id, transfer_id, product_id, quantity, lot_number, lot_expiry FROM transfer_items WHERE transfer_id = ? ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.TransferItem
+		var expiryStr string
+		if err := rows.Scan(&item.ID, &item.TransferID, &item.ProductID, &item.Quantity, &item.LotNumber, &expiryStr); err != nil {
+			return nil, err
+		}
+		item.LotExpiry, _ = time.Parse(expiryLayout, expiryStr)
+		t.Items = append(t.Items, item)
+	}
+	return &t, nil
+}
+
+// GetAllTransfers devuelve las transferencias sin sus líneas.
+func (r *LocationRepo) GetAllTransfers() ([]models.Transfer, error) {
+	rows, err := r.db.Query("SELECT id, date, from_location_id, to_location_id, status, received_date FROM transfers ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []models.Transfer
+	for rows.Next() {
+		var t models.Transfer
+		var dateStr, receivedStr string
+		if err := rows.Scan(&t.ID, &dateStr, &t.FromID, &t.ToID, &t.Status, &receivedStr); err != nil {
+			return nil, err
+		}
+		t.Date, _ = time.Parse(time.RFC3339, dateStr)
+		t.ReceivedDate, _ = time.Parse(time.RFC3339, receivedStr)
+		transfers = append(transfers, t)
+	}
+	return transfers, nil
+}
+
+// queryRower es lo que tienen en común *sql.DB y *sql.Tx para consultar una fila.
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func locationQuantity(q queryRower, productID, locationID int) (float64, error) {
+	var quantity float64
+	err := q.QueryRow("SELECT quantity FROM location_stock WHERE product_id = ? AND location_id = ?", productID, locationID).Scan(&quantity)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return quantity, err
+}
+
+// addLocationStock suma (o resta, si delta es negativo) stock de un producto
+// en una ubicación y recalcula el stock total del producto.
+func addLocationStock(tx *sql.Tx, productID, locationID int, delta float64) error {
+	_, err := tx.Exec(`INSERT INTO location_stock (product_id, location_id, quantity) VALUES (?, ?, ?)
+		ON CONFLICT(product_id, location_id) DO UPDATE SET quantity = quantity + excluded.quantity`, productID, locationID, delta)
+	if err != nil {
+		return err
+	}
+	return syncProductQuantity(tx, productID)
+}
+
+// productQuantityQuery calcula el stock total de un producto: lo que hay en
+// las ubicaciones más lo que viaja en transferencias no recibidas.
+const productQuantityQuery = `COALESCE((SELECT SUM(ls.quantity) FROM location_stock ls WHERE ls.product_id = products.id), 0)
+	+ COALESCE((SELECT SUM(i.quantity) FROM transfer_items i JOIN transfers t ON t.id = i.transfer_id WHERE t.status = ? AND i.product_id = products.id), 0)`
+
+// syncProductQuantity deriva products.quantity del stock por ubicación, que
+// es el único que se modifica directamente.
+func syncProductQuantity(tx *sql.Tx, productID int) error {
+	_, err := tx.Exec("UPDATE products SET quantity = "+productQuantityQuery+" WHERE id = ?", models.TransferInTransit, productID)
+	return err
+}
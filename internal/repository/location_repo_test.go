@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"database/sql"
+	"math"
+	"sales-system/internal/models"
+	"testing"
+)
+
+func TestTransferMovesStock(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to int
+		quantity float64
+		wantErr  bool
+	}{
+		{"parte del stock", models.DefaultLocationID, 0, 4, false},
+		{"todo el stock", models.DefaultLocationID, 0, 10, false},
+		{"cantidad decimal", models.DefaultLocationID, 0, 2.5, false},
+		{"stock insuficiente en el origen", models.DefaultLocationID, 0, 11, true},
+		{"mismo origen y destino", models.DefaultLocationID, models.DefaultLocationID, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDatabase(t)
+			productRepo, locationRepo := NewProductRepo(db), NewLocationRepo(db)
+			id := testProduct(t, db, models.Product{Name: "Queso", Price: 50})
+			if err := productRepo.ReceiveStock(id, models.DefaultLocationID, 10, 5, testDate, "Compra", models.Lot{}); err != nil {
+				t.Fatal(err)
+			}
+			warehouse, err := locationRepo.CreateLocation("Depósito")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.to == 0 {
+				tt.to = int(warehouse)
+			}
+
+			transfer := models.Transfer{Date: testDate, FromID: tt.from, ToID: tt.to, Items: []models.TransferItem{{ProductID: id, Quantity: tt.quantity}}}
+			transferID, err := locationRepo.CreateTransfer(transfer)
+			received := tt.quantity
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("se esperaba un error")
+				}
+				received = 0
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				// En tránsito la mercadería no está en ninguna de las dos
+				// ubicaciones, pero sigue contando en el stock total.
+				assertLocationStock(t, db, id, models.DefaultLocationID, 10-tt.quantity)
+				assertLocationStock(t, db, id, int(warehouse), 0)
+				inTransit, err := locationRepo.GetInTransitByProduct()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if math.Abs(inTransit[id]-tt.quantity) > quantityEpsilon {
+					t.Errorf("en tránsito %g, se esperaba %g", inTransit[id], tt.quantity)
+				}
+				if err := locationRepo.ReceiveTransfer(int(transferID), testDate); err != nil {
+					t.Fatal(err)
+				}
+				if err := locationRepo.ReceiveTransfer(int(transferID), testDate); err == nil {
+					t.Error("se pudo recibir dos veces la misma transferencia")
+				}
+			}
+
+			assertLocationStock(t, db, id, models.DefaultLocationID, 10-received)
+			assertLocationStock(t, db, id, int(warehouse), received)
+			p, err := productRepo.GetProductByID(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(p.Quantity-10) > quantityEpsilon {
+				t.Errorf("stock total %g, se esperaba 10", p.Quantity)
+			}
+		})
+	}
+}
+
+// assertLocationStock verifica el stock del producto en una ubicación.
+func assertLocationStock(t *testing.T, db *sql.DB, productID, locationID int, want float64) {
+	t.Helper()
+	quantity, err := locationQuantity(db, productID, locationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(quantity-want) > quantityEpsilon {
+		t.Errorf("stock %g en la ubicación %d, se esperaba %g", quantity, locationID, want)
+	}
+}
\ No newline at end of file
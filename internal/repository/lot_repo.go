@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"database/sql"
+	"sales-system/internal/models"
+	"time"
+)
+
+type LotRepo struct {
+	db *sql.DB
+}
+
+func NewLotRepo(db *sql.DB) *LotRepo {
+	return &LotRepo{db: db}
+}
+
+// expiryLayout es el formato en que se guardan los vencimientos, que permite
+// ordenarlos y compararlos como texto.
+const expiryLayout = "2006-01-02"
+
+const lotColumns = "id, product_id, location_id, number, expiry, quantity"
+
+func scanLots(rows *sql.Rows) ([]models.Lot, error) {
+	defer rows.Close()
+
+	var lots []models.Lot
+	for rows.Next() {
+		var l models.Lot
+		var expiryStr string
+		if err := rows.Scan(&l.ID, &l.ProductID, &l.LocationID, &l.Number, &expiryStr, &l.Quantity); err != nil {
+			return nil, err
+		}
+		l.Expiry, _ = time.Parse(expiryLayout, expiryStr)
+		lots = append(lots, l)
+	}
+	return lots, nil
+}
+
+// GetLots devuelve los lotes con stock de un producto en una ubicación, en
+// orden de vencimiento (el primero es el próximo a salir).
+func (r *LotRepo) GetLots(productID, locationID int) ([]models.Lot, error) {
+	rows, err := r.db.Query("SELECT "+lotColumns+" FROM lots WHERE product_id = ? AND location_id = ? AND quantity > 1e-9 ORDER BY expiry, id", productID, locationID)
+	if err != nil {
+		return nil, err
+	}
+	return scanLots(rows)
+}
+
+// GetExpiringLots devuelve los lotes con stock que vencen hasta la fecha
+// indicada, incluidos los ya vencidos, ordenados por vencimiento.
+func (r *LotRepo) GetExpiringLots(until time.Time) ([]models.Lot, error) {
+	rows, err := r.db.Query("SELECT "+lotColumns+" FROM lots WHERE quantity > 1e-9 AND expiry <= ? ORDER BY expiry, product_id", until.Format(expiryLayout))
+	if err != nil {
+		return nil, err
+	}
+	return scanLots(rows)
+}
+
+// GetSaleLots devuelve los lotes de los que salió la mercadería de una venta.
+func (r *LotRepo) GetSaleLots(saleID int) ([]models.SaleLot, error) {
+	rows, err := r.db.Query("SELECT sl.sale_id, sl.lot_id, l.number, l.expiry, sl.quantity FROM sale_lots sl JOIN lots l ON l.id = sl.lot_id WHERE sl.sale_id = ? AND sl.quantity > 1e-9 ORDER BY sl.id", saleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var saleLots []models.SaleLot
+	for rows.Next() {
+		var sl models.SaleLot
+		var expiryStr string
+		if err := rows.Scan(&sl.SaleID, &sl.LotID, &sl.Number, &expiryStr, &sl.Quantity); err != nil {
+			return nil, err
+		}
+		sl.Expiry, _ = time.Parse(expiryLayout, expiryStr)
+		saleLots = append(saleLots, sl)
+	}
+	return saleLots, nil
+}
+
+// GetStockWithoutLot devuelve el stock de un producto en una ubicación que no
+// está asignado a ningún lote: lo que hay en la ubicación menos lo que suman
+// sus lotes.
+func (r *LotRepo) GetStockWithoutLot(productID, locationID int) (float64, error) {
+	quantity, err := locationQuantity(r.db, productID, locationID)
+	if err != nil {
+		return 0, err
+	}
+	inLots, err := lotsQuantity(r.db, productID, locationID)
+	if err != nil {
+		return 0, err
+	}
+	return max(quantity-inLots, 0), nil
+}
+
+// lotsQuantity suma el stock de los lotes de un producto en una ubicación.
+func lotsQuantity(q queryRower, productID, locationID int) (float64, error) {
+	var quantity float64
+	err := q.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM lots WHERE product_id = ? AND location_id = ? AND quantity > 1e-9", productID, locationID).Scan(&quantity)
+	return quantity, err
+}
+
+// trimLots descuenta de los lotes lo que supera al stock de la ubicación,
+// para que los lotes nunca sumen más que el stock. Se usa cuando sale
+// mercadería sin indicar de qué lote (por ejemplo, un faltante de una toma de
+// inventario): primero se considera faltante el stock sin lote.
+func trimLots(tx *sql.Tx, productID, locationID int, now time.Time) error {
+	quantity, err := locationQuantity(tx, productID, locationID)
+	if err != nil {
+		return err
+	}
+	inLots, err := lotsQuantity(tx, productID, locationID)
+	if err != nil {
+		return err
+	}
+	if excess := inLots - max(quantity, 0); excess > quantityEpsilon {
+		_, err = consumeLots(tx, productID, locationID, excess, true, now)
+	}
+	return err
+}
+
+// addLot suma stock a un lote, creándolo si todavía no existe en la ubicación.
+func addLot(tx *sql.Tx, productID, locationID int, number string, expiry time.Time, quantity float64) error {
+	_, err := tx.Exec(`INSERT INTO lots (product_id, location_id, number, expiry, quantity) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(product_id, location_id, number) DO UPDATE SET quantity = quantity + excluded.quantity`, productID, locationID, number, expiry.Format(expiryLayout), quantity)
+	return err
+}
+
+// lotAllocation es la cantidad que se tomó de un lote.
+type lotAllocation struct {
+	LotID    int
+	Number   string
+	Expiry   time.Time
+	Quantity float64
+}
+
+// consumeLots descuenta la cantidad de los lotes de la ubicación empezando
+// por el que vence primero (FEFO). Lo que no alcanza a cubrirse con lotes se
+// considera stock sin lote.
+func consumeLots(tx *sql.Tx, productID, locationID int, quantity float64, includeExpired bool, now time.Time) ([]lotAllocation, error) {
+	query := "SELECT " + lotColumns + " FROM lots WHERE product_id = ? AND location_id = ? AND quantity > 1e-9"
+	args := []any{productID, locationID}
+	if !includeExpired {
+		query += " AND expiry >= ?"
+		args = append(args, now.Format(expiryLayout))
+	}
+	rows, err := tx.Query(query+" ORDER BY expiry, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	lots, err := scanLots(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var allocations []lotAllocation
+	pending := quantity
+	for _, l := range lots {
+		if pending <= quantityEpsilon {
+			break
+		}
+		taken := min(l.Quantity, pending)
+		if _, err := tx.Exec("UPDATE lots SET quantity = quantity - ? WHERE id = ?", taken, l.ID); err != nil {
+			return nil, err
+		}
+		allocations = append(allocations, lotAllocation{LotID: l.ID, Number: l.Number, Expiry: l.Expiry, Quantity: taken})
+		pending -= taken
+	}
+	return allocations, nil
+}
+
+// returnSaleLots devuelve a sus lotes la mercadería de un producto de una
+// venta, empezando por el último lote asignado.
+func returnSaleLots(tx *sql.Tx, saleID, productID int, quantity float64) error {
+	type saleLot struct {
+		id, lotID int
+		quantity  float64
+	}
+	rows, err := tx.Query("SELECT sl.id, sl.lot_id, sl.quantity FROM sale_lots sl JOIN lots l ON l.id = sl.lot_id WHERE sl.sale_id = ? AND l.product_id = ? AND sl.quantity > 1e-9 ORDER BY sl.id DESC", saleID, productID)
+	if err != nil {
+		return err
+	}
+	var saleLots []saleLot
+	for rows.Next() {
+		var sl saleLot
+		if err := rows.Scan(&sl.id, &sl.lotID, &sl.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		saleLots = append(saleLots, sl)
+	}
+	rows.Close()
+
+	pending := quantity
+	for _, sl := range saleLots {
+		if pending <= quantityEpsilon {
+			break
+		}
+		returned := min(sl.quantity, pending)
+		if _, err := tx.Exec("UPDATE lots SET quantity = quantity + ? WHERE id = ?", returned, sl.lotID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE sale_lots SET quantity = quantity - ? WHERE id = ?", returned, sl.id); err != nil {
+			return err
+		}
+		pending -= returned
+	}
+	return nil
+}
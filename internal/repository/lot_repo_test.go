@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"database/sql"
+	"math"
+	"reflect"
+	"sales-system/internal/models"
+	"testing"
+)
+
+// lotQuantities devuelve el stock de cada lote del producto en la ubicación
+// por número, incluidos los lotes que quedaron sin stock.
+func lotQuantities(t *testing.T, db *sql.DB, productID, locationID int) map[string]float64 {
+	t.Helper()
+	rows, err := db.Query("SELECT number, quantity FROM lots WHERE product_id = ? AND location_id = ?", productID, locationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	lots := make(map[string]float64)
+	for rows.Next() {
+		var number string
+		var quantity float64
+		if err := rows.Scan(&number, &quantity); err != nil {
+			t.Fatal(err)
+		}
+		lots[number] = math.Round(quantity*1000) / 1000
+	}
+	return lots
+}
+
+// testLotProduct registra un producto con control de lotes y le carga 5
+// unidades en cada lote: L1 vence en 30 días, L2 en 10 y L3 venció ayer.
+// Además carga withoutLot unidades sin lote.
+func testLotProduct(t *testing.T, db *sql.DB, withoutLot float64) int {
+	t.Helper()
+	productRepo := NewProductRepo(db)
+	id := testProduct(t, db, models.Product{Name: "Yogur", Price: 10, TrackLots: true})
+	lots := []models.Lot{
+		{Number: "L1", Expiry: testDate.AddDate(0, 0, 30)},
+		{Number: "L2", Expiry: testDate.AddDate(0, 0, 10)},
+		{Number: "L3", Expiry: testDate.AddDate(0, 0, -1)},
+	}
+	for _, lot := range lots {
+		if err := productRepo.ReceiveStock(id, models.DefaultLocationID, 5, 4, testDate, "Compra", lot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if withoutLot > 0 {
+		if err := productRepo.ReceiveStock(id, models.DefaultLocationID, withoutLot, 4, testDate, "Compra", models.Lot{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return id
+}
+
+func TestSaleConsumesAndReturnsLots(t *testing.T) {
+	tests := []struct {
+		name           string
+		sold           float64
+		includeExpired bool
+		saleLots       map[string]float64
+		afterSale      map[string]float64
+		returned       float64
+		afterReturn    map[string]float64
+	}{
+		{
+			name:        "el que vence primero sale primero",
+			sold:        7,
+			saleLots:    map[string]float64{"L2": 5, "L1": 2},
+			afterSale:   map[string]float64{"L1": 3, "L2": 0, "L3": 5},
+			returned:    3,
+			afterReturn: map[string]float64{"L1": 5, "L2": 1, "L3": 5},
+		},
+		{
+			name:           "incluyendo vencidos",
+			sold:           7,
+			includeExpired: true,
+			saleLots:       map[string]float64{"L3": 5, "L2": 2},
+			afterSale:      map[string]float64{"L1": 5, "L2": 3, "L3": 0},
+			returned:       7,
+			afterReturn:    map[string]float64{"L1": 5, "L2": 5, "L3": 5},
+		},
+		{
+			name:        "el resto sale sin lote",
+			sold:        12,
+			saleLots:    map[string]float64{"L2": 5, "L1": 5},
+			afterSale:   map[string]float64{"L1": 0, "L2": 0, "L3": 5},
+			returned:    12,
+			afterReturn: map[string]float64{"L1": 5, "L2": 5, "L3": 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDatabase(t)
+			productRepo, saleRepo, lotRepo := NewProductRepo(db), NewSaleRepo(db), NewLotRepo(db)
+			id := testLotProduct(t, db, 5)
+
+			sale := models.Sale{Date: testDate, LocationID: models.DefaultLocationID, ProductID: id, Quantity: tt.sold, Price: 10, Total: 10 * tt.sold, Status: models.StatusPaid}
+			saleID, err := saleRepo.RecordSale(sale, tt.includeExpired, models.PointsRedemption{}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sale.ID = int(saleID)
+			saleLots, err := lotRepo.GetSaleLots(sale.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]float64)
+			for _, sl := range saleLots {
+				got[sl.Number] += sl.Quantity
+			}
+			if !reflect.DeepEqual(got, tt.saleLots) {
+				t.Errorf("lotes de la venta %v, se esperaba %v", got, tt.saleLots)
+			}
+			if got := lotQuantities(t, db, id, models.DefaultLocationID); !reflect.DeepEqual(got, tt.afterSale) {
+				t.Errorf("lotes tras la venta %v, se esperaba %v", got, tt.afterSale)
+			}
+			assertStock(t, db, id, 20-tt.sold)
+
+			if err := productRepo.ReturnStock(sale, tt.returned, 4, testDate, "Devolución"); err != nil {
+				t.Fatal(err)
+			}
+			if got := lotQuantities(t, db, id, models.DefaultLocationID); !reflect.DeepEqual(got, tt.afterReturn) {
+				t.Errorf("lotes tras la devolución %v, se esperaba %v", got, tt.afterReturn)
+			}
+			assertStock(t, db, id, 20-tt.sold+tt.returned)
+		})
+	}
+}
+
+func TestApplyStocktakeKeepsLotsInSync(t *testing.T) {
+	tests := []struct {
+		name           string
+		counted        float64
+		lot            models.Lot
+		wantLots       map[string]float64
+		wantWithoutLot float64
+	}{
+		{
+			name:           "faltante cubierto por el stock sin lote",
+			counted:        17,
+			wantLots:       map[string]float64{"L1": 5, "L2": 5, "L3": 5},
+			wantWithoutLot: 2,
+		},
+		{
+			name:           "faltante que toca los lotes",
+			counted:        12,
+			wantLots:       map[string]float64{"L1": 5, "L2": 5, "L3": 2},
+			wantWithoutLot: 0,
+		},
+		{
+			name:           "sobrante en un lote nuevo",
+			counted:        24,
+			lot:            models.Lot{Number: "L4", Expiry: testDate.AddDate(0, 2, 0)},
+			wantLots:       map[string]float64{"L1": 5, "L2": 5, "L3": 5, "L4": 4},
+			wantWithoutLot: 5,
+		},
+		{
+			name:           "sobrante sin lote",
+			counted:        24,
+			wantLots:       map[string]float64{"L1": 5, "L2": 5, "L3": 5},
+			wantWithoutLot: 9,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDatabase(t)
+			productRepo, stocktakeRepo, lotRepo := NewProductRepo(db), NewStocktakeRepo(db), NewLotRepo(db)
+			id := testLotProduct(t, db, 5)
+
+			stocktakeID, err := stocktakeRepo.CreateStocktake(testDate, models.DefaultLocationID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stocktake, err := stocktakeRepo.GetStocktakeByID(int(stocktakeID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := productRepo.GetProductByID(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := stocktakeRepo.SaveCount(*stocktake, *p, tt.counted); err != nil {
+				t.Fatal(err)
+			}
+			lots := map[int]models.Lot{id: tt.lot}
+			if err := stocktakeRepo.ApplyStocktake(int(stocktakeID), "Supervisor", lots, testDate); err != nil {
+				t.Fatal(err)
+			}
+
+			assertStock(t, db, id, tt.counted)
+			if got := lotQuantities(t, db, id, models.DefaultLocationID); !reflect.DeepEqual(got, tt.wantLots) {
+				t.Errorf("lotes %v, se esperaba %v", got, tt.wantLots)
+			}
+			withoutLot, err := lotRepo.GetStockWithoutLot(id, models.DefaultLocationID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(withoutLot-tt.wantWithoutLot) > 1e-9 {
+				t.Errorf("stock sin lote %g, se esperaba %g", withoutLot, tt.wantWithoutLot)
+			}
+		})
+	}
+}
+
+func TestTransferCarriesLots(t *testing.T) {
+	tests := []struct {
+		name        string
+		quantity    float64
+		originLots  map[string]float64
+		destLots    map[string]float64
+		destWithout float64
+	}{
+		{"el lote que vence primero", 5, map[string]float64{"L1": 5, "L2": 5, "L3": 0}, map[string]float64{"L3": 5}, 0},
+		{"parte de un lote", 7, map[string]float64{"L1": 5, "L2": 3, "L3": 0}, map[string]float64{"L2": 2, "L3": 5}, 0},
+		{"lotes y stock sin lote", 17, map[string]float64{"L1": 0, "L2": 0, "L3": 0}, map[string]float64{"L1": 5, "L2": 5, "L3": 5}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDatabase(t)
+			locationRepo, lotRepo := NewLocationRepo(db), NewLotRepo(db)
+			id := testLotProduct(t, db, 5)
+			warehouse, err := locationRepo.CreateLocation("Depósito")
+			if err != nil {
+				t.Fatal(err)
+			}
+			dest := int(warehouse)
+
+			transfer := models.Transfer{Date: testDate, FromID: models.DefaultLocationID, ToID: dest, Items: []models.TransferItem{{ProductID: id, Quantity: tt.quantity}}}
+			transferID, err := locationRepo.CreateTransfer(transfer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := locationRepo.ReceiveTransfer(int(transferID), testDate); err != nil {
+				t.Fatal(err)
+			}
+
+			origin, destination := lotQuantities(t, db, id, models.DefaultLocationID), lotQuantities(t, db, id, dest)
+			if !reflect.DeepEqual(origin, tt.originLots) {
+				t.Errorf("lotes en el origen %v, se esperaba %v", origin, tt.originLots)
+			}
+			if !reflect.DeepEqual(destination, tt.destLots) {
+				t.Errorf("lotes en el destino %v, se esperaba %v", destination, tt.destLots)
+			}
+			withoutLot, err := lotRepo.GetStockWithoutLot(id, dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(withoutLot-tt.destWithout) > 1e-9 {
+				t.Errorf("stock sin lote en el destino %g, se esperaba %g", withoutLot, tt.destWithout)
+			}
+		})
+	}
+}
\ No newline at end of file
@@ -0,0 +1,339 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sales-system/internal/models"
+	"time"
+)
+
+type LoyaltyRepo struct {
+	db *sql.DB
+}
+
+func NewLoyaltyRepo(db *sql.DB) *LoyaltyRepo {
+	return &LoyaltyRepo{db: db}
+}
+
+// defaultPointsExpiryDays es el vencimiento de los puntos mientras el
+// programa no se configuró.
+const defaultPointsExpiryDays = 365
+
+// GetSettings devuelve las reglas del programa de puntos. Si nunca se
+// configuró, el programa está desactivado.
+func (r *LoyaltyRepo) GetSettings() (models.LoyaltySettings, error) {
+	settings := models.LoyaltySettings{PointValue: 1, ExpiryDays: defaultPointsExpiryDays, BonusDays: make(map[time.Weekday]float64)}
+	err := r.db.QueryRow("SELECT amount_per_point, point_value, expiry_days FROM loyalty_settings WHERE id = 1").Scan(&settings.AmountPerPoint, &settings.PointValue, &settings.ExpiryDays)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return settings, err
+	}
+
+	rows, err := r.db.Query("SELECT category_id FROM loyalty_excluded_categories ORDER BY category_id")
+	if err != nil {
+		return settings, err
+	}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return settings, err
+		}
+		settings.ExcludedCategories = append(settings.ExcludedCategories, id)
+	}
+	rows.Close()
+
+	rows, err = r.db.Query("SELECT weekday, multiplier FROM loyalty_bonus_days")
+	if err != nil {
+		return settings, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var weekday int
+		var multiplier float64
+		if err := rows.Scan(&weekday, &multiplier); err != nil {
+			return settings, err
+		}
+		settings.BonusDays[time.Weekday(weekday)] = multiplier
+	}
+	return settings, rows.Err()
+}
+
+// SaveSettings reemplaza las reglas del programa de puntos.
+func (r *LoyaltyRepo) SaveSettings(s models.LoyaltySettings) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT OR REPLACE INTO loyalty_settings (id, amount_per_point, point_value, expiry_days) VALUES (1, ?, ?, ?)", s.AmountPerPoint, s.PointValue, s.ExpiryDays)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM loyalty_excluded_categories"); err != nil {
+		return err
+	}
+	for _, id := range s.ExcludedCategories {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO loyalty_excluded_categories (category_id) VALUES (?)", id); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM loyalty_bonus_days"); err != nil {
+		return err
+	}
+	for weekday, multiplier := range s.BonusDays {
+		if _, err := tx.Exec("INSERT INTO loyalty_bonus_days (weekday, multiplier) VALUES (?, ?)", int(weekday), multiplier); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// EarnsPoints indica si un producto suma puntos: no suma si su categoría, o
+// alguna categoría superior, está excluida del programa.
+func (r *LoyaltyRepo) EarnsPoints(product models.Product) (bool, error) {
+	if product.CategoryID == 0 {
+		return true, nil
+	}
+	var excluded bool
+	err := r.db.QueryRow(`WITH RECURSIVE up(id) AS (
+			SELECT ?
+			UNION SELECT c.parent_id FROM categories c JOIN up ON c.id = up.id WHERE c.parent_id <> 0
+		)
+		SELECT EXISTS (SELECT 1 FROM loyalty_excluded_categories WHERE category_id IN (SELECT id FROM up))`, product.CategoryID).Scan(&excluded)
+	return !excluded, err
+}
+
+// GetBalance devuelve los puntos disponibles del cliente.
+func (r *LoyaltyRepo) GetBalance(customerID int) (int, error) {
+	var balance int
+	err := r.db.QueryRow("SELECT COALESCE(SUM(points), 0) FROM loyalty_points WHERE customer_id = ?", customerID).Scan(&balance)
+	return balance, err
+}
+
+// GetLedger devuelve los movimientos de puntos del cliente en orden.
+func (r *LoyaltyRepo) GetLedger(customerID int) ([]models.LoyaltyEntry, error) {
+	rows, err := r.db.Query("SELECT id, customer_id, date, points, type, sale_id, expires_at, remaining FROM loyalty_points WHERE customer_id = ? ORDER BY date, id", customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.LoyaltyEntry
+	for rows.Next() {
+		var e models.LoyaltyEntry
+		var dateStr, expiresStr string
+		if err := rows.Scan(&e.ID, &e.CustomerID, &dateStr, &e.Points, &e.Type, &e.SaleID, &expiresStr, &e.Remaining); err != nil {
+			return nil, err
+		}
+		e.Date, _ = time.Parse(time.RFC3339, dateStr)
+		e.ExpiresAt, _ = time.Parse(effectiveDateLayout, expiresStr)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// EarnPoints acredita puntos al cliente por una venta, con el vencimiento
+// que fijan las reglas.
+func (r *LoyaltyRepo) EarnPoints(customerID, saleID, points int, date time.Time, expiryDays int) error {
+	if points <= 0 {
+		return nil
+	}
+	expires := ""
+	if expiryDays > 0 {
+		expires = date.AddDate(0, 0, expiryDays).Format(effectiveDateLayout)
+	}
+	_, err := r.db.Exec("INSERT INTO loyalty_points (customer_id, date, points, type, sale_id, expires_at, remaining) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		customerID, date.Format(time.RFC3339), points, models.PointsEarned, saleID, expires, points)
+	return err
+}
+
+// redeemPoints descuenta dentro de la transacción los puntos canjeados en
+// una venta. Se usan primero los puntos que vencen antes.
+func redeemPoints(tx *sql.Tx, customerID, saleID, points int, date time.Time) error {
+	var available int
+	if err := tx.QueryRow("SELECT COALESCE(SUM(remaining), 0) FROM loyalty_points WHERE customer_id = ? AND remaining > 0", customerID).Scan(&available); err != nil {
+		return err
+	}
+	if points > available {
+		return fmt.Errorf("el cliente tiene %d puntos disponibles", available)
+	}
+	if _, err := usePoints(tx, customerID, points, 0); err != nil {
+		return err
+	}
+	_, err := tx.Exec("INSERT INTO loyalty_points (customer_id, date, points, type, sale_id) VALUES (?, ?, ?, ?, ?)", customerID, date.Format(time.RFC3339), -points, models.PointsRedeemed, saleID)
+	return err
+}
+
+// usePoints baja hasta points del saldo sin usar de las acumulaciones del
+// cliente: primero las de la venta saleID, si se indica, y después las que
+// vencen antes. Devuelve los puntos que pudo bajar.
+func usePoints(tx *sql.Tx, customerID, points, saleID int) (int, error) {
+	rows, err := tx.Query("SELECT id, remaining FROM loyalty_points WHERE customer_id = ? AND remaining > 0 ORDER BY sale_id <> ?, expires_at = '', expires_at, id", customerID, saleID)
+	if err != nil {
+		return 0, err
+	}
+	type lot struct{ id, remaining int }
+	var lots []lot
+	for rows.Next() {
+		var l lot
+		if err := rows.Scan(&l.id, &l.remaining); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		lots = append(lots, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	used := 0
+	for _, l := range lots {
+		if used == points {
+			break
+		}
+		n := min(points-used, l.remaining)
+		if _, err := tx.Exec("UPDATE loyalty_points SET remaining = remaining - ? WHERE id = ?", n, l.id); err != nil {
+			return 0, err
+		}
+		used += n
+	}
+	return used, nil
+}
+
+// reverseSalePoints anula dentro de la transacción los puntos de una venta
+// según reversal y devuelve los puntos anulados y los reintegrados.
+func reverseSalePoints(tx *sql.Tx, saleID int, reversal models.PointsReversal, date time.Time) (int, int, error) {
+	if reversal.Fraction <= 0 {
+		return 0, 0, nil
+	}
+	reversed, err := reverseEarnedPoints(tx, saleID, reversal.Fraction, date)
+	if err != nil || !reversal.RestoreRedeemed {
+		return reversed, 0, err
+	}
+	expiryDays := defaultPointsExpiryDays
+	err = tx.QueryRow("SELECT expiry_days FROM loyalty_settings WHERE id = 1").Scan(&expiryDays)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, err
+	}
+	restored, err := restoreRedeemedPoints(tx, saleID, reversal.Fraction, date, expiryDays)
+	return reversed, restored, err
+}
+
+// reverseEarnedPoints anula la parte indicada (de 0 a 1) de los puntos que
+// ganó una venta que se eliminó, se editó o se devolvió. Lo ya anulado antes
+// no se vuelve a contar. Si el cliente ya usó esos puntos, se descuentan de
+// su saldo aunque quede negativo. Devuelve los puntos anulados.
+func reverseEarnedPoints(tx *sql.Tx, saleID int, fraction float64, date time.Time) (int, error) {
+	earned, err := salePoints(tx, saleID, models.PointsEarned, models.PointsReversed)
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for customerID, points := range earned {
+		points = int(math.Round(float64(points) * fraction))
+		if points <= 0 {
+			continue
+		}
+		if _, err := usePoints(tx, customerID, points, saleID); err != nil {
+			return 0, err
+		}
+		_, err := tx.Exec("INSERT INTO loyalty_points (customer_id, date, points, type, sale_id) VALUES (?, ?, ?, ?, ?)", customerID, date.Format(time.RFC3339), -points, models.PointsReversed, saleID)
+		if err != nil {
+			return 0, err
+		}
+		total += points
+	}
+	return total, nil
+}
+
+// restoreRedeemedPoints reintegra al cliente la parte indicada (de 0 a 1) de
+// los puntos que canjeó en una venta que se eliminó, se editó o se devolvió.
+// Los puntos reintegrados vencen como si se hubieran ganado en la fecha
+// indicada. Devuelve los puntos reintegrados.
+func restoreRedeemedPoints(tx *sql.Tx, saleID int, fraction float64, date time.Time, expiryDays int) (int, error) {
+	redeemed, err := salePoints(tx, saleID, models.PointsRedeemed, models.PointsRestored)
+	if err != nil {
+		return 0, err
+	}
+	expires := ""
+	if expiryDays > 0 {
+		expires = date.AddDate(0, 0, expiryDays).Format(effectiveDateLayout)
+	}
+	total := 0
+	for customerID, points := range redeemed {
+		points = int(math.Round(float64(-points) * fraction))
+		if points <= 0 {
+			continue
+		}
+		_, err := tx.Exec("INSERT INTO loyalty_points (customer_id, date, points, type, sale_id, expires_at, remaining) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			customerID, date.Format(time.RFC3339), points, models.PointsRestored, saleID, expires, points)
+		if err != nil {
+			return 0, err
+		}
+		total += points
+	}
+	return total, nil
+}
+
+// salePoints suma por cliente los movimientos de una venta de un tipo y de
+// su anulación, es decir, lo que todavía queda sin anular.
+func salePoints(tx *sql.Tx, saleID int, entryType, reversalType string) (map[int]int, error) {
+	rows, err := tx.Query("SELECT customer_id, SUM(points) FROM loyalty_points WHERE sale_id = ? AND type IN (?, ?) GROUP BY customer_id", saleID, entryType, reversalType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make(map[int]int)
+	for rows.Next() {
+		var customerID, sum int
+		if err := rows.Scan(&customerID, &sum); err != nil {
+			return nil, err
+		}
+		points[customerID] = sum
+	}
+	return points, rows.Err()
+}
+
+// ExpirePoints da de baja los puntos no usados cuyo vencimiento ya pasó y
+// devuelve cuántos puntos vencieron.
+func (r *LoyaltyRepo) ExpirePoints(now time.Time) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, customer_id, remaining FROM loyalty_points WHERE remaining > 0 AND expires_at <> '' AND expires_at < ?", now.Format(effectiveDateLayout))
+	if err != nil {
+		return 0, err
+	}
+	type expiring struct{ id, customerID, remaining int }
+	var due []expiring
+	for rows.Next() {
+		var e expiring
+		if err := rows.Scan(&e.id, &e.customerID, &e.remaining); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, e)
+	}
+	rows.Close()
+
+	total := 0
+	for _, e := range due {
+		if _, err := tx.Exec("UPDATE loyalty_points SET remaining = 0 WHERE id = ?", e.id); err != nil {
+			return 0, err
+		}
+		_, err := tx.Exec("INSERT INTO loyalty_points (customer_id, date, points, type) VALUES (?, ?, ?, ?)", e.customerID, now.Format(time.RFC3339), -e.remaining, models.PointsExpired)
+		if err != nil {
+			return 0, err
+		}
+		total += e.remaining
+	}
+	return total, tx.Commit()
+}
\ No newline at end of file
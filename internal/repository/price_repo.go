@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"database/sql"
+	"sales-system/internal/models"
+	"time"
+)
+
+type PriceRepo struct {
+	db *sql.DB
+}
+
+func NewPriceRepo(db *sql.DB) *PriceRepo {
+	return &PriceRepo{db: db}
+}
+
+// effectiveDateLayout es el formato en que se guardan las fechas de vigencia,
+// que permite compararlas como texto.
+const effectiveDateLayout = "2006-01-02"
+
+const priceChangeColumns = "id, product_id, old_price, new_price, effective_date, status, reason"
+
+func scanPriceChanges(rows *sql.Rows) ([]models.PriceChange, error) {
+	defer rows.Close()
+
+	var changes []models.PriceChange
+	for rows.Next() {
+		var c models.PriceChange
+		var dateStr string
+		if err := rows.Scan(&c.ID, &c.ProductID, &c.OldPrice, &c.NewPrice, &dateStr, &c.Status, &c.Reason); err != nil {
+			return nil, err
+		}
+		c.EffectiveDate, _ = time.Parse(effectiveDateLayout, dateStr)
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// GetPriceHistory devuelve todos los cambios de precio de un producto, del
+// más reciente al más antiguo, incluidos los programados y los cancelados.
+func (r *PriceRepo) GetPriceHistory(productID int) ([]models.PriceChange, error) {
+	rows, err := r.db.Query("SELECT "+priceChangeColumns+" FROM price_changes WHERE product_id = ? ORDER BY effective_date DESC, id DESC", productID)
+	if err != nil {
+		return nil, err
+	}
+	return scanPriceChanges(rows)
+}
+
+// GetPriceAt devuelve el precio que tenía el producto en una fecha. Devuelve
+// sql.ErrNoRows si el producto todavía no tenía precio registrado.
+func (r *PriceRepo) GetPriceAt(productID int, date time.Time) (float64, error) {
+	var price float64
+	err := r.db.QueryRow("SELECT new_price FROM price_changes WHERE product_id = ? AND status = ? AND effective_date <= ? ORDER BY effective_date DESC, id DESC LIMIT 1", productID, models.PriceChangeApplied, date.Format(effectiveDateLayout)).Scan(&price)
+	return price, err
+}
+
+// GetScheduledChanges devuelve los cambios de precio pendientes, por fecha de vigencia.
+func (r *PriceRepo) GetScheduledChanges() ([]models.PriceChange, error) {
+	rows, err := r.db.Query("SELECT "+priceChangeColumns+" FROM price_changes WHERE status = ? ORDER BY effective_date, id", models.PriceChangeScheduled)
+	if err != nil {
+		return nil, err
+	}
+	return scanPriceChanges(rows)
+}
+
+// GetAppliedChanges devuelve los cambios de precio que entraron en vigencia
+// entre dos fechas, inclusive.
+func (r *PriceRepo) GetAppliedChanges(start, end time.Time) ([]models.PriceChange, error) {
+	rows, err := r.db.Query("SELECT "+priceChangeColumns+" FROM price_changes WHERE status = ? AND effective_date BETWEEN ? AND ? ORDER BY effective_date, product_id", models.PriceChangeApplied, start.Format(effectiveDateLayout), end.Format(effectiveDateLayout))
+	if err != nil {
+		return nil, err
+	}
+	return scanPriceChanges(rows)
+}
+
+// SavePriceChanges registra varios cambios de precio en una sola operación.
+// Los que tienen vigencia hasta hoy se aplican en el momento y quedan en el
+// historial con fecha de hoy, porque las ventas anteriores se hicieron con el
+// precio anterior; los demás quedan programados. Devuelve cuántos se
+// aplicaron y cuántos se programaron.
+func (r *PriceRepo) SavePriceChanges(changes []models.PriceChange, now time.Time) (applied, scheduled int, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	today := now.Format(effectiveDateLayout)
+	for _, c := range changes {
+		if c.EffectiveDate.Format(effectiveDateLayout) <= today {
+			if err := changePrice(tx, c.ProductID, c.NewPrice, now, c.Reason); err != nil {
+				return 0, 0, err
+			}
+			applied++
+			continue
+		}
+		if err := tx.QueryRow("SELECT price FROM products WHERE id = ?", c.ProductID).Scan(&c.OldPrice); err != nil {
+			return 0, 0, err
+		}
+		c.Status = models.PriceChangeScheduled
+		if err := insertPriceChange(tx, c); err != nil {
+			return 0, 0, err
+		}
+		scheduled++
+	}
+	return applied, scheduled, tx.Commit()
+}
+
+// CancelPriceChange cancela un cambio de precio que todavía no se aplicó.
+func (r *PriceRepo) CancelPriceChange(id int) error {
+	res, err := r.db.Exec("UPDATE price_changes SET status = ? WHERE id = ? AND status = ?", models.PriceChangeCancelled, id, models.PriceChangeScheduled)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ApplyScheduledChanges aplica los cambios programados cuya fecha de vigencia
+// ya llegó y devuelve cuántos se aplicaron. Como precio anterior queda el que
+// tenía el producto al momento de aplicarse y, si se aplican con atraso, la
+// vigencia pasa a ser la fecha en que se aplicaron.
+func (r *PriceRepo) ApplyScheduledChanges(now time.Time) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT "+priceChangeColumns+" FROM price_changes WHERE status = ? AND effective_date <= ? ORDER BY effective_date, id", models.PriceChangeScheduled, now.Format(effectiveDateLayout))
+	if err != nil {
+		return 0, err
+	}
+	due, err := scanPriceChanges(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, c := range due {
+		var oldPrice float64
+		if err := tx.QueryRow("SELECT price FROM products WHERE id = ?", c.ProductID).Scan(&oldPrice); err != nil {
+			return 0, err
+		}
+		if err := setPrice(tx, c.ProductID, c.NewPrice, now, true); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec("UPDATE price_changes SET old_price = ?, status = ?, effective_date = ? WHERE id = ?", oldPrice, models.PriceChangeApplied, now.Format(effectiveDateLayout), c.ID); err != nil {
+			return 0, err
+		}
+	}
+	return len(due), tx.Commit()
+}
+
+// execer es lo que tienen en común *sql.DB y *sql.Tx para ejecutar sentencias.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func insertPriceChange(e execer, c models.PriceChange) error {
+	_, err := e.Exec("INSERT INTO price_changes (product_id, old_price, new_price, effective_date, status, reason) VALUES (?, ?, ?, ?, ?, ?)", c.ProductID, c.OldPrice, c.NewPrice, c.EffectiveDate.Format(effectiveDateLayout), c.Status, c.Reason)
+	return err
+}
+
+// changePrice actualiza el precio de un producto y deja el cambio en el
+// historial de precios. Si el producto es una variante pasa a tener precio
+// propio; si es un producto padre, el precio se propaga a sus variantes.
+func changePrice(tx *sql.Tx, productID int, newPrice float64, date time.Time, reason string) error {
+	return updatePrice(tx, productID, newPrice, date, reason, true)
+}
+
+// inheritPrice aplica a una variante el precio de su producto padre.
+func inheritPrice(tx *sql.Tx, variantID int, price float64, date time.Time) error {
+	return updatePrice(tx, variantID, price, date, "Precio del producto padre", false)
+}
+
+func updatePrice(tx *sql.Tx, productID int, newPrice float64, date time.Time, reason string, own bool) error {
+	var oldPrice float64
+	if err := tx.QueryRow("SELECT price FROM products WHERE id = ?", productID).Scan(&oldPrice); err != nil {
+		return err
+	}
+	if oldPrice == newPrice {
+		return nil
+	}
+	if err := setPrice(tx, productID, newPrice, date, own); err != nil {
+		return err
+	}
+	return insertPriceChange(tx, models.PriceChange{
+		ProductID:     productID,
+		OldPrice:      oldPrice,
+		NewPrice:      newPrice,
+		EffectiveDate: date,
+		Status:        models.PriceChangeApplied,
+		Reason:        reason,
+	})
+}
+
+// setPrice fija el precio de un producto y lo propaga a las variantes que
+// siguen el precio del padre. Es el único lugar donde cambia el precio, tanto
+// al editar un producto como al aplicar cambios programados. Con own, una
+// variante queda marcada con precio propio.
+func setPrice(tx *sql.Tx, productID int, price float64, date time.Time, own bool) error {
+	if _, err := tx.Exec("UPDATE products SET price = ?, price_override = CASE WHEN ? THEN parent_id <> 0 ELSE price_override END WHERE id = ?", price, own, productID); err != nil {
+		return err
+	}
+	rows, err := tx.Query("SELECT id FROM products WHERE parent_id = ? AND price_override = 0", productID)
+	if err != nil {
+		return err
+	}
+	var variants []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		variants = append(variants, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range variants {
+		if err := inheritPrice(tx, id, price, date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
\ No newline at end of file
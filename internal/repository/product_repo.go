@@ -2,8 +2,16 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"math"
 	"sales-system/internal/models"
+	"sales-system/internal/utils"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/mattn/go-sqlite3"
 )
 
 type ProductRepo struct {
@@ -14,53 +22,716 @@ func NewProductRepo(db *sql.DB) *ProductRepo {
 	return &ProductRepo{db: db}
 }
 
-func (r *ProductRepo) CreateProduct(p models.Product) (int64, error) {
-	res, err := r.db.Exec("INSERT INTO products (date, name, quantity, price) VALUES (?, ?, ?, ?)", p.Date.Format(time.RFC3339), p.Name, p.Quantity, p.Price)
+const productColumns = "id, date, name, quantity, price, cost, cost_method, min_stock, reorder_qty, category_id, brand, tags, sku, barcode, parent_id, attributes, unit, decimals, purchase_unit, purchase_factor, track_lots, is_kit, archived, price_override"
+
+// scanner es la interfaz común de *sql.Row y *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanProduct(row scanner) (*models.Product, error) {
+	var p models.Product
+	var dateStr, tags, attributes string
+	if err := row.Scan(&p.ID, &dateStr, &p.Name, &p.Quantity, &p.Price, &p.Cost, &p.CostMethod, &p.MinStock, &p.ReorderQty, &p.CategoryID, &p.Brand, &tags, &p.SKU, &p.Barcode, &p.ParentID, &attributes, &p.Unit, &p.Decimals, &p.PurchaseUnit, &p.PurchaseFactor, &p.TrackLots, &p.IsKit, &p.Archived, &p.PriceOverride); err != nil {
+		return nil, err
+	}
+	p.Date, _ = time.Parse(time.RFC3339, dateStr)
+	p.Tags = models.ParseTags(tags)
+	p.Attributes = models.ParseAttributes(attributes)
+	return &p, nil
+}
+
+func scanProducts(rows *sql.Rows) ([]models.Product, error) {
+	defer rows.Close()
+
+	var products []models.Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *p)
+	}
+	return products, nil
+}
+
+// Las etiquetas se guardan separadas por comas en una sola columna.
+func joinTags(tags []string) string {
+	return strings.Join(tags, ",")
+}
+
+// CreateProduct registra el producto y, si tiene cantidad inicial, la asienta
+// como primera entrada de mercadería al costo indicado, en el lote indicado
+// si el producto controla lotes.
+func (r *ProductRepo) CreateProduct(p models.Product, lot models.Lot) (int64, error) {
+	if p.CostMethod == "" {
+		p.CostMethod = models.CostMethodAverage
+	}
+	if p.Unit == "" {
+		p.Unit = models.UnitPiece
+	}
+	if p.PurchaseFactor <= 0 {
+		p.PurchaseFactor = 1
+	}
+	res, err := r.db.Exec("INSERT INTO products (date, name, quantity, price, cost, cost_method, min_stock, reorder_qty, category_id, brand, tags, sku, barcode, parent_id, attributes, unit, decimals, purchase_unit, purchase_factor, track_lots, price_override) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", p.Date.Format(time.RFC3339), p.Name, 0, p.Price, p.Cost, p.CostMethod, p.MinStock, p.ReorderQty, p.CategoryID, p.Brand, joinTags(p.Tags), p.SKU, p.Barcode, p.ParentID, models.FormatAttributes(p.Attributes), p.Unit, p.Decimals, p.PurchaseUnit, p.PurchaseFactor, p.TrackLots, p.ParentID != 0 && p.PriceOverride)
 	if err != nil {
-		return 0, err
+		return 0, duplicateCodeError(err)
 	}
 	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	err = insertPriceChange(r.db, models.PriceChange{ProductID: int(id), NewPrice: p.Price, EffectiveDate: p.Date, Status: models.PriceChangeApplied, Reason: "Precio inicial"})
+	if err != nil {
+		return 0, err
+	}
+	if p.Quantity > 0 {
+		err = r.ReceiveStock(int(id), models.DefaultLocationID, p.Quantity, p.Cost, p.Date, "Stock inicial", lot)
+	}
 	return id, err
 }
 
 func (r *ProductRepo) GetProductByID(id int) (*models.Product, error) {
-	row := r.db.QueryRow("SELECT id, date, name, quantity, price FROM products WHERE id = ?", id)
-	var p models.Product
-	var dateStr string
-	err := row.Scan(&p.ID, &dateStr, &p.Name, &p.Quantity, &p.Price)
+	row := r.db.QueryRow("SELECT "+productColumns+" FROM products WHERE id = ?", id)
+	return scanProduct(row)
+}
+
+// GetProductByCode busca un producto por código de barras o SKU, en ese
+// orden, para aceptar indistintamente lo que escriba o escanee el usuario. El
+// ID se indica con el prefijo "#" (por ejemplo "#12"), para que no se
+// confunda con un SKU numérico.
+func (r *ProductRepo) GetProductByCode(code string) (*models.Product, error) {
+	code = strings.TrimSpace(code)
+	if idStr, ok := strings.CutPrefix(code, "#"); ok {
+		id, err := strconv.Atoi(strings.TrimSpace(idStr))
+		if err != nil {
+			return nil, sql.ErrNoRows
+		}
+		return r.GetProductByID(id)
+	}
+
+	if barcode, ok := utils.NormalizeBarcode(code); ok {
+		p, err := scanProduct(r.db.QueryRow("SELECT "+productColumns+" FROM products WHERE barcode = ?", barcode))
+		if err != sql.ErrNoRows {
+			return p, err
+		}
+	}
+
+	return scanProduct(r.db.QueryRow("SELECT "+productColumns+" FROM products WHERE sku = ? COLLATE NOCASE AND sku <> ''", code))
+}
+
+func (r *ProductRepo) GetAllProducts() ([]models.Product, error) {
+	rows, err := r.db.Query("SELECT " + productColumns + " FROM products WHERE archived = 0 ORDER BY id DESC")
 	if err != nil {
 		return nil, err
 	}
-	p.Date, _ = time.Parse(time.RFC3339, dateStr)
-	return &p, nil
+	return scanProducts(rows)
 }
 
-func (r *ProductRepo) GetAllProducts() ([]models.Product, error) {
-	rows, err := r.db.Query("SELECT id, date, name, quantity, price FROM products ORDER BY id DESC")
+// ProductFilter define los criterios de búsqueda y orden del listado de productos.
+// Los campos vacíos no filtran.
+type ProductFilter struct {
+	CategoryIDs []int
+	Brand       string
+	Tag         string
+	Name        string
+	SortBy      string
+}
+
+// Columnas por las que se puede ordenar el listado de productos.
+var productSortColumns = map[string]string{
+	"id":     "id DESC",
+	"nombre": "name COLLATE NOCASE",
+	"stock":  "quantity",
+	"precio": "price",
+	"marca":  "brand COLLATE NOCASE, name COLLATE NOCASE",
+}
+
+// FindProducts devuelve los productos que cumplen con el filtro, en el orden
+// pedido. Los productos archivados no se incluyen.
+func (r *ProductRepo) FindProducts(f ProductFilter) ([]models.Product, error) {
+	conditions := []string{"archived = 0"}
+	var args []any
+
+	if len(f.CategoryIDs) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(f.CategoryIDs)), ",")
+		conditions = append(conditions, "category_id IN ("+placeholders+")")
+		for _, id := range f.CategoryIDs {
+			args = append(args, id)
+		}
+	}
+	if f.Brand != "" {
+		conditions = append(conditions, "brand = ? COLLATE NOCASE")
+		args = append(args, f.Brand)
+	}
+	if f.Tag != "" {
+		conditions = append(conditions, "(',' || tags || ',') LIKE ?")
+		args = append(args, "%,"+f.Tag+",%")
+	}
+	if f.Name != "" {
+		conditions = append(conditions, "(name LIKE ? OR sku = ? OR barcode = ?)")
+		args = append(args, "%"+f.Name+"%", f.Name, f.Name)
+	}
+
+	query := "SELECT " + productColumns + " FROM products WHERE " + strings.Join(conditions, " AND ")
+	order, ok := productSortColumns[f.SortBy]
+	if !ok {
+		order = productSortColumns["id"]
+	}
+	query += " ORDER BY " + order
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanProducts(rows)
+}
+
+// GetLowStockProducts devuelve los productos con stock en o por debajo de su mínimo.
+func (r *ProductRepo) GetLowStockProducts() ([]models.Product, error) {
+	rows, err := r.db.Query("SELECT " + productColumns + " FROM products WHERE archived = 0 AND min_stock > 0 AND quantity <= min_stock ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	return scanProducts(rows)
+}
+
+// UpdateProduct guarda los datos del producto, salvo la cantidad, que solo
+// cambia con movimientos de stock. Si cambió el precio, el cambio queda en el
+// historial de precios.
+func (r *ProductRepo) UpdateProduct(p models.Product) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var unit string
+	var quantity float64
+	if err := tx.QueryRow("SELECT unit, quantity FROM products WHERE id = ?", p.ID).Scan(&unit, &quantity); err != nil {
+		return err
+	}
+	if p.Unit != unit && math.Abs(quantity) > quantityEpsilon {
+		return ErrUnitChangeWithStock
+	}
+
+	if err := changePrice(tx, p.ID, p.Price, time.Now(), "Edición del producto"); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE products SET date = ?, price = ?, cost = ?, cost_method = ?, min_stock = ?, reorder_qty = ?, category_id = ?, brand = ?, tags = ?, sku = ?, barcode = ?, unit = ?, decimals = ?, purchase_unit = ?, purchase_factor = ?, track_lots = ? WHERE id = ?", p.Date.Format(time.RFC3339), p.Price, p.Cost, p.CostMethod, p.MinStock, p.ReorderQty, p.CategoryID, p.Brand, joinTags(p.Tags), p.SKU, p.Barcode, p.Unit, p.Decimals, p.PurchaseUnit, p.PurchaseFactor, p.TrackLots, p.ID)
+	if err != nil {
+		return duplicateCodeError(err)
+	}
+	return tx.Commit()
+}
+
+// GetVariants devuelve las variantes de un producto padre.
+func (r *ProductRepo) GetVariants(parentID int) ([]models.Product, error) {
+	rows, err := r.db.Query("SELECT "+productColumns+" FROM products WHERE parent_id = ? ORDER BY id", parentID)
+	if err != nil {
+		return nil, err
+	}
+	return scanProducts(rows)
+}
+
+// UseParentPrice quita el precio propio de una variante: vuelve a tomar el
+// precio del producto padre y a seguir sus cambios.
+func (r *ProductRepo) UseParentPrice(variantID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var parentPrice float64
+	err = tx.QueryRow("SELECT pp.price FROM products p JOIN products pp ON pp.id = p.parent_id WHERE p.id = ?", variantID).Scan(&parentPrice)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE products SET price_override = 0 WHERE id = ?", variantID); err != nil {
+		return err
+	}
+	if err := inheritPrice(tx, variantID, parentPrice, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetKitComponents devuelve la lista de materiales de un kit.
+func (r *ProductRepo) GetKitComponents(kitID int) ([]models.KitComponent, error) {
+	return kitComponents(r.db, kitID)
+}
+
+// SetKitComponents reemplaza la lista de materiales de un kit. Sin
+// componentes, el producto deja de ser un kit. Un kit no puede tener stock
+// propio ni formar parte de otro kit.
+func (r *ProductRepo) SetKitComponents(kitID int, components []models.KitComponent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var quantity float64
+	if err := tx.QueryRow("SELECT quantity FROM products WHERE id = ?", kitID).Scan(&quantity); err != nil {
+		return err
+	}
+	if len(components) > 0 && quantity > quantityEpsilon {
+		return fmt.Errorf("el producto tiene stock propio (%g)", quantity)
+	}
+	var usedIn int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM kit_components WHERE component_id = ?", kitID).Scan(&usedIn); err != nil {
+		return err
+	}
+	if len(components) > 0 && usedIn > 0 {
+		return fmt.Errorf("el producto es componente de otro kit")
+	}
+
+	if _, err := tx.Exec("DELETE FROM kit_components WHERE kit_id = ?", kitID); err != nil {
+		return err
+	}
+	for _, c := range components {
+		var isKit bool
+		if err := tx.QueryRow("SELECT is_kit FROM products WHERE id = ?", c.ComponentID).Scan(&isKit); err != nil {
+			return err
+		}
+		if isKit || c.ComponentID == kitID {
+			return fmt.Errorf("un kit no puede ser componente de otro kit")
+		}
+		if _, err := tx.Exec("INSERT INTO kit_components (kit_id, component_id, quantity) VALUES (?, ?, ?)", kitID, c.ComponentID, c.Quantity); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("UPDATE products SET is_kit = ? WHERE id = ?", len(components) > 0, kitID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetKitAvailability devuelve cuántos kits se pueden armar con el stock de
+// los componentes en una ubicación, o en toda la empresa si locationID es 0.
+func (r *ProductRepo) GetKitAvailability(kitID, locationID int) (float64, error) {
+	components, err := kitComponents(r.db, kitID)
+	if err != nil {
+		return 0, err
+	}
+	stock := make(map[int]float64, len(components))
+	for _, c := range components {
+		var quantity float64
+		if locationID == 0 {
+			err = r.db.QueryRow("SELECT quantity FROM products WHERE id = ?", c.ComponentID).Scan(&quantity)
+		} else {
+			quantity, err = locationQuantity(r.db, c.ComponentID, locationID)
+		}
+		if err != nil {
+			return 0, err
+		}
+		stock[c.ComponentID] = quantity
+	}
+	return models.KitAvailability(components, stock), nil
+}
+
+// GetSaleComponents devuelve lo que se descontó de cada componente en la
+// venta de un kit.
+func (r *ProductRepo) GetSaleComponents(saleID int) ([]models.SaleComponent, error) {
+	return saleComponents(r.db, saleID)
+}
+
+func saleComponents(q queryer, saleID int) ([]models.SaleComponent, error) {
+	rows, err := q.Query("SELECT sale_id, component_id, per_kit, quantity, price, cost FROM sale_components WHERE sale_id = ? ORDER BY component_id", saleID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var products []models.Product
+	var components []models.SaleComponent
 	for rows.Next() {
-		var p models.Product
-		var dateStr string
-		if err := rows.Scan(&p.ID, &dateStr, &p.Name, &p.Quantity, &p.Price); err != nil {
+		var c models.SaleComponent
+		if err := rows.Scan(&c.SaleID, &c.ComponentID, &c.PerKit, &c.Quantity, &c.Price, &c.Cost); err != nil {
 			return nil, err
 		}
-		p.Date, _ = time.Parse(time.RFC3339, dateStr)
-		products = append(products, p)
+		components = append(components, c)
 	}
-	return products, nil
+	return components, nil
 }
 
-func (r *ProductRepo) UpdateProduct(p models.Product) error {
-	_, err := r.db.Exec("UPDATE products SET date = ?, quantity = ?, price = ? WHERE id = ?", p.Date.Format(time.RFC3339), p.Quantity, p.Price, p.ID)
+func kitComponents(q queryer, kitID int) ([]models.KitComponent, error) {
+	rows, err := q.Query("SELECT kit_id, component_id, quantity FROM kit_components WHERE kit_id = ? ORDER BY component_id", kitID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var components []models.KitComponent
+	for rows.Next() {
+		var c models.KitComponent
+		if err := rows.Scan(&c.KitID, &c.ComponentID, &c.Quantity); err != nil {
+			return nil, err
+		}
+		components = append(components, c)
+	}
+	return components, nil
+}
+
+// queryer es lo que tienen en común *sql.DB y *sql.Tx para consultar varias filas.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// ArchiveProduct oculta un producto de los listados y de la venta sin perder
+// su historial. Se cancelan sus cambios de precio programados.
+func (r *ProductRepo) ArchiveProduct(id int) error {
+	_, err := r.db.Exec("UPDATE products SET archived = 1 WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec("UPDATE price_changes SET status = ? WHERE product_id = ? AND status = ?", models.PriceChangeCancelled, id, models.PriceChangeScheduled)
 	return err
 }
 
+// RestoreProduct vuelve a habilitar un producto archivado.
+func (r *ProductRepo) RestoreProduct(id int) error {
+	_, err := r.db.Exec("UPDATE products SET archived = 0 WHERE id = ?", id)
+	return err
+}
+
+// GetArchivedProducts devuelve los productos archivados.
+func (r *ProductRepo) GetArchivedProducts() ([]models.Product, error) {
+	rows, err := r.db.Query("SELECT " + productColumns + " FROM products WHERE archived = 1 ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	return scanProducts(rows)
+}
+
+// ErrProductHasSales indica que el producto no se puede eliminar porque
+// tiene ventas registradas; en su lugar se archiva.
+var ErrProductHasSales = errors.New("el producto tiene ventas registradas")
+
+// ErrUnitChangeWithStock indica que se quiso cambiar la unidad de medida de un
+// producto que tiene stock.
+var ErrUnitChangeWithStock = errors.New("no se puede cambiar la unidad de un producto con stock")
+
+// ErrDuplicateSKU y ErrDuplicateBarcode indican que el SKU o el código de
+// barras ya pertenecen a otro producto.
+var (
+	ErrDuplicateSKU     = errors.New("el SKU ya pertenece a otro producto")
+	ErrDuplicateBarcode = errors.New("el código de barras ya pertenece a otro producto")
+)
+
+// duplicateCodeError traduce la violación de los índices únicos de SKU y
+// código de barras a un error legible.
+func duplicateCodeError(err error) error {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.ExtendedCode != sqlite3.ErrConstraintUnique {
+		return err
+	}
+	switch {
+	case strings.Contains(sqliteErr.Error(), "products.sku"):
+		return ErrDuplicateSKU
+	case strings.Contains(sqliteErr.Error(), "products.barcode"):
+		return ErrDuplicateBarcode
+	}
+	return err
+}
+
+// DeleteProduct elimina un producto sin ventas. Si tiene ventas devuelve
+// ErrProductHasSales.
 func (r *ProductRepo) DeleteProduct(id int) error {
+	var sales int
+	if err := r.db.QueryRow("SELECT (SELECT COUNT(*) FROM sales WHERE product_id = ?) + (SELECT COUNT(*) FROM sale_components WHERE component_id = ?)", id, id).Scan(&sales); err != nil {
+		return err
+	}
+	if sales > 0 {
+		return ErrProductHasSales
+	}
+	var kits int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM kit_components WHERE component_id = ?", id).Scan(&kits); err != nil {
+		return err
+	}
+	if kits > 0 {
+		return fmt.Errorf("el producto es componente de %d kit(s)", kits)
+	}
 	_, err := r.db.Exec("DELETE FROM products WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec("DELETE FROM kit_components WHERE kit_id = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec("DELETE FROM location_stock WHERE product_id = ?", id)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec("DELETE FROM price_changes WHERE product_id = ?", id)
+	return err
+}
+
+// ReceiveStock registra una entrada de mercadería en una ubicación: suma la
+// cantidad al stock, recalcula el costo promedio ponderado y guarda una capa
+// de costo para FIFO. Si se indica un número de lote, la cantidad se suma a
+// ese lote.
+func (r *ProductRepo) ReceiveStock(productID, locationID int, quantity, unitCost float64, date time.Time, reference string, lot models.Lot) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := receiveStock(tx, productID, locationID, quantity, unitCost, date, models.MovementReceipt, reference); err != nil {
+		return err
+	}
+	if lot.Number != "" {
+		if err := addLot(tx, productID, locationID, lot.Number, lot.Expiry, quantity); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ConsumeStock descuenta del stock de la ubicación de la venta la cantidad
+// vendida y devuelve el costo total de lo vendido según el método de costeo
+// del producto. Los lotes se consumen por fecha de vencimiento (FEFO); los
+// vencidos solo si includeExpired es verdadero.
+func (r *ProductRepo) ConsumeStock(sale models.Sale, quantity float64, reference string, includeExpired bool) (float64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	cost, err := consumeSale(tx, sale, quantity, reference, includeExpired)
+	if err != nil {
+		return 0, err
+	}
+	return cost, tx.Commit()
+}
+
+// consumeSale descuenta lo vendido en una venta dentro de la transacción y
+// devuelve su costo.
+func consumeSale(tx *sql.Tx, sale models.Sale, quantity float64, reference string, includeExpired bool) (float64, error) {
+	components, err := kitComponents(tx, sale.ProductID)
+	if err != nil {
+		return 0, err
+	}
+	if len(components) == 0 {
+		return consumeSaleStock(tx, sale, sale.ProductID, quantity, reference, includeExpired)
+	}
+
+	// Un kit no tiene stock propio: se descuenta cada componente y se registra
+	// lo consumido para poder devolverlo y para los reportes.
+	var total float64
+	for _, c := range components {
+		var price float64
+		if err := tx.QueryRow("SELECT price FROM products WHERE id = ?", c.ComponentID).Scan(&price); err != nil {
+			return 0, err
+		}
+		componentQty := quantity * c.Quantity
+		cost, err := consumeSaleStock(tx, sale, c.ComponentID, componentQty, reference, includeExpired)
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.Exec(`INSERT INTO sale_components (sale_id, component_id, per_kit, quantity, price, cost) VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(sale_id, component_id) DO UPDATE SET quantity = quantity + excluded.quantity, cost = cost + excluded.cost`,
+			sale.ID, c.ComponentID, c.Quantity, componentQty, price, cost)
+		if err != nil {
+			return 0, err
+		}
+		total += cost
+	}
+	return total, nil
+}
+
+// consumeSaleStock descuenta un producto para una venta, con sus lotes.
+func consumeSaleStock(tx *sql.Tx, sale models.Sale, productID int, quantity float64, reference string, includeExpired bool) (float64, error) {
+	cost, err := consumeStock(tx, productID, sale.LocationID, quantity, sale.Date, models.MovementSale, reference)
+	if err != nil {
+		return 0, err
+	}
+	allocations, err := consumeLots(tx, productID, sale.LocationID, quantity, includeExpired, sale.Date)
+	if err != nil {
+		return 0, err
+	}
+	for _, a := range allocations {
+		if _, err := tx.Exec("INSERT INTO sale_lots (sale_id, lot_id, quantity) VALUES (?, ?, ?)", sale.ID, a.LotID, a.Quantity); err != nil {
+			return 0, err
+		}
+	}
+	return cost, nil
+}
+
+// ReturnStock reingresa al stock de la ubicación de la venta una cantidad
+// previamente vendida, al costo unitario con el que había salido, y la
+// devuelve a los lotes de los que salió. Si la venta fue de un kit, se
+// reingresan sus componentes al costo con que salió cada uno.
+func (r *ProductRepo) ReturnStock(sale models.Sale, quantity, unitCost float64, date time.Time, reference string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := returnSale(tx, sale, quantity, unitCost, date, reference); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// returnSale reingresa dentro de la transacción lo devuelto de una venta.
+func returnSale(tx *sql.Tx, sale models.Sale, quantity, unitCost float64, date time.Time, reference string) error {
+	components, err := saleComponents(tx, sale.ID)
+	if err != nil {
+		return err
+	}
+	if len(components) == 0 {
+		if err := receiveStock(tx, sale.ProductID, sale.LocationID, quantity, unitCost, date, models.MovementReturn, reference); err != nil {
+			return err
+		}
+		return returnSaleLots(tx, sale.ID, sale.ProductID, quantity)
+	}
+
+	for _, c := range components {
+		returned := min(quantity*c.PerKit, c.Quantity)
+		if returned <= quantityEpsilon {
+			continue
+		}
+		componentCost := c.Cost / c.Quantity
+		if err := receiveStock(tx, c.ComponentID, sale.LocationID, returned, componentCost, date, models.MovementReturn, reference); err != nil {
+			return err
+		}
+		if err := returnSaleLots(tx, sale.ID, c.ComponentID, returned); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE sale_components SET quantity = quantity - ?, cost = cost - ? WHERE sale_id = ? AND component_id = ?", returned, returned*componentCost, sale.ID, c.ComponentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsufficientStockError indica que la ubicación no tiene stock suficiente
+// para una salida.
+type InsufficientStockError struct {
+	Product   string
+	Available float64
+	Requested float64
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("stock insuficiente de %s en la ubicación (disponible: %g, pedido: %g)", e.Product, e.Available, e.Requested)
+}
+
+// quantityEpsilon absorbe los errores de redondeo al operar con cantidades decimales.
+const quantityEpsilon = 1e-9
+
+func receiveStock(tx *sql.Tx, productID, locationID int, quantity, unitCost float64, date time.Time, movementType, reference string) error {
+	p, err := scanProduct(tx.QueryRow("SELECT "+productColumns+" FROM products WHERE id = ?", productID))
+	if err != nil {
+		return err
+	}
+
+	// Promedio ponderado entre el stock existente y lo que ingresa.
+	newQuantity := p.Quantity + quantity
+	newCost := unitCost
+	if p.Quantity > 0 && newQuantity > 0 {
+		newCost = (p.Quantity*p.Cost + quantity*unitCost) / newQuantity
+	}
+
+	if _, err := tx.Exec("UPDATE products SET cost = ? WHERE id = ?", newCost, productID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO stock_layers (product_id, date, quantity, remaining, unit_cost) VALUES (?, ?, ?, ?, ?)", productID, date.Format(time.RFC3339), quantity, quantity, unitCost); err != nil {
+		return err
+	}
+	if err := addLocationStock(tx, productID, locationID, quantity); err != nil {
+		return err
+	}
+	return insertMovement(tx, models.StockMovement{Date: date, ProductID: productID, LocationID: locationID, Type: movementType, Quantity: quantity, UnitCost: unitCost, Reference: reference})
+}
+
+// consumeStock descuenta stock de una ubicación y devuelve el costo de lo que
+// salió. Falla si la ubicación no tiene la cantidad pedida.
+func consumeStock(tx *sql.Tx, productID, locationID int, quantity float64, date time.Time, movementType, reference string) (float64, error) {
+	p, err := scanProduct(tx.QueryRow("SELECT "+productColumns+" FROM products WHERE id = ?", productID))
+	if err != nil {
+		return 0, err
+	}
+	available, err := locationQuantity(tx, productID, locationID)
+	if err != nil {
+		return 0, err
+	}
+	if quantity > available+quantityEpsilon {
+		return 0, &InsufficientStockError{Product: p.Name, Available: available, Requested: quantity}
+	}
+
+	type layer struct {
+		id        int
+		remaining float64
+		unitCost  float64
+	}
+	rows, err := tx.Query("SELECT id, remaining, unit_cost FROM stock_layers WHERE product_id = ? AND remaining > 1e-9 ORDER BY date, id", productID)
+	if err != nil {
+		return 0, err
+	}
+	var layers []layer
+	for rows.Next() {
+		var l layer
+		if err := rows.Scan(&l.id, &l.remaining, &l.unitCost); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		layers = append(layers, l)
+	}
+	rows.Close()
+
+	// Las capas se consumen siempre en orden de llegada; el método de costeo
+	// solo decide a qué costo se valoriza la salida.
+	var fifoCost float64
+	pending := quantity
+	for _, l := range layers {
+		if pending <= quantityEpsilon {
+			break
+		}
+		taken := min(l.remaining, pending)
+		fifoCost += taken * l.unitCost
+		pending -= taken
+		if _, err := tx.Exec("UPDATE stock_layers SET remaining = ? WHERE id = ?", l.remaining-taken, l.id); err != nil {
+			return 0, err
+		}
+	}
+	// Stock sin capas (cargado antes del control de costos) se valoriza al costo actual.
+	fifoCost += max(pending, 0) * p.Cost
+
+	cost := quantity * p.Cost
+	newCost := p.Cost
+	if p.CostMethod == models.CostMethodFIFO {
+		cost = fifoCost
+		var remainingQty, remainingValue float64
+		if err := tx.QueryRow("SELECT COALESCE(SUM(remaining), 0), COALESCE(SUM(remaining * unit_cost), 0) FROM stock_layers WHERE product_id = ?", productID).Scan(&remainingQty, &remainingValue); err != nil {
+			return 0, err
+		}
+		if remainingQty > quantityEpsilon {
+			newCost = remainingValue / remainingQty
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE products SET cost = ? WHERE id = ?", newCost, productID); err != nil {
+		return 0, err
+	}
+	if err := addLocationStock(tx, productID, locationID, -quantity); err != nil {
+		return 0, err
+	}
+
+	unitCost := 0.0
+	if quantity != 0 {
+		unitCost = cost / quantity
+	}
+	err = insertMovement(tx, models.StockMovement{Date: date, ProductID: productID, LocationID: locationID, Type: movementType, Quantity: -quantity, UnitCost: unitCost, Reference: reference})
+	return cost, err
+}
+
+func insertMovement(tx *sql.Tx, m models.StockMovement) error {
+	_, err := tx.Exec("INSERT INTO stock_movements (date, product_id, location_id, type, quantity, unit_cost, reference) VALUES (?, ?, ?, ?, ?, ?, ?)", m.Date.Format(time.RFC3339), m.ProductID, m.LocationID, m.Type, m.Quantity, m.UnitCost, m.Reference)
 	return err
 }
\ No newline at end of file
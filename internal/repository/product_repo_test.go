@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sales-system/internal/database"
+	"sales-system/internal/models"
+	"testing"
+	"time"
+)
+
+// testDate es la fecha de los movimientos de las pruebas.
+var testDate = time.Date(2024, 6, 15, 12, 0, 0, 0, time.Local)
+
+// testDatabase crea una base vacía con el esquema real en un directorio
+// temporal de la prueba.
+func testDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+	database.InitDB(filepath.Join(t.TempDir(), "test.db"))
+	db := database.DB
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// testProduct registra un producto sin stock y devuelve su ID.
+func testProduct(t *testing.T, db *sql.DB, p models.Product) int {
+	t.Helper()
+	if p.Date.IsZero() {
+		p.Date = testDate
+	}
+	id, err := NewProductRepo(db).CreateProduct(p, models.Lot{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int(id)
+}
+
+// assertStock verifica el stock del producto en la ubicación principal y
+// que products.quantity siga coincidiendo con él.
+func assertStock(t *testing.T, db *sql.DB, productID int, want float64) {
+	t.Helper()
+	quantity, err := locationQuantity(db, productID, models.DefaultLocationID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewProductRepo(db).GetProductByID(productID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(quantity-want) > quantityEpsilon || math.Abs(p.Quantity-want) > quantityEpsilon {
+		t.Errorf("%s: stock %g en la ubicación y %g en el producto, se esperaba %g", p.Name, quantity, p.Quantity, want)
+	}
+}
+
+func TestRecordSaleConsumesStock(t *testing.T) {
+	type receipt struct{ quantity, unitCost float64 }
+	tests := []struct {
+		name       string
+		costMethod string
+		receipts   []receipt
+		sold       float64
+		wantCost   float64
+		wantStock  float64
+		wantErr    bool
+	}{
+		{"costo promedio", models.CostMethodAverage, []receipt{{10, 5}, {10, 7}}, 5, 30, 15, false},
+		{"costo FIFO", models.CostMethodFIFO, []receipt{{10, 5}, {10, 7}}, 12, 64, 8, false},
+		{"todo el stock", models.CostMethodFIFO, []receipt{{10, 5}, {10, 7}}, 20, 120, 0, false},
+		{"cantidad decimal", models.CostMethodAverage, []receipt{{1.5, 10}}, 0.75, 7.5, 0.75, false},
+		{"stock insuficiente", models.CostMethodAverage, []receipt{{10, 5}, {10, 7}}, 25, 0, 20, true},
+		{"sin stock", models.CostMethodAverage, nil, 1, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDatabase(t)
+			productRepo, saleRepo := NewProductRepo(db), NewSaleRepo(db)
+			id := testProduct(t, db, models.Product{Name: "Queso", Price: 50, CostMethod: tt.costMethod})
+			for _, r := range tt.receipts {
+				if err := productRepo.ReceiveStock(id, models.DefaultLocationID, r.quantity, r.unitCost, testDate, "Compra", models.Lot{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			sale := models.Sale{Date: testDate, LocationID: models.DefaultLocationID, Client: "Cliente", ProductID: id, Quantity: tt.sold, Price: 50, Total: 50 * tt.sold, Status: models.StatusPaid}
+			saleID, err := saleRepo.RecordSale(sale, false, models.PointsRedemption{}, nil)
+			if tt.wantErr {
+				var stockErr *InsufficientStockError
+				if !errors.As(err, &stockErr) {
+					t.Fatalf("error %v, se esperaba *InsufficientStockError", err)
+				}
+				if stockErr.Requested != tt.sold || stockErr.Available != tt.wantStock {
+					t.Errorf("error con disponible %g y pedido %g, se esperaba %g y %g", stockErr.Available, stockErr.Requested, tt.wantStock, tt.sold)
+				}
+				sales, err := saleRepo.GetAllSales()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(sales) != 0 {
+					t.Errorf("se registraron %d ventas sin stock", len(sales))
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				recorded, err := saleRepo.GetSaleByID(int(saleID))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if math.Abs(recorded.Cost-tt.wantCost) > 1e-9 {
+					t.Errorf("costo de la venta %g, se esperaba %g", recorded.Cost, tt.wantCost)
+				}
+			}
+			assertStock(t, db, id, tt.wantStock)
+		})
+	}
+}
+
+func TestReturnStockRestoresStockAtSaleCost(t *testing.T) {
+	db := testDatabase(t)
+	productRepo, saleRepo := NewProductRepo(db), NewSaleRepo(db)
+	id := testProduct(t, db, models.Product{Name: "Queso", Price: 50, CostMethod: models.CostMethodFIFO})
+	if err := productRepo.ReceiveStock(id, models.DefaultLocationID, 10, 5, testDate, "Compra", models.Lot{}); err != nil {
+		t.Fatal(err)
+	}
+	sale := models.Sale{Date: testDate, LocationID: models.DefaultLocationID, ProductID: id, Quantity: 4, Price: 50, Total: 200, Status: models.StatusPaid}
+	saleID, err := saleRepo.RecordSale(sale, false, models.PointsRedemption{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sale.ID = int(saleID)
+	if err := productRepo.ReceiveStock(id, models.DefaultLocationID, 6, 8, testDate, "Compra", models.Lot{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := productRepo.ReturnStock(sale, 3, 5, testDate, "Devolución"); err != nil {
+		t.Fatal(err)
+	}
+	assertStock(t, db, id, 15)
+	// Lo devuelto vuelve al costo con que salió: 6 a 5, 6 a 8 y 3 a 5.
+	p, err := productRepo.GetProductByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (9*5.0 + 6*8.0) / 15; math.Abs(p.Cost-want) > 1e-9 {
+		t.Errorf("costo %g, se esperaba %g", p.Cost, want)
+	}
+}
+
+func TestGetProductByCode(t *testing.T) {
+	db := testDatabase(t)
+	productRepo := NewProductRepo(db)
+	cheese := testProduct(t, db, models.Product{Name: "Queso", Price: 50, SKU: "QUE-01", Barcode: "4006381333931"})
+	// Un SKU numérico no se confunde con el ID de otro producto.
+	bread := testProduct(t, db, models.Product{Name: "Pan", Price: 10, SKU: fmt.Sprint(cheese)})
+	soda := testProduct(t, db, models.Product{Name: "Gaseosa", Price: 20, Barcode: "0036000291452"})
+
+	tests := []struct {
+		code string
+		want int
+	}{
+		{fmt.Sprintf("#%d", cheese), cheese},
+		{fmt.Sprintf("# %d", bread), bread},
+		{"que-01", cheese},
+		{fmt.Sprint(cheese), bread},
+		{"4006381333931", cheese},
+		{"036000291452", soda},
+		{"#999", 0},
+		{"#abc", 0},
+		{"NO-EXISTE", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			p, err := productRepo.GetProductByCode(tt.code)
+			if tt.want == 0 {
+				if !errors.Is(err, sql.ErrNoRows) {
+					t.Errorf("error %v, se esperaba sql.ErrNoRows", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.ID != tt.want {
+				t.Errorf("producto %d, se esperaba %d", p.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateProductDuplicateCodes(t *testing.T) {
+	db := testDatabase(t)
+	testProduct(t, db, models.Product{Name: "Queso", SKU: "QUE-01", Barcode: "4006381333931"})
+	tests := []struct {
+		name    string
+		product models.Product
+		want    error
+	}{
+		{"SKU repetido", models.Product{Name: "Otro", SKU: "QUE-01"}, ErrDuplicateSKU},
+		{"SKU repetido en minúsculas", models.Product{Name: "Otro", SKU: "que-01"}, ErrDuplicateSKU},
+		{"código de barras repetido", models.Product{Name: "Otro", Barcode: "4006381333931"}, ErrDuplicateBarcode},
+		{"sin SKU ni código", models.Product{Name: "Otro"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.product.Date = testDate
+			_, err := NewProductRepo(db).CreateProduct(tt.product, models.Lot{})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error %v, se esperaba %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKitSaleConsumesAndReturnsComponents(t *testing.T) {
+	db := testDatabase(t)
+	productRepo, saleRepo := NewProductRepo(db), NewSaleRepo(db)
+	bread := testProduct(t, db, models.Product{Name: "Pan", Price: 10})
+	cheese := testProduct(t, db, models.Product{Name: "Queso", Price: 50})
+	kit := testProduct(t, db, models.Product{Name: "Picada", Price: 100})
+	for _, c := range []struct {
+		id   int
+		cost float64
+	}{{bread, 2}, {cheese, 20}} {
+		if err := productRepo.ReceiveStock(c.id, models.DefaultLocationID, 10, c.cost, testDate, "Compra", models.Lot{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := productRepo.SetKitComponents(kit, []models.KitComponent{{ComponentID: bread, Quantity: 2}, {ComponentID: cheese, Quantity: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Un kit que no se puede armar completo no descuenta ningún componente.
+	_, err := saleRepo.RecordSale(models.Sale{Date: testDate, LocationID: models.DefaultLocationID, ProductID: kit, Quantity: 6, Price: 100, Total: 600, Status: models.StatusPaid}, false, models.PointsRedemption{}, nil)
+	var stockErr *InsufficientStockError
+	if !errors.As(err, &stockErr) || stockErr.Product != "Pan" {
+		t.Fatalf("error %v, se esperaba stock insuficiente de Pan", err)
+	}
+	assertStock(t, db, bread, 10)
+	assertStock(t, db, cheese, 10)
+
+	sale := models.Sale{Date: testDate, LocationID: models.DefaultLocationID, ProductID: kit, Quantity: 3, Price: 100, Total: 300, Status: models.StatusPaid}
+	saleID, err := saleRepo.RecordSale(sale, false, models.PointsRedemption{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sale.ID = int(saleID)
+	recorded, err := saleRepo.GetSaleByID(sale.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 3 * (2*2.0 + 20); recorded.Cost != want {
+		t.Errorf("costo del kit %g, se esperaba %g", recorded.Cost, want)
+	}
+	assertStock(t, db, kit, 0)
+	assertStock(t, db, bread, 4)
+	assertStock(t, db, cheese, 7)
+
+	// Las devoluciones nunca reingresan más de lo que salió de cada componente.
+	steps := []struct {
+		returned              float64
+		wantBread, wantCheese float64
+		leftBread, leftCheese float64
+	}{
+		{1, 6, 8, 4, 2},
+		{1.5, 9, 9.5, 1, 0.5},
+		{5, 10, 10, 0, 0},
+	}
+	for _, step := range steps {
+		if err := productRepo.ReturnStock(sale, step.returned, 0, testDate, "Devolución"); err != nil {
+			t.Fatal(err)
+		}
+		assertStock(t, db, bread, step.wantBread)
+		assertStock(t, db, cheese, step.wantCheese)
+		components, err := productRepo.GetSaleComponents(sale.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		left := map[int]float64{}
+		for _, c := range components {
+			left[c.ComponentID] = c.Quantity
+		}
+		if math.Abs(left[bread]-step.leftBread) > 1e-9 || math.Abs(left[cheese]-step.leftCheese) > 1e-9 {
+			t.Errorf("tras devolver %g quedan %g de pan y %g de queso vendidos, se esperaba %g y %g", step.returned, left[bread], left[cheese], step.leftBread, step.leftCheese)
+		}
+	}
+	assertStock(t, db, kit, 0)
+}
+
+func TestUpdateProductUnitChange(t *testing.T) {
+	tests := []struct {
+		name    string
+		stock   float64
+		unit    string
+		wantErr error
+	}{
+		{"sin stock", 0, models.UnitKilogram, nil},
+		{"con stock", 2.5, models.UnitKilogram, ErrUnitChangeWithStock},
+		{"con stock y la misma unidad", 2.5, models.UnitPiece, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDatabase(t)
+			productRepo := NewProductRepo(db)
+			id := testProduct(t, db, models.Product{Name: "Queso", Price: 50, Decimals: 3})
+			if tt.stock > 0 {
+				if err := productRepo.ReceiveStock(id, models.DefaultLocationID, tt.stock, 5, testDate, "Compra", models.Lot{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p, err := productRepo.GetProductByID(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.Unit = tt.unit
+			if err := productRepo.UpdateProduct(*p); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error %v, se esperaba %v", err, tt.wantErr)
+			}
+		})
+	}
+}
\ No newline at end of file
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"sales-system/internal/models"
+	"time"
+)
+
+type PurchaseOrderRepo struct {
+	db *sql.DB
+}
+
+func NewPurchaseOrderRepo(db *sql.DB) *PurchaseOrderRepo {
+	return &PurchaseOrderRepo{db: db}
+}
+
+// CreatePurchaseOrder guarda la orden de compra junto con sus líneas.
+func (r *PurchaseOrderRepo) CreatePurchaseOrder(o models.PurchaseOrder) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO purchase_orders (date, supplier, status) VALUES (?, ?, ?)", o.Date.Format(time.RFC3339), o.Supplier, o.Status)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range o.Items {
+		_, err := tx.Exec("INSERT INTO purchase_order_items (order_id, product_id, quantity, unit_cost) VALUES (?, ?, ?, ?)", id, item.ProductID, item.Quantity, item.UnitCost)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+func (r *PurchaseOrderRepo) GetPurchaseOrderByID(id int) (*models.PurchaseOrder, error) {
+	var o models.PurchaseOrder
+	var dateStr string
+	err := r.db.QueryRow("SELECT id, date, supplier, status FROM purchase_orders WHERE id = ?", id).Scan(&o.ID, &dateStr, &o.Supplier, &o.Status)
+	if err != nil {
+		return nil, err
+	}
+	o.Date, _ = time.Parse(time.RFC3339, dateStr)
+
+	rows, err := r.db.Query("SELECT id, order_id, product_id, quantity, unit_cost FROM purchase_order_items WHERE order_id = ? ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.PurchaseOrderItem
+		if err := rows.Scan(&item.ID, &item.OrderID, &item.ProductID, &item.Quantity, &item.UnitCost); err != nil {
+			return nil, err
+		}
+		o.Items = append(o.Items, item)
+	}
+	return &o, nil
+}
+
+// GetAllPurchaseOrders devuelve las órdenes de compra sin sus líneas.
+func (r *PurchaseOrderRepo) GetAllPurchaseOrders() ([]models.PurchaseOrder, error) {
+	rows, err := r.db.Query("SELECT id, date, supplier, status FROM purchase_orders ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []models.PurchaseOrder
+	for rows.Next() {
+		var o models.PurchaseOrder
+		var dateStr string
+		if err := rows.Scan(&o.ID, &dateStr, &o.Supplier, &o.Status); err != nil {
+			return nil, err
+		}
+		o.Date, _ = time.Parse(time.RFC3339, dateStr)
+		orders = append(orders, o)
+	}
+	return orders, nil
+}
+
+// ReceivePurchaseOrder ingresa al stock de la ubicación todas las líneas de la
+// orden y la marca como recibida. lots indica, por ID de línea, el lote y
+// vencimiento de los productos con control de lotes.
+func (r *PurchaseOrderRepo) ReceivePurchaseOrder(id, locationID int, lots map[int]models.Lot, date time.Time) error {
+	o, err := r.GetPurchaseOrderByID(id)
+	if err != nil {
+		return err
+	}
+	if o.Status == models.PurchaseReceived {
+		return fmt.Errorf("la orden de compra #%d ya fue recibida", id)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	reference := fmt.Sprintf("Orden de compra #%d", id)
+	for _, item := range o.Items {
+		if err := receiveStock(tx, item.ProductID, locationID, item.Quantity, item.UnitCost, date, models.MovementReceipt, reference); err != nil {
+			return err
+		}
+		if lot, ok := lots[item.ID]; ok && lot.Number != "" {
+			if err := addLot(tx, item.ProductID, locationID, lot.Number, lot.Expiry, item.Quantity); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := tx.Exec("UPDATE purchase_orders SET status = ? WHERE id = ?", models.PurchaseReceived, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
\ No newline at end of file
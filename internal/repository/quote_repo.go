@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"database/sql"
+	"sales-system/internal/models"
+	"time"
+)
+
+type QuoteRepo struct {
+	db *sql.DB
+}
+
+func NewQuoteRepo(db *sql.DB) *QuoteRepo {
+	return &QuoteRepo{db: db}
+}
+
+const quoteColumns = "id, date, client, valid_until, status, location_id"
+
+const quoteLineColumns = "id, quote_id, product_id, product_name, quantity, price, discount, sale_id"
+
+func scanQuote(row scanner) (*models.Quote, error) {
+	var q models.Quote
+	var dateStr, validStr string
+	if err := row.Scan(&q.ID, &dateStr, &q.Client, &validStr, &q.Status, &q.LocationID); err != nil {
+		return nil, err
+	}
+	q.Date, _ = time.Parse(time.RFC3339, dateStr)
+	q.ValidUntil, _ = time.Parse(effectiveDateLayout, validStr)
+	return &q, nil
+}
+
+func scanQuoteLines(rows *sql.Rows) ([]models.QuoteLine, error) {
+	defer rows.Close()
+
+	var lines []models.QuoteLine
+	for rows.Next() {
+		var l models.QuoteLine
+		if err := rows.Scan(&l.ID, &l.QuoteID, &l.ProductID, &l.ProductName, &l.Quantity, &l.Price, &l.Discount, &l.SaleID); err != nil {
+			return nil, err
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+// CreateQuote guarda el presupuesto junto con sus líneas.
+func (r *QuoteRepo) CreateQuote(q models.Quote) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO quotes (date, client, valid_until, status, location_id) VALUES (?, ?, ?, ?, ?)", q.Date.Format(time.RFC3339), q.Client, q.ValidUntil.Format(effectiveDateLayout), q.Status, q.LocationID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, l := range q.Lines {
+		_, err := tx.Exec("INSERT INTO quote_lines (quote_id, product_id, product_name, quantity, price, discount) VALUES (?, ?, ?, ?, ?, ?)", id, l.ProductID, l.ProductName, l.Quantity, l.Price, l.Discount)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+func (r *QuoteRepo) GetQuoteByID(id int) (*models.Quote, error) {
+	q, err := scanQuote(r.db.QueryRow("SELECT "+quoteColumns+" FROM quotes WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query("SELECT "+quoteLineColumns+" FROM quote_lines WHERE quote_id = ? ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	q.Lines, err = scanQuoteLines(rows)
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// GetAllQuotes devuelve los presupuestos con sus líneas, del más reciente al
+// más antiguo.
+func (r *QuoteRepo) GetAllQuotes() ([]models.Quote, error) {
+	rows, err := r.db.Query("SELECT " + quoteColumns + " FROM quotes ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var quotes []models.Quote
+	index := make(map[int]int)
+	for rows.Next() {
+		q, err := scanQuote(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[q.ID] = len(quotes)
+		quotes = append(quotes, *q)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lineRows, err := r.db.Query("SELECT " + quoteLineColumns + " FROM quote_lines ORDER BY quote_id, id")
+	if err != nil {
+		return nil, err
+	}
+	lines, err := scanQuoteLines(lineRows)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lines {
+		if i, ok := index[l.QuoteID]; ok {
+			quotes[i].Lines = append(quotes[i].Lines, l)
+		}
+	}
+	return quotes, nil
+}
+
+func (r *QuoteRepo) UpdateQuoteStatus(id int, status string) error {
+	_, err := r.db.Exec("UPDATE quotes SET status = ? WHERE id = ?", status, id)
+	return err
+}
+
+// SetQuoteLineSale registra la venta en que se convirtió una línea del presupuesto.
+func (r *QuoteRepo) SetQuoteLineSale(lineID int, saleID int64) error {
+	_, err := r.db.Exec("UPDATE quote_lines SET sale_id = ? WHERE id = ?", saleID, lineID)
+	return err
+}
+
+// ExpireQuotes marca como vencidos los presupuestos abiertos cuya fecha de
+// validez ya pasó y devuelve cuántos se vencieron.
+func (r *QuoteRepo) ExpireQuotes(now time.Time) (int64, error) {
+	res, err := r.db.Exec("UPDATE quotes SET status = ? WHERE status IN (?, ?) AND valid_until < ?", models.QuoteExpired, models.QuoteDraft, models.QuoteSent, now.Format(effectiveDateLayout))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
\ No newline at end of file
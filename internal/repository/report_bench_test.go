@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sales-system/internal/database"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Los benchmarks de reportes trabajan sobre una base generada con un millón
+// de ventas repartidas en tres años. La cantidad se puede cambiar con la
+// variable de entorno BENCH_SALES para pruebas rápidas:
+//
+//	BENCH_SALES=50000 go test -run '^$' -bench Report ./internal/repository
+
+const benchProducts = 500
+
+var (
+	benchOnce  sync.Once
+	benchDir   string
+	benchDB    *sql.DB
+	benchErr   error
+	benchStart = time.Date(2022, 1, 1, 0, 0, 0, 0, time.Local)
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if benchDB != nil {
+		benchDB.Close()
+	}
+	if benchDir != "" {
+		os.RemoveAll(benchDir)
+	}
+	os.Exit(code)
+}
+
+// benchDatabase genera la base de los benchmarks la primera vez que se pide.
+func benchDatabase(b *testing.B) *sql.DB {
+	b.Helper()
+	benchOnce.Do(func() {
+		sales := 1000000
+		if n, err := strconv.Atoi(os.Getenv("BENCH_SALES")); err == nil && n > 0 {
+			sales = n
+		}
+		benchDir, benchErr = os.MkdirTemp("", "sales-bench")
+		if benchErr != nil {
+			return
+		}
+		database.InitDB(filepath.Join(benchDir, "bench.db"))
+		benchDB = database.DB
+		benchErr = generateSales(benchDB, sales)
+	})
+	if benchErr != nil {
+		b.Fatal(benchErr)
+	}
+	return benchDB
+}
+
+// generateSales carga productos (uno de cada cinco es variante de otro) y
+// ventas con fechas al azar dentro de los tres años desde benchStart.
+func generateSales(db *sql.DB, sales int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := 1; i <= benchProducts; i++ {
+		parentID := 0
+		if i%5 == 0 {
+			parentID = i - 1
+		}
+		_, err := tx.Exec("INSERT INTO products (id, date, name, quantity, price, brand, category_id, parent_id) VALUES (?, ?, ?, 0, ?, ?, 0, ?)",
+			i, benchStart.Format(time.RFC3339), fmt.Sprintf("Producto %d", i), float64(i%50+1), fmt.Sprintf("Marca %d", i%20), parentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO sales (date, client, product_id, quantity, price, total, status, cost, location_id, product_name, product_sku) VALUES (?, ?, ?, ?, ?, ?, 'Pagado', ?, 1, ?, '')")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	rnd := rand.New(rand.NewSource(1))
+	span := int64(benchStart.AddDate(3, 0, 0).Sub(benchStart) / time.Second)
+	for i := 0; i < sales; i++ {
+		date := benchStart.Add(time.Duration(rnd.Int63n(span)) * time.Second)
+		productID := rnd.Intn(benchProducts) + 1
+		quantity := float64(rnd.Intn(5) + 1)
+		price := float64(productID%50 + 1)
+		_, err := stmt.Exec(date.Format(time.RFC3339), fmt.Sprintf("Cliente %d", rnd.Intn(2000)), productID, quantity, price, price*quantity, price*quantity*0.6, fmt.Sprintf("Producto %d", productID))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// benchMonth es el último mes completo de la base generada.
+func benchMonth() (time.Time, time.Time) {
+	start := benchStart.AddDate(2, 11, 0)
+	return start, start.AddDate(0, 1, 0).Add(-time.Second)
+}
+
+// BenchmarkReportPerSaleLookup es como se armaba el reporte antes: todas las
+// ventas del mes en memoria y una consulta por venta para su producto (y otra
+// para el padre de las variantes).
+func BenchmarkReportPerSaleLookup(b *testing.B) {
+	db := benchDatabase(b)
+	saleRepo, productRepo := NewSaleRepo(db), NewProductRepo(db)
+	start, end := benchMonth()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sales, err := saleRepo.GetSalesByDateRange(start, end)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var total float64
+		for _, s := range sales {
+			product, err := productRepo.GetProductByID(s.ProductID)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if product.IsVariant() {
+				if _, err := productRepo.GetProductByID(product.ParentID); err != nil {
+					b.Fatal(err)
+				}
+			}
+			total += s.Total
+		}
+		if len(sales) == 0 {
+			b.Fatal("no hay ventas en el mes")
+		}
+	}
+}
+
+// BenchmarkReportJoined recorre las mismas ventas con sus productos en una
+// sola consulta.
+func BenchmarkReportJoined(b *testing.B) {
+	db := benchDatabase(b)
+	saleRepo := NewSaleRepo(db)
+	start, end := benchMonth()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		count := 0
+		var total float64
+		err := saleRepo.EachReportSale(start, end, false, func(row ReportRow) error {
+			count++
+			total += row.Total
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if count == 0 {
+			b.Fatal("no hay ventas en el mes")
+		}
+	}
+}
+
+// BenchmarkSalesByDateScan y BenchmarkSalesByDateIndex comparan la búsqueda
+// por fecha recorriendo toda la tabla y usando idx_sales_date.
+func BenchmarkSalesByDateScan(b *testing.B) {
+	benchSalesByDate(b, "SELECT COUNT(*), COALESCE(SUM(total), 0) FROM sales NOT INDEXED WHERE date BETWEEN ? AND ?")
+}
+
+func BenchmarkSalesByDateIndex(b *testing.B) {
+	benchSalesByDate(b, "SELECT COUNT(*), COALESCE(SUM(total), 0) FROM sales WHERE date BETWEEN ? AND ?")
+}
+
+func benchSalesByDate(b *testing.B, query string) {
+	db := benchDatabase(b)
+	start, end := benchMonth()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var count int
+		var total float64
+		if err := db.QueryRow(query, start.Format(time.RFC3339), end.Format(time.RFC3339)).Scan(&count, &total); err != nil {
+			b.Fatal(err)
+		}
+		if count == 0 {
+			b.Fatal("no hay ventas en el mes")
+		}
+	}
+}
\ No newline at end of file
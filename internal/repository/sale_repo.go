@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
 	"sales-system/internal/models"
 	"time"
 )
@@ -14,73 +15,419 @@ func NewSaleRepo(db *sql.DB) *SaleRepo {
 	return &SaleRepo{db: db}
 }
 
+const saleColumns = "id, date, client, product_id, quantity, price, total, status, cost, location_id, product_name, product_sku, currency, currency_amount, exchange_rate, seller_id"
+
+func scanSale(row scanner) (*models.Sale, error) {
+	var s models.Sale
+	var dateStr string
+	if err := row.Scan(&s.ID, &dateStr, &s.Client, &s.ProductID, &s.Quantity, &s.Price, &s.Total, &s.Status, &s.Cost, &s.LocationID, &s.ProductName, &s.ProductSKU, &s.Currency, &s.CurrencyAmount, &s.ExchangeRate, &s.SellerID); err != nil {
+		return nil, err
+	}
+	s.Date, _ = time.Parse(time.RFC3339, dateStr)
+	return &s, nil
+}
+
+func scanSales(rows *sql.Rows) ([]models.Sale, error) {
+	defer rows.Close()
+
+	var sales []models.Sale
+	for rows.Next() {
+		s, err := scanSale(rows)
+		if err != nil {
+			return nil, err
+		}
+		sales = append(sales, *s)
+	}
+	return sales, nil
+}
+
+// CreateSale registra la venta junto con el nombre y el SKU que tiene el
+// producto en ese momento.
 func (r *SaleRepo) CreateSale(s models.Sale) (int64, error) {
-	res, err := r.db.Exec("INSERT INTO sales (date, client, product_id, quantity, price, total, status) VALUES (?, ?, ?, ?, ?, ?, ?)", s.Date.Format(time.RFC3339), s.Client, s.ProductID, s.Quantity, s.Price, s.Total, s.Status)
+	return insertSale(r.db, s)
+}
+
+// RecordSale registra la venta, descuenta el stock (y sus lotes), congela en
+// ella el costo de lo vendido y descuenta los puntos canjeados y lo pagado
+// con tarjetas, si los hay, en una sola transacción. Si la ubicación no tiene
+// stock suficiente no se registra nada y se devuelve un *InsufficientStockError.
+func (r *SaleRepo) RecordSale(s models.Sale, includeExpired bool, redemption models.PointsRedemption, cards []models.GiftCardPayment) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id, err := recordSale(tx, s, includeExpired, redemption, cards)
 	if err != nil {
 		return 0, err
 	}
-	id, err := res.LastInsertId()
-	return id, err
+	return id, tx.Commit()
+}
+
+func recordSale(tx *sql.Tx, s models.Sale, includeExpired bool, redemption models.PointsRedemption, cards []models.GiftCardPayment) (int64, error) {
+	id, err := insertSale(tx, s)
+	if err != nil {
+		return 0, err
+	}
+	s.ID = int(id)
+	cost, err := consumeSale(tx, s, s.Quantity, fmt.Sprintf("Venta #%d", id), includeExpired)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("UPDATE sales SET cost = ? WHERE id = ?", cost, id); err != nil {
+		return 0, err
+	}
+	if redemption.Points > 0 {
+		if err := redeemPoints(tx, redemption.CustomerID, int(id), redemption.Points, s.Date); err != nil {
+			return 0, fmt.Errorf("no se pudieron canjear los puntos: %w", err)
+		}
+	}
+	for _, p := range cards {
+		if err := redeemCard(tx, p.CardID, int(id), p.Amount, s.Date); err != nil {
+			return 0, fmt.Errorf("no se pudo cobrar con la tarjeta %s: %w", p.Code, err)
+		}
+	}
+	return id, nil
+}
+
+type execQueryRower interface {
+	execer
+	queryRower
+}
+
+func insertSale(q execQueryRower, s models.Sale) (int64, error) {
+	if s.LocationID == 0 {
+		s.LocationID = models.DefaultLocationID
+	}
+	if s.ProductName == "" {
+		err := q.QueryRow("SELECT name, sku FROM products WHERE id = ?", s.ProductID).Scan(&s.ProductName, &s.ProductSKU)
+		if err != nil {
+			return 0, err
+		}
+	}
+	res, err := q.Exec("INSERT INTO sales (date, client, product_id, quantity, price, total, status, cost, location_id, product_name, product_sku, currency, currency_amount, exchange_rate, seller_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		s.Date.Format(time.RFC3339), s.Client, s.ProductID, s.Quantity, s.Price, s.Total, s.Status, s.Cost, s.LocationID, s.ProductName, s.ProductSKU, s.Currency, s.CurrencyAmount, s.ExchangeRate, s.SellerID)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
 }
 
 func (r *SaleRepo) GetSaleByID(id int) (*models.Sale, error) {
-	row := r.db.QueryRow("SELECT id, date, client, product_id, quantity, price, total, status FROM sales WHERE id = ?", id)
-	var s models.Sale
-	var dateStr string
-	err := row.Scan(&s.ID, &dateStr, &s.Client, &s.ProductID, &s.Quantity, &s.Price, &s.Total, &s.Status)
+	row := r.db.QueryRow("SELECT "+saleColumns+" FROM sales WHERE id = ?", id)
+	return scanSale(row)
+}
+
+func (r *SaleRepo) GetAllSales() ([]models.Sale, error) {
+	rows, err := r.db.Query("SELECT " + saleColumns + " FROM sales ORDER BY id DESC")
 	if err != nil {
 		return nil, err
 	}
-	s.Date, _ = time.Parse(time.RFC3339, dateStr)
-	return &s, nil
+	return scanSales(rows)
 }
 
-func (r *SaleRepo) GetAllSales() ([]models.Sale, error) {
-	rows, err := r.db.Query("SELECT id, date, client, product_id, quantity, price, total, status FROM sales ORDER BY id DESC")
+func (r *SaleRepo) UpdateSale(s models.Sale) error {
+	return updateSale(r.db, s)
+}
+
+func updateSale(e execer, s models.Sale) error {
+	_, err := e.Exec("UPDATE sales SET date = ?, client = ?, quantity = ?, price = ?, total = ?, status = ?, cost = ?, currency_amount = ?, seller_id = ? WHERE id = ?", s.Date.Format(time.RFC3339), s.Client, s.Quantity, s.Price, s.Total, s.Status, s.Cost, s.CurrencyAmount, s.SellerID, s.ID)
+	return err
+}
+
+// EditSale guarda los cambios de una venta en una sola transacción. Si la
+// cantidad cambió respecto de oldQuantity, descuenta o reingresa la
+// diferencia y ajusta el costo de lo vendido; además anula los puntos de la
+// venta según points. Devuelve los puntos anulados y los reintegrados.
+func (r *SaleRepo) EditSale(s models.Sale, oldQuantity float64, includeExpired bool, points models.PointsReversal, now time.Time) (int, int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	reference := fmt.Sprintf("Venta #%d (edición)", s.ID)
+	if delta := s.Quantity - oldQuantity; delta > 0 {
+		extraCost, err := consumeSale(tx, s, delta, reference, includeExpired)
+		if err != nil {
+			return 0, 0, err
+		}
+		s.Cost += extraCost
+	} else if delta < 0 && oldQuantity > 0 {
+		unitCost := s.Cost / oldQuantity
+		if err := returnSale(tx, s, -delta, unitCost, s.Date, reference); err != nil {
+			return 0, 0, err
+		}
+		s.Cost = unitCost * s.Quantity
+	}
+	if err := updateSale(tx, s); err != nil {
+		return 0, 0, err
+	}
+	reversed, restored, err := reverseSalePoints(tx, s.ID, points, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	return reversed, restored, tx.Commit()
+}
+
+// DeleteSale elimina una venta en una sola transacción: reingresa su
+// mercadería al stock y a sus lotes, anula los puntos que ganó, reintegra
+// los que se canjearon en ella y devuelve a las tarjetas de regalo y
+// créditos en tienda lo que se cobró con ellos. Devuelve los puntos anulados
+// y los reintegrados y lo devuelto a cada tarjeta.
+func (r *SaleRepo) DeleteSale(s models.Sale, now time.Time) (int, int, []models.GiftCardPayment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	defer tx.Rollback()
+
+	if s.Quantity > 0 {
+		if err := returnSale(tx, s, s.Quantity, s.Cost/s.Quantity, now, fmt.Sprintf("Venta #%d eliminada", s.ID)); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+	if err := deleteSale(tx, s.ID); err != nil {
+		return 0, 0, nil, err
+	}
+	reversed, restored, err := reverseSalePoints(tx, s.ID, models.PointsReversal{Fraction: 1, RestoreRedeemed: true}, now)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	cards, err := voidCardRedemptions(tx, s.ID, now)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return reversed, restored, cards, tx.Commit()
+}
+
+// ReturnSale registra en una sola transacción la devolución de quantity
+// unidades de una venta. s es la venta ya descontada de lo devuelto: se
+// reingresa la mercadería al costo unitario con que salió, se guarda la
+// venta, se anula la parte de los puntos ganados y se reintegra la de los
+// canjeados y, si la venta estaba pagada, se entrega refund como crédito en
+// tienda. Devuelve el crédito emitido (nil si la venta no estaba pagada) y
+// los puntos anulados y los reintegrados.
+func (r *SaleRepo) ReturnSale(s models.Sale, quantity, unitCost, refund float64, now time.Time) (*models.GiftCard, int, int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer tx.Rollback()
+
+	if err := returnSale(tx, s, quantity, unitCost, now, fmt.Sprintf("Devolución venta #%d", s.ID)); err != nil {
+		return nil, 0, 0, err
+	}
+	if err := updateSale(tx, s); err != nil {
+		return nil, 0, 0, err
+	}
+	points := models.PointsReversal{Fraction: quantity / (s.Quantity + quantity), RestoreRedeemed: true}
+	reversed, restored, err := reverseSalePoints(tx, s.ID, points, now)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var card *models.GiftCard
+	if s.Status == models.StatusPaid {
+		card, err = createCard(tx, models.GiftCard{Kind: models.GiftCardKindCredit, Client: s.Client, IssuedAt: now})
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		movement := models.GiftCardMovement{CardID: card.ID, Date: now, Amount: refund, Type: models.GiftCardReturn, SaleID: s.ID}
+		if err := addCardMovement(tx, movement); err != nil {
+			return nil, 0, 0, err
+		}
+		card.Balance = refund
+	}
+	return card, reversed, restored, tx.Commit()
+}
+
+// deleteSale borra la venta junto con sus lotes y componentes y la quita de
+// su envío. El envío se borra solo si no le quedan otras ventas del ticket.
+func deleteSale(e execer, id int) error {
+	for _, query := range []string{
+		"DELETE FROM sales WHERE id = ?",
+		"DELETE FROM sale_lots WHERE sale_id = ?",
+		"DELETE FROM sale_components WHERE sale_id = ?",
+		"DELETE FROM shipments WHERE id IN (SELECT shipment_id FROM shipment_sales GROUP BY shipment_id HAVING COUNT(*) = 1 AND MAX(sale_id) = ?)",
+		"DELETE FROM shipment_sales WHERE sale_id = ?",
+	} {
+		if _, err := e.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetQuantitySoldByProduct devuelve las unidades vendidas de cada producto en el rango de fechas.
+func (r *SaleRepo) GetQuantitySoldByProduct(start, end time.Time) (map[int]float64, error) {
+	rows, err := r.db.Query("SELECT product_id, SUM(quantity) FROM sales WHERE date BETWEEN ? AND ? GROUP BY product_id", start.Format(time.RFC3339), end.Format(time.RFC3339))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var sales []models.Sale
+	sold := make(map[int]float64)
 	for rows.Next() {
-		var s models.Sale
-		var dateStr string
-		if err := rows.Scan(&s.ID, &dateStr, &s.Client, &s.ProductID, &s.Quantity, &s.Price, &s.Total, &s.Status); err != nil {
+		var productID int
+		var quantity float64
+		if err := rows.Scan(&productID, &quantity); err != nil {
 			return nil, err
 		}
-		s.Date, _ = time.Parse(time.RFC3339, dateStr)
-		sales = append(sales, s)
+		sold[productID] = quantity
 	}
-	return sales, nil
+	return sold, nil
 }
 
-func (r *SaleRepo) UpdateSale(s models.Sale) error {
-	_, err := r.db.Exec("UPDATE sales SET date = ?, client = ?, quantity = ?, price = ?, total = ?, status = ? WHERE id = ?", s.Date.Format(time.RFC3339), s.Client, s.Quantity, s.Price, s.Total, s.Status, s.ID)
-	return err
+// GetSalesByClient devuelve las ventas de un cliente, de la más reciente a la
+// más antigua. El nombre se compara sin distinguir mayúsculas.
+func (r *SaleRepo) GetSalesByClient(client string) ([]models.Sale, error) {
+	rows, err := r.db.Query("SELECT "+saleColumns+" FROM sales WHERE client = ? COLLATE NOCASE ORDER BY date DESC, id DESC", client)
+	if err != nil {
+		return nil, err
+	}
+	return scanSales(rows)
 }
 
-func (r *SaleRepo) DeleteSale(id int) error {
-	_, err := r.db.Exec("DELETE FROM sales WHERE id = ?", id)
-	return err
+// GetCustomerActivity resume las compras de cada cliente con nombre. Las
+// ventas apartadas no cuentan hasta que se terminan de pagar, y las tarjetas
+// de regalo cuentan cuando se consumen, no cuando se compran.
+func (r *SaleRepo) GetCustomerActivity() ([]models.CustomerActivity, error) {
+	rows, err := r.db.Query(`SELECT client, MIN(date), MAX(date), COUNT(DISTINCT substr(date, 1, 10)), SUM(total)
+		FROM sales
+		WHERE TRIM(client) <> '' AND status <> ? AND product_id <> 0
+		GROUP BY client COLLATE NOCASE
+		ORDER BY client`, models.StatusLayaway)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activity []models.CustomerActivity
+	for rows.Next() {
+		var a models.CustomerActivity
+		var firstStr, lastStr string
+		if err := rows.Scan(&a.Client, &firstStr, &lastStr, &a.Orders, &a.Total); err != nil {
+			return nil, err
+		}
+		a.FirstPurchase, _ = time.Parse(time.RFC3339, firstStr)
+		a.LastPurchase, _ = time.Parse(time.RFC3339, lastStr)
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
 }
 
-func (r *SaleRepo) GetSalesByDateRange(start, end time.Time) ([]models.Sale, error) {
-	rows, err := r.db.Query("SELECT id, date, client, product_id, quantity, price, total, status FROM sales WHERE date BETWEEN ? AND ?", start.Format(time.RFC3339), end.Format(time.RFC3339))
+// ReportRow es una venta con los datos de su producto que usan los reportes,
+// obtenidos en la misma consulta. ProductFound es falso si el producto ya no
+// existe; los campos Parent solo se completan para las variantes.
+type ReportRow struct {
+	models.Sale
+	ProductFound     bool
+	Name             string
+	Brand            string
+	CategoryID       int
+	ParentID         int
+	ParentName       string
+	ParentBrand      string
+	ParentCategoryID int
+}
+
+const reportProductColumns = "p.id IS NOT NULL, COALESCE(p.name, ''), COALESCE(p.brand, ''), COALESCE(p.category_id, 0), COALESCE(pp.id, 0), COALESCE(pp.name, ''), COALESCE(pp.brand, ''), COALESCE(pp.category_id, 0)"
+
+// reportSalesQuery devuelve las ventas del período con su producto y, si es
+// una variante, su producto padre. La venta de tarjetas de regalo no es
+// ingreso sino una deuda con el cliente, por lo que no se incluye. Como en
+// las comisiones, los apartados sin terminar de pagar tampoco (un apartado
+// cancelado no fue una venta) y los completados cuentan en la fecha en que
+// se terminaron de pagar.
+const reportSalesQuery = `SELECT s.id, ` + creditedDate + `, s.client, s.product_id, s.quantity, s.price, s.total, s.status, s.cost, s.location_id, s.product_name, s.product_sku, ` + reportProductColumns + `
+	FROM sales s
+	LEFT JOIN products p ON p.id = s.product_id
+	LEFT JOIN products pp ON pp.id = p.parent_id AND p.parent_id <> 0
+	LEFT JOIN layaways l ON l.sale_id = s.id AND l.status = ?
+	WHERE s.product_id <> 0 AND s.status <> ? AND ` + creditedDate + ` BETWEEN ? AND ?`
+
+// reportKitComponentsQuery devuelve una fila por componente de cada venta de
+// un kit del período. El importe del kit se reparte según el precio de lista
+// de los componentes al momento de la venta (o según la cantidad, si no
+// tenían precio) y el costo es el de lo que salió de cada uno. Los apartados
+// se tratan igual que en reportSalesQuery.
+const reportKitComponentsQuery = `SELECT s.id, ` + creditedDate + `, s.client, sc.component_id, sc.quantity, sc.price,
+		s.total * CASE WHEN SUM(sc.price * sc.quantity) OVER w > 0
+			THEN sc.price * sc.quantity / SUM(sc.price * sc.quantity) OVER w
+			ELSE sc.quantity / SUM(sc.quantity) OVER w END,
+		s.status, sc.cost, s.location_id, COALESCE(p.name, 'Producto #' || sc.component_id), COALESCE(p.sku, ''), ` + reportProductColumns + `
+	FROM sale_components sc
+	JOIN sales s ON s.id = sc.sale_id
+	LEFT JOIN products p ON p.id = sc.component_id
+	LEFT JOIN products pp ON pp.id = p.parent_id AND p.parent_id <> 0
+	LEFT JOIN layaways l ON l.sale_id = s.id AND l.status = ?
+	WHERE s.status <> ? AND ` + creditedDate + ` BETWEEN ? AND ? AND sc.quantity > 1e-9
+	WINDOW w AS (PARTITION BY sc.sale_id)`
+
+// EachReportSale recorre las ventas del período en orden de fecha y llama a
+// fn con cada una, sin cargarlas todas en memoria. Con splitKits, las ventas
+// de kits se reemplazan por una fila por componente. Si fn devuelve un error
+// el recorrido se detiene y se devuelve ese error.
+func (r *SaleRepo) EachReportSale(start, end time.Time, splitKits bool, fn func(ReportRow) error) error {
+	from, to := start.Format(time.RFC3339), end.Format(time.RFC3339)
+	query := reportSalesQuery + " ORDER BY 2, 1"
+	args := []any{models.LayawayCompleted, models.StatusLayaway, from, to}
+	if splitKits {
+		query = reportSalesQuery + " AND NOT EXISTS (SELECT 1 FROM sale_components sc WHERE sc.sale_id = s.id AND sc.quantity > 1e-9)" +
+			" UNION ALL " + reportKitComponentsQuery + " ORDER BY 2, 1"
+		args = append(args, models.LayawayCompleted, models.StatusLayaway, from, to)
+	}
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var sales []models.Sale
 	for rows.Next() {
-		var s models.Sale
+		var row ReportRow
 		var dateStr string
-		if err := rows.Scan(&s.ID, &dateStr, &s.Client, &s.ProductID, &s.Quantity, &s.Price, &s.Total, &s.Status); err != nil {
+		err := rows.Scan(&row.ID, &dateStr, &row.Client, &row.ProductID, &row.Quantity, &row.Price, &row.Total, &row.Status, &row.Cost, &row.LocationID, &row.ProductName, &row.ProductSKU,
+			&row.ProductFound, &row.Name, &row.Brand, &row.CategoryID, &row.ParentID, &row.ParentName, &row.ParentBrand, &row.ParentCategoryID)
+		if err != nil {
+			return err
+		}
+		row.Date, _ = time.Parse(time.RFC3339, dateStr)
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetForeignCurrencyTotals devuelve lo cobrado en cada moneda extranjera
+// por las ventas pagadas del período, con su equivalente en moneda local.
+func (r *SaleRepo) GetForeignCurrencyTotals(start, end time.Time) ([]models.CurrencyTotal, error) {
+	rows, err := r.db.Query(`SELECT currency, SUM(currency_amount), SUM(currency_amount * exchange_rate)
+		FROM sales WHERE currency <> '' AND status = ? AND date BETWEEN ? AND ?
+		GROUP BY currency ORDER BY currency`, models.StatusPaid, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []models.CurrencyTotal
+	for rows.Next() {
+		var t models.CurrencyTotal
+		if err := rows.Scan(&t.Currency, &t.Amount, &t.BaseAmount); err != nil {
 			return nil, err
 		}
-		s.Date, _ = time.Parse(time.RFC3339, dateStr)
-		sales = append(sales, s)
+		totals = append(totals, t)
 	}
-	return sales, nil
+	return totals, rows.Err()
+}
+
+func (r *SaleRepo) GetSalesByDateRange(start, end time.Time) ([]models.Sale, error) {
+	rows, err := r.db.Query("SELECT "+saleColumns+" FROM sales WHERE date BETWEEN ? AND ?", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	return scanSales(rows)
 }
\ No newline at end of file
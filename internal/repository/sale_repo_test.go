@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"sales-system/internal/models"
+	"testing"
+)
+
+// testSale registra la venta de quantity unidades de un producto con 10
+// unidades a costo 5 y devuelve la venta.
+func testSale(t *testing.T, db *sql.DB, quantity float64, redemption models.PointsRedemption) (int, models.Sale) {
+	t.Helper()
+	id := testProduct(t, db, models.Product{Name: "Queso", Price: 50})
+	if err := NewProductRepo(db).ReceiveStock(id, models.DefaultLocationID, 10, 5, testDate, "Compra", models.Lot{}); err != nil {
+		t.Fatal(err)
+	}
+	sale := models.Sale{Date: testDate, LocationID: models.DefaultLocationID, Client: "Cliente", ProductID: id, Quantity: quantity, Price: 50, Total: 50 * quantity, Status: models.StatusPaid}
+	saleID, err := NewSaleRepo(db).RecordSale(sale, false, redemption, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := NewSaleRepo(db).GetSaleByID(int(saleID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id, *recorded
+}
+
+func TestEditSaleAdjustsStockAndCost(t *testing.T) {
+	tests := []struct {
+		name      string
+		quantity  float64
+		wantCost  float64
+		wantStock float64
+		wantErr   bool
+	}{
+		{"más cantidad", 6, 30, 4, false},
+		{"menos cantidad", 1, 5, 9, false},
+		{"sin cambios", 4, 20, 6, false},
+		{"cantidad cero", 0, 0, 10, false},
+		{"stock insuficiente", 15, 20, 6, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDatabase(t)
+			saleRepo := NewSaleRepo(db)
+			productID, sale := testSale(t, db, 4, models.PointsRedemption{})
+
+			edited := sale
+			edited.Quantity, edited.Total = tt.quantity, 50*tt.quantity
+			_, _, err := saleRepo.EditSale(edited, sale.Quantity, false, models.PointsReversal{}, testDate)
+			if tt.wantErr {
+				var stockErr *InsufficientStockError
+				if !errors.As(err, &stockErr) {
+					t.Fatalf("error %v, se esperaba *InsufficientStockError", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			// Si falla el stock, la venta queda como estaba.
+			got, err := saleRepo.GetSaleByID(sale.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantQuantity := tt.quantity
+			if tt.wantErr {
+				wantQuantity = sale.Quantity
+			}
+			if got.Quantity != wantQuantity || math.Abs(got.Cost-tt.wantCost) > 1e-9 {
+				t.Errorf("venta con cantidad %g y costo %g, se esperaba %g y %g", got.Quantity, got.Cost, wantQuantity, tt.wantCost)
+			}
+			assertStock(t, db, productID, tt.wantStock)
+		})
+	}
+}
+
+func TestEditSaleReversesPoints(t *testing.T) {
+	tests := []struct {
+		name         string
+		points       models.PointsReversal
+		wantReversed int
+		wantRestored int
+		wantBalance  int
+	}{
+		{"sin cambios en los puntos", models.PointsReversal{}, 0, 0, 80},
+		{"cambió el cliente o el estado", models.PointsReversal{Fraction: 1}, 10, 0, 70},
+		{"cambió el total", models.PointsReversal{Fraction: 1, RestoreRedeemed: true}, 10, 30, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDatabase(t)
+			saleRepo, loyaltyRepo := NewSaleRepo(db), NewLoyaltyRepo(db)
+			if err := loyaltyRepo.EarnPoints(1, 0, 100, testDate, 365); err != nil {
+				t.Fatal(err)
+			}
+			_, sale := testSale(t, db, 4, models.PointsRedemption{CustomerID: 1, Points: 30})
+			if err := loyaltyRepo.EarnPoints(1, sale.ID, 10, testDate, 365); err != nil {
+				t.Fatal(err)
+			}
+
+			reversed, restored, err := saleRepo.EditSale(sale, sale.Quantity, false, tt.points, testDate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reversed != tt.wantReversed || restored != tt.wantRestored {
+				t.Errorf("%d puntos anulados y %d reintegrados, se esperaba %d y %d", reversed, restored, tt.wantReversed, tt.wantRestored)
+			}
+			assertPointsBalance(t, loyaltyRepo, 1, tt.wantBalance)
+		})
+	}
+}
+
+func TestDeleteSaleRestoresStockAndPoints(t *testing.T) {
+	db := testDatabase(t)
+	saleRepo, loyaltyRepo := NewSaleRepo(db), NewLoyaltyRepo(db)
+	if err := loyaltyRepo.EarnPoints(1, 0, 100, testDate, 365); err != nil {
+		t.Fatal(err)
+	}
+	productID, sale := testSale(t, db, 4, models.PointsRedemption{CustomerID: 1, Points: 30})
+	if err := loyaltyRepo.EarnPoints(1, sale.ID, 10, testDate, 365); err != nil {
+		t.Fatal(err)
+	}
+	assertStock(t, db, productID, 6)
+	assertPointsBalance(t, loyaltyRepo, 1, 80)
+
+	reversed, restored, _, err := saleRepo.DeleteSale(sale, testDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reversed != 10 || restored != 30 {
+		t.Errorf("%d puntos anulados y %d reintegrados, se esperaba 10 y 30", reversed, restored)
+	}
+	if _, err := saleRepo.GetSaleByID(sale.ID); err == nil {
+		t.Error("la venta sigue registrada")
+	}
+	assertStock(t, db, productID, 10)
+	assertPointsBalance(t, loyaltyRepo, 1, 100)
+}
+
+// assertPointsBalance verifica el saldo de puntos del cliente.
+func assertPointsBalance(t *testing.T, loyaltyRepo *LoyaltyRepo, customerID, want int) {
+	t.Helper()
+	balance, err := loyaltyRepo.GetBalance(customerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance != want {
+		t.Errorf("saldo de puntos %d, se esperaba %d", balance, want)
+	}
+}
\ No newline at end of file
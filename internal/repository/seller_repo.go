@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"sales-system/internal/models"
+	"time"
+)
+
+type SellerRepo struct {
+	db *sql.DB
+}
+
+func NewSellerRepo(db *sql.DB) *SellerRepo {
+	return &SellerRepo{db: db}
+}
+
+func scanSeller(row scanner) (*models.Seller, error) {
+	var s models.Seller
+	if err := row.Scan(&s.ID, &s.Name, &s.Active); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *SellerRepo) CreateSeller(s models.Seller) (int64, error) {
+	res, err := r.db.Exec("INSERT INTO sellers (name, active) VALUES (?, 1)", s.Name)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// SetSellerActive da de baja o reactiva a un vendedor. Los vendedores dados
+// de baja no se ofrecen en las ventas nuevas pero conservan su historial.
+func (r *SellerRepo) SetSellerActive(id int, active bool) error {
+	_, err := r.db.Exec("UPDATE sellers SET active = ? WHERE id = ?", active, id)
+	return err
+}
+
+func (r *SellerRepo) GetSellerByID(id int) (*models.Seller, error) {
+	return scanSeller(r.db.QueryRow("SELECT id, name, active FROM sellers WHERE id = ?", id))
+}
+
+// GetSellerByName busca un vendedor por nombre sin distinguir mayúsculas.
+func (r *SellerRepo) GetSellerByName(name string) (*models.Seller, error) {
+	return scanSeller(r.db.QueryRow("SELECT id, name, active FROM sellers WHERE name = ?", name))
+}
+
+func (r *SellerRepo) GetAllSellers() ([]models.Seller, error) {
+	rows, err := r.db.Query("SELECT id, name, active FROM sellers ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sellers []models.Seller
+	for rows.Next() {
+		s, err := scanSeller(rows)
+		if err != nil {
+			return nil, err
+		}
+		sellers = append(sellers, *s)
+	}
+	return sellers, rows.Err()
+}
+
+// GetPlan devuelve las reglas de comisión del vendedor. Si no tiene reglas
+// cargadas, el plan no paga comisión.
+func (r *SellerRepo) GetPlan(sellerID int) (models.CommissionPlan, error) {
+	plan := models.CommissionPlan{SellerID: sellerID, Basis: models.CommissionOnSale, CategoryRates: make(map[int]float64)}
+	err := r.db.QueryRow("SELECT basis, percent FROM commission_plans WHERE seller_id = ?", sellerID).Scan(&plan.Basis, &plan.Percent)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return plan, err
+	}
+
+	rows, err := r.db.Query("SELECT threshold, percent FROM commission_tiers WHERE seller_id = ? ORDER BY threshold", sellerID)
+	if err != nil {
+		return plan, err
+	}
+	for rows.Next() {
+		var t models.CommissionTier
+		if err := rows.Scan(&t.From, &t.Percent); err != nil {
+			rows.Close()
+			return plan, err
+		}
+		plan.Tiers = append(plan.Tiers, t)
+	}
+	rows.Close()
+
+	rows, err = r.db.Query("SELECT category_id, percent FROM commission_category_rates WHERE seller_id = ?", sellerID)
+	if err != nil {
+		return plan, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var categoryID int
+		var percent float64
+		if err := rows.Scan(&categoryID, &percent); err != nil {
+			return plan, err
+		}
+		plan.CategoryRates[categoryID] = percent
+	}
+	return plan, rows.Err()
+}
+
+// SavePlan reemplaza las reglas de comisión del vendedor.
+func (r *SellerRepo) SavePlan(p models.CommissionPlan) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT OR REPLACE INTO commission_plans (seller_id, basis, percent) VALUES (?, ?, ?)", p.SellerID, p.Basis, p.Percent); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM commission_tiers WHERE seller_id = ?", p.SellerID); err != nil {
+		return err
+	}
+	for _, t := range p.Tiers {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO commission_tiers (seller_id, threshold, percent) VALUES (?, ?, ?)", p.SellerID, t.From, t.Percent); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM commission_category_rates WHERE seller_id = ?", p.SellerID); err != nil {
+		return err
+	}
+	for categoryID, percent := range p.CategoryRates {
+		if _, err := tx.Exec("INSERT INTO commission_category_rates (seller_id, category_id, percent) VALUES (?, ?, ?)", p.SellerID, categoryID, percent); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// creditedDate es la fecha en que una venta cuenta para las comisiones y las
+// metas: la de la venta o, si fue un apartado, la del pago que lo completó.
+// Requiere unir layaways l con los apartados completados.
+const creditedDate = "COALESCE(NULLIF(l.completed_date, ''), s.date)"
+
+// GetSellerSales devuelve las ventas del vendedor en el rango de fechas con
+// la categoría de su producto (la del producto padre si es una variante sin
+// categoría propia). No incluye las ventas de tarjetas de regalo ni los
+// apartados que todavía no se terminaron de pagar; los completados cuentan en
+// la fecha en que se terminaron de pagar.
+func (r *SellerRepo) GetSellerSales(sellerID int, start, end time.Time) ([]models.SellerSale, error) {
+	rows, err := r.db.Query(`SELECT s.id, `+creditedDate+`, s.product_name, COALESCE(NULLIF(p.category_id, 0), pp.category_id, 0), s.total, s.cost
+		FROM sales s
+		LEFT JOIN products p ON p.id = s.product_id
+		LEFT JOIN products pp ON pp.id = p.parent_id AND p.parent_id <> 0
+		LEFT JOIN layaways l ON l.sale_id = s.id AND l.status = ?
+		WHERE s.seller_id = ? AND s.product_id <> 0 AND s.status <> ? AND `+creditedDate+` BETWEEN ? AND ?
+		ORDER BY 2, s.id`, models.LayawayCompleted, sellerID, models.StatusLayaway, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sales []models.SellerSale
+	for rows.Next() {
+		var s models.SellerSale
+		var dateStr string
+		if err := rows.Scan(&s.SaleID, &dateStr, &s.ProductName, &s.CategoryID, &s.Total, &s.Cost); err != nil {
+			return nil, err
+		}
+		s.Date, _ = time.Parse(time.RFC3339, dateStr)
+		sales = append(sales, s)
+	}
+	return sales, rows.Err()
+}
\ No newline at end of file
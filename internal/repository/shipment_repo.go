@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"database/sql"
+	"sales-system/internal/models"
+	"time"
+)
+
+type ShipmentRepo struct {
+	db *sql.DB
+}
+
+func NewShipmentRepo(db *sql.DB) *ShipmentRepo {
+	return &ShipmentRepo{db: db}
+}
+
+// shipmentSaleID es la primera venta del ticket de un envío.
+const shipmentSaleID = "(SELECT MIN(ss.sale_id) FROM shipment_sales ss WHERE ss.shipment_id = sh.id)"
+
+// shipmentColumns lleva al final el estado de pago de la venta y el teléfono
+// del cliente, para la hoja de ruta.
+const shipmentColumns = `sh.id, COALESCE(` + shipmentSaleID + `, 0), sh.client, sh.address, sh.zone, sh.scheduled_date, sh.courier, sh.fee, sh.status, sh.note,
+	COALESCE(s.status, ''), COALESCE((SELECT c.phone FROM customers c WHERE c.name = sh.client), '')`
+
+const shipmentFrom = " FROM shipments sh LEFT JOIN sales s ON s.id = " + shipmentSaleID
+
+func scanShipment(row scanner) (*models.Shipment, error) {
+	var s models.Shipment
+	var dateStr string
+	err := row.Scan(&s.ID, &s.SaleID, &s.Client, &s.Address, &s.Zone, &dateStr, &s.Courier, &s.Fee, &s.Status, &s.Note,
+		&s.SaleStatus, &s.Phone)
+	if err != nil {
+		return nil, err
+	}
+	s.ScheduledDate, _ = time.Parse(effectiveDateLayout, dateStr)
+	return &s, nil
+}
+
+func scanShipments(rows *sql.Rows) ([]models.Shipment, error) {
+	defer rows.Close()
+	var shipments []models.Shipment
+	for rows.Next() {
+		s, err := scanShipment(rows)
+		if err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, *s)
+	}
+	return shipments, rows.Err()
+}
+
+// withItems completa las ventas que viajan en cada envío.
+func (r *ShipmentRepo) withItems(shipments []models.Shipment) ([]models.Shipment, error) {
+	for i := range shipments {
+		rows, err := r.db.Query(`SELECT ss.sale_id, s.product_name, s.quantity FROM shipment_sales ss JOIN sales s ON s.id = ss.sale_id
+			WHERE ss.shipment_id = ? ORDER BY ss.sale_id`, shipments[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var item models.ShipmentItem
+			if err := rows.Scan(&item.SaleID, &item.ProductName, &item.Quantity); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			shipments[i].Items = append(shipments[i].Items, item)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return shipments, nil
+}
+
+// CreateShipment registra en estado pendiente el envío de las ventas de un
+// ticket, vinculado a todas ellas.
+func (r *ShipmentRepo) CreateShipment(s models.Shipment, saleIDs []int) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO shipments (client, address, zone, scheduled_date, courier, fee, status, note) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		s.Client, s.Address, s.Zone, s.ScheduledDate.Format(effectiveDateLayout), s.Courier, s.Fee, models.ShipmentPending, s.Note)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	for _, saleID := range saleIDs {
+		if _, err := tx.Exec("INSERT INTO shipment_sales (shipment_id, sale_id) VALUES (?, ?)", id, saleID); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// GetShipmentByID devuelve un envío. Devuelve sql.ErrNoRows si no existe.
+func (r *ShipmentRepo) GetShipmentByID(id int) (*models.Shipment, error) {
+	return scanShipment(r.db.QueryRow("SELECT "+shipmentColumns+shipmentFrom+" WHERE sh.id = ?", id))
+}
+
+// GetLastShipment devuelve el último envío del cliente, para proponer su
+// dirección y zona en el próximo. Devuelve sql.ErrNoRows si no tiene.
+func (r *ShipmentRepo) GetLastShipment(client string) (*models.Shipment, error) {
+	return scanShipment(r.db.QueryRow("SELECT "+shipmentColumns+shipmentFrom+" WHERE sh.client = ? COLLATE NOCASE ORDER BY sh.id DESC LIMIT 1", client))
+}
+
+// GetShipmentsByDate devuelve los envíos programados para el día, ordenados
+// por zona, con las ventas que viajan en cada uno.
+func (r *ShipmentRepo) GetShipmentsByDate(date time.Time) ([]models.Shipment, error) {
+	rows, err := r.db.Query("SELECT "+shipmentColumns+shipmentFrom+" WHERE sh.scheduled_date = ? ORDER BY sh.zone COLLATE NOCASE, sh.id", date.Format(effectiveDateLayout))
+	if err != nil {
+		return nil, err
+	}
+	shipments, err := scanShipments(rows)
+	if err != nil {
+		return nil, err
+	}
+	return r.withItems(shipments)
+}
+
+// GetOpenShipments devuelve los envíos que todavía no se entregaron, por
+// fecha y zona.
+func (r *ShipmentRepo) GetOpenShipments() ([]models.Shipment, error) {
+	rows, err := r.db.Query("SELECT "+shipmentColumns+shipmentFrom+" WHERE sh.status <> ? ORDER BY sh.scheduled_date, sh.zone COLLATE NOCASE, sh.id", models.ShipmentDelivered)
+	if err != nil {
+		return nil, err
+	}
+	return scanShipments(rows)
+}
+
+// UpdateShipment guarda el estado, la fecha programada, el repartidor y la
+// nota del envío.
+func (r *ShipmentRepo) UpdateShipment(s models.Shipment) error {
+	_, err := r.db.Exec("UPDATE shipments SET status = ?, scheduled_date = ?, courier = ?, note = ? WHERE id = ?",
+		s.Status, s.ScheduledDate.Format(effectiveDateLayout), s.Courier, s.Note, s.ID)
+	return err
+}
+
+// GetFeeTotal devuelve lo cobrado por envíos de las ventas del rango de
+// fechas. Los envíos fallidos no se cobran.
+func (r *ShipmentRepo) GetFeeTotal(start, end time.Time) (float64, error) {
+	var total float64
+	err := r.db.QueryRow(`SELECT COALESCE(SUM(sh.fee), 0) FROM shipments sh JOIN sales s ON s.id = `+shipmentSaleID+`
+		WHERE sh.status <> ? AND s.date BETWEEN ? AND ?`,
+		models.ShipmentFailed, start.Format(time.RFC3339), end.Format(time.RFC3339)).Scan(&total)
+	return total, err
+}
\ No newline at end of file
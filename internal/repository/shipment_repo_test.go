@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"sales-system/internal/models"
+	"testing"
+)
+
+func TestDeleteSaleUnlinksShipment(t *testing.T) {
+	db := testDatabase(t)
+	saleRepo, shipmentRepo := NewSaleRepo(db), NewShipmentRepo(db)
+	productID := testProduct(t, db, models.Product{Name: "Queso", Price: 50})
+	if err := NewProductRepo(db).ReceiveStock(productID, models.DefaultLocationID, 10, 5, testDate, "Compra", models.Lot{}); err != nil {
+		t.Fatal(err)
+	}
+	var sales []models.Sale
+	for range 3 {
+		sale := models.Sale{Date: testDate, LocationID: models.DefaultLocationID, Client: "Cliente", ProductID: productID, Quantity: 1, Price: 50, Total: 50, Status: models.StatusPaid}
+		id, err := saleRepo.RecordSale(sale, false, models.PointsRedemption{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorded, err := saleRepo.GetSaleByID(int(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sales = append(sales, *recorded)
+	}
+
+	shipment := models.Shipment{Client: "Cliente", Address: "Calle 1", ScheduledDate: testDate}
+	ticketID, err := shipmentRepo.CreateShipment(shipment, []int{sales[0].ID, sales[1].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Otro envío puede incluir una venta que ya viaja en otro.
+	if _, err := shipmentRepo.CreateShipment(shipment, []int{sales[0].ID, sales[2].ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		deleted    models.Sale
+		wantSaleID int
+	}{
+		{sales[0], sales[1].ID},
+		{sales[1], 0},
+	}
+	for _, step := range steps {
+		if _, _, _, err := saleRepo.DeleteSale(step.deleted, testDate); err != nil {
+			t.Fatal(err)
+		}
+		got, err := shipmentRepo.GetShipmentByID(int(ticketID))
+		if step.wantSaleID == 0 {
+			if !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("el envío sin ventas sigue registrado (error %v)", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.SaleID != step.wantSaleID {
+			t.Errorf("envío de la venta #%d tras eliminar la #%d, se esperaba la #%d", got.SaleID, step.deleted.ID, step.wantSaleID)
+		}
+	}
+}
\ No newline at end of file
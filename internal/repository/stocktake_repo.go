@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"sales-system/internal/models"
+	"time"
+)
+
+type StocktakeRepo struct {
+	db *sql.DB
+}
+
+func NewStocktakeRepo(db *sql.DB) *StocktakeRepo {
+	return &StocktakeRepo{db: db}
+}
+
+func (r *StocktakeRepo) CreateStocktake(date time.Time, locationID int) (int64, error) {
+	res, err := r.db.Exec("INSERT INTO stocktakes (date, location_id, status) VALUES (?, ?, ?)", date.Format(time.RFC3339), locationID, models.StocktakeOpen)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetStocktakeByID devuelve la toma de inventario con sus conteos.
+func (r *StocktakeRepo) GetStocktakeByID(id int) (*models.Stocktake, error) {
+	var t models.Stocktake
+	var dateStr, appliedStr string
+	err := r.db.QueryRow("SELECT id, date, location_id, status, supervisor, applied_date FROM stocktakes WHERE id = ?", id).Scan(&t.ID, &dateStr, &t.LocationID, &t.Status, &t.Supervisor, &appliedStr)
+	if err != nil {
+		return nil, err
+	}
+	t.Date, _ = time.Parse(time.RFC3339, dateStr)
+	t.AppliedDate, _ = time.Parse(time.RFC3339, appliedStr)
+
+	rows, err := r.db.Query("SELECT id, stocktake_id, product_id, expected, counted, unit_cost FROM stocktake_counts WHERE stocktake_id = ? ORDER BY product_id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c models.StocktakeCount
+		if err := rows.Scan(&c.ID, &c.StocktakeID, &c.ProductID, &c.Expected, &c.Counted, &c.UnitCost); err != nil {
+			return nil, err
+		}
+		t.Counts = append(t.Counts, c)
+	}
+	return &t, nil
+}
+
+// GetAllStocktakes devuelve las tomas de inventario sin sus conteos.
+func (r *StocktakeRepo) GetAllStocktakes() ([]models.Stocktake, error) {
+	rows, err := r.db.Query("SELECT id, date, location_id, status, supervisor, applied_date FROM stocktakes ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stocktakes []models.Stocktake
+	for rows.Next() {
+		var t models.Stocktake
+		var dateStr, appliedStr string
+		if err := rows.Scan(&t.ID, &dateStr, &t.LocationID, &t.Status, &t.Supervisor, &appliedStr); err != nil {
+			return nil, err
+		}
+		t.Date, _ = time.Parse(time.RFC3339, dateStr)
+		t.AppliedDate, _ = time.Parse(time.RFC3339, appliedStr)
+		stocktakes = append(stocktakes, t)
+	}
+	return stocktakes, nil
+}
+
+// SaveCount guarda el conteo de un producto. En el primer conteo se congela
+// el stock esperado en la ubicación de la toma y el costo del producto; los
+// conteos siguientes solo reemplazan la cantidad contada.
+func (r *StocktakeRepo) SaveCount(t models.Stocktake, p models.Product, counted float64) error {
+	expected, err := locationQuantity(r.db, p.ID, t.LocationID)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`INSERT INTO stocktake_counts (stocktake_id, product_id, expected, counted, unit_cost) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(stocktake_id, product_id) DO UPDATE SET counted = excluded.counted`, t.ID, p.ID, expected, counted, p.Cost)
+	return err
+}
+
+// DeleteCount quita un producto de la toma para que no se ajuste.
+func (r *StocktakeRepo) DeleteCount(stocktakeID, productID int) error {
+	_, err := r.db.Exec("DELETE FROM stocktake_counts WHERE stocktake_id = ? AND product_id = ?", stocktakeID, productID)
+	return err
+}
+
+// ApplyStocktake registra un movimiento de ajuste por cada diferencia y
+// marca la toma como aplicada con el nombre del supervisor que la confirmó.
+// lots indica, por ID de producto, el lote al que entra un sobrante.
+func (r *StocktakeRepo) ApplyStocktake(id int, supervisor string, lots map[int]models.Lot, date time.Time) error {
+	t, err := r.GetStocktakeByID(id)
+	if err != nil {
+		return err
+	}
+	if t.Status != models.StocktakeOpen {
+		return fmt.Errorf("la toma de inventario #%d está %s", id, t.Status)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	reference := fmt.Sprintf("Toma de inventario #%d", id)
+	for _, c := range t.Counts {
+		if !c.HasVariance() {
+			continue
+		}
+		variance := c.Variance()
+		if variance > 0 {
+			err = receiveStock(tx, c.ProductID, t.LocationID, variance, c.UnitCost, date, models.MovementAdjustment, reference)
+			if lot, ok := lots[c.ProductID]; ok && lot.Number != "" && err == nil {
+				err = addLot(tx, c.ProductID, t.LocationID, lot.Number, lot.Expiry, variance)
+			}
+		} else {
+			_, err = consumeStock(tx, c.ProductID, t.LocationID, -variance, date, models.MovementAdjustment, reference)
+			if err == nil {
+				err = trimLots(tx, c.ProductID, t.LocationID, date)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("UPDATE stocktakes SET status = ?, supervisor = ?, applied_date = ? WHERE id = ?", models.StocktakeApplied, supervisor, date.Format(time.RFC3339), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CancelStocktake descarta una toma abierta sin tocar el stock.
+func (r *StocktakeRepo) CancelStocktake(id int) error {
+	res, err := r.db.Exec("UPDATE stocktakes SET status = ? WHERE id = ? AND status = ?", models.StocktakeCancelled, id, models.StocktakeOpen)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("la toma de inventario #%d no está abierta", id)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"sales-system/internal/models"
+	"testing"
+)
+
+func TestApplyStocktakeAdjustsCountedProducts(t *testing.T) {
+	tests := []struct {
+		name                  string
+		countedCheese         float64
+		countedBread          float64
+		removeBread           bool
+		wantCheese, wantBread float64
+		wantCounts            int
+	}{
+		{"faltante y sobrante", 7, 12, false, 7, 12, 2},
+		{"sin diferencias", 10, 10, false, 10, 10, 2},
+		{"conteo quitado", 7, 0, true, 7, 10, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDatabase(t)
+			productRepo, stocktakeRepo := NewProductRepo(db), NewStocktakeRepo(db)
+			cheese := testProduct(t, db, models.Product{Name: "Queso", Price: 50})
+			bread := testProduct(t, db, models.Product{Name: "Pan", Price: 10})
+			for _, id := range []int{cheese, bread} {
+				if err := productRepo.ReceiveStock(id, models.DefaultLocationID, 10, 5, testDate, "Compra", models.Lot{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stocktakeID, err := stocktakeRepo.CreateStocktake(testDate, models.DefaultLocationID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stocktake, err := stocktakeRepo.GetStocktakeByID(int(stocktakeID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for id, counted := range map[int]float64{cheese: tt.countedCheese, bread: tt.countedBread} {
+				p, err := productRepo.GetProductByID(id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := stocktakeRepo.SaveCount(*stocktake, *p, counted); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.removeBread {
+				if err := stocktakeRepo.DeleteCount(stocktake.ID, bread); err != nil {
+					t.Fatal(err)
+				}
+			}
+			stocktake, err = stocktakeRepo.GetStocktakeByID(stocktake.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stocktake.Counts) != tt.wantCounts {
+				t.Errorf("%d conteos en la toma, se esperaban %d", len(stocktake.Counts), tt.wantCounts)
+			}
+
+			if err := stocktakeRepo.ApplyStocktake(stocktake.ID, "Supervisor", nil, testDate); err != nil {
+				t.Fatal(err)
+			}
+			assertStock(t, db, cheese, tt.wantCheese)
+			assertStock(t, db, bread, tt.wantBread)
+			if err := stocktakeRepo.ApplyStocktake(stocktake.ID, "Supervisor", nil, testDate); err == nil {
+				t.Error("se pudo aplicar dos veces la misma toma")
+			}
+		})
+	}
+}
\ No newline at end of file
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"database/sql"
+	"sales-system/internal/models"
+	"time"
+)
+
+// targetPeriodLayout es el formato con que se guarda el mes de una meta.
+const targetPeriodLayout = "2006-01"
+
+type TargetRepo struct {
+	db *sql.DB
+}
+
+func NewTargetRepo(db *sql.DB) *TargetRepo {
+	return &TargetRepo{db: db}
+}
+
+// SetTarget guarda la meta del mes, reemplazando la anterior si ya había una
+// para el mismo local, categoría o vendedor. Una meta de 0 la elimina.
+func (r *TargetRepo) SetTarget(t models.SalesTarget) error {
+	if t.Amount <= 0 {
+		_, err := r.db.Exec("DELETE FROM sales_targets WHERE period = ? AND scope = ? AND ref_id = ?", t.Month.Format(targetPeriodLayout), t.Scope, t.RefID)
+		return err
+	}
+	_, err := r.db.Exec("INSERT OR REPLACE INTO sales_targets (period, scope, ref_id, amount) VALUES (?, ?, ?, ?)",
+		t.Month.Format(targetPeriodLayout), t.Scope, t.RefID, t.Amount)
+	return err
+}
+
+// GetTargets devuelve las metas del mes ordenadas por tipo.
+func (r *TargetRepo) GetTargets(month time.Time) ([]models.SalesTarget, error) {
+	rows, err := r.db.Query("SELECT scope, ref_id, amount FROM sales_targets WHERE period = ? ORDER BY scope DESC, ref_id", month.Format(targetPeriodLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []models.SalesTarget
+	for rows.Next() {
+		t := models.SalesTarget{Month: month}
+		if err := rows.Scan(&t.Scope, &t.RefID, &t.Amount); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+	return targets, rows.Err()
+}
+
+// GetTargetSales devuelve las ventas del rango de fechas con su local,
+// vendedor y la categoría de su producto (la del producto padre si es una
+// variante sin categoría propia). Igual que en las comisiones, no incluye
+// las ventas de tarjetas de regalo ni los apartados sin terminar de pagar, y
+// los completados cuentan en la fecha en que se terminaron de pagar.
+func (r *TargetRepo) GetTargetSales(start, end time.Time) ([]models.TargetSale, error) {
+	rows, err := r.db.Query(`SELECT `+creditedDate+`, s.location_id, COALESCE(NULLIF(p.category_id, 0), pp.category_id, 0), s.seller_id, s.total
+		FROM sales s
+		LEFT JOIN products p ON p.id = s.product_id
+		LEFT JOIN products pp ON pp.id = p.parent_id AND p.parent_id <> 0
+		LEFT JOIN layaways l ON l.sale_id = s.id AND l.status = ?
+		WHERE s.product_id <> 0 AND s.status <> ? AND `+creditedDate+` BETWEEN ? AND ?`,
+		models.LayawayCompleted, models.StatusLayaway, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sales []models.TargetSale
+	for rows.Next() {
+		var s models.TargetSale
+		var dateStr string
+		if err := rows.Scan(&dateStr, &s.LocationID, &s.CategoryID, &s.SellerID, &s.Total); err != nil {
+			return nil, err
+		}
+		s.Date, _ = time.Parse(time.RFC3339, dateStr)
+		sales = append(sales, s)
+	}
+	return sales, rows.Err()
+}
\ No newline at end of file
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EANCheckDigit calcula el dígito verificador EAN/UPC para los dígitos dados
+// (sin el verificador). Devuelve -1 si la cadena contiene algo que no sea un dígito.
+func EANCheckDigit(digits string) int {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		c := digits[len(digits)-1-i]
+		if c < '0' || c > '9' {
+			return -1
+		}
+		d := int(c - '0')
+		// Desde la derecha, las posiciones impares pesan 3 y las pares 1.
+		if i%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10
+}
+
+// IsValidEAN13 indica si el código tiene 13 dígitos con verificador correcto.
+func IsValidEAN13(code string) bool {
+	return len(code) == 13 && EANCheckDigit(code[:12]) == int(code[12]-'0')
+}
+
+// IsValidUPCA indica si el código tiene 12 dígitos con verificador correcto.
+func IsValidUPCA(code string) bool {
+	return len(code) == 12 && EANCheckDigit(code[:11]) == int(code[11]-'0')
+}
+
+// NormalizeBarcode valida un código EAN-13 o UPC-A y lo devuelve como EAN-13
+// (un UPC-A equivale al EAN-13 con un cero adelante). Devuelve false si el
+// código no es válido.
+func NormalizeBarcode(code string) (string, bool) {
+	code = strings.TrimSpace(code)
+	switch {
+	case IsValidEAN13(code):
+		return code, true
+	case IsValidUPCA(code):
+		return "0" + code, true
+	}
+	return "", false
+}
+
+// Patrones EAN-13: codificación L (paridad impar) de cada dígito. La G es la
+// R invertida y la R es el complemento de la L.
+var eanL = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+
+// eanParity indica, según el primer dígito, qué dígitos de la mitad izquierda usan la codificación G.
+var eanParity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+
+// Zonas de silencio, en módulos, que deben quedar en blanco a cada lado del
+// código para que el escáner lo lea.
+const (
+	EAN13QuietLeft   = 11
+	EAN13QuietRight  = 7
+	Code128QuietZone = 10
+)
+
+// EAN13Modules devuelve los módulos (true = barra) de un código EAN-13 válido,
+// incluidas las guardas, listos para dibujar.
+func EAN13Modules(code string) ([]bool, error) {
+	if !IsValidEAN13(code) {
+		return nil, fmt.Errorf("código EAN-13 inválido: %s", code)
+	}
+
+	pattern := "101"
+	parity := eanParity[code[0]-'0']
+	for i := 1; i <= 6; i++ {
+		l := eanL[code[i]-'0']
+		if parity[i-1] == 'G' {
+			l = reverse(complement(l))
+		}
+		pattern += l
+	}
+	pattern += "01010"
+	for i := 7; i <= 12; i++ {
+		pattern += complement(eanL[code[i]-'0'])
+	}
+	pattern += "101"
+
+	modules := make([]bool, len(pattern))
+	for i, c := range pattern {
+		modules[i] = c == '1'
+	}
+	return modules, nil
+}
+
+func complement(s string) string {
+	b := []byte(s)
+	for i := range b {
+		if b[i] == '0' {
+			b[i] = '1'
+		} else {
+			b[i] = '0'
+		}
+	}
+	return string(b)
+}
+
+func reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+// code128Widths contiene el ancho de barras y espacios alternados de cada
+// símbolo Code 128, indexado por su valor (0-105).
+var code128Widths = [106]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232",
+}
+
+const (
+	code128StartB = 104
+	code128Stop   = "2331112"
+)
+
+// Code128Modules codifica el texto con el juego de caracteres B de Code 128
+// (ASCII imprimible) y devuelve sus módulos (true = barra), con inicio,
+// dígito de control y parada.
+func Code128Modules(text string) ([]bool, error) {
+	if text == "" {
+		return nil, fmt.Errorf("no se puede codificar un texto vacío")
+	}
+
+	symbols := []string{code128Widths[code128StartB]}
+	checksum := code128StartB
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c < 32 || c > 127 {
+			return nil, fmt.Errorf("carácter no soportado en Code 128: %q", c)
+		}
+		value := int(c - 32)
+		symbols = append(symbols, code128Widths[value])
+		checksum += value * (i + 1)
+	}
+	symbols = append(symbols, code128Widths[checksum%103], code128Stop)
+
+	var modules []bool
+	for _, widths := range symbols {
+		for i, w := range widths {
+			bar := i%2 == 0
+			for n := 0; n < int(w-'0'); n++ {
+				modules = append(modules, bar)
+			}
+		}
+	}
+	return modules, nil
+}
\ No newline at end of file
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeBarcode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+		ok   bool
+	}{
+		{"4006381333931", "4006381333931", true},
+		{" 5901234123457 ", "5901234123457", true},
+		{"036000291452", "0036000291452", true},
+		{"4006381333932", "", false},
+		{"036000291453", "", false},
+		{"40063813339a1", "", false},
+		{"ABC-123", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			got, ok := NormalizeBarcode(tt.code)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("NormalizeBarcode(%q) = %q, %v; se esperaba %q, %v", tt.code, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// bits convierte los módulos en una cadena de unos (barras) y ceros.
+func bits(modules []bool) string {
+	var b strings.Builder
+	for _, m := range modules {
+		if m {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	return b.String()
+}
+
+func TestEAN13Modules(t *testing.T) {
+	tests := []struct {
+		code    string
+		wantErr bool
+	}{
+		{"4006381333931", false},
+		{"5901234123457", false},
+		{"0000000000000", false},
+		{"4006381333932", true},
+		{"400638133393", true},
+		{"40063813339a1", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			modules, err := EAN13Modules(tt.code)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("se esperaba un error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := bits(modules)
+			if len(s) != 95 {
+				t.Fatalf("%d módulos, se esperaban 95", len(s))
+			}
+			if s[:3] != "101" || s[45:50] != "01010" || s[92:] != "101" {
+				t.Errorf("guardas incorrectas: %s", s)
+			}
+			// Cada dígito ocupa 7 módulos con dos barras; los de la izquierda
+			// empiezan con espacio y los de la derecha con barra.
+			for i := 0; i < 12; i++ {
+				start := 3 + i*7
+				if i >= 6 {
+					start += 5
+				}
+				digit := s[start : start+7]
+				if bars := strings.Count(digit, "01") + int(digit[0]-'0'); bars != 2 {
+					t.Errorf("dígito %d (%s): %d barras, se esperaban 2", i+2, digit, bars)
+				}
+				if left := i < 6; (digit[0] == '0') != left || (digit[6] == '1') != left {
+					t.Errorf("dígito %d (%s): bordes incorrectos", i+2, digit)
+				}
+			}
+		})
+	}
+}
+
+func TestEAN13ModulesFirstDigitParity(t *testing.T) {
+	// El primer dígito no se dibuja: define la paridad de la mitad
+	// izquierda, por eso dos códigos que solo difieren en él cambian la
+	// izquierda y no la derecha.
+	a, err := EAN13Modules("0012345678905")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := EAN13Modules("4012345678901")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sa, sb := bits(a), bits(b)
+	if sa[3:45] == sb[3:45] {
+		t.Error("la mitad izquierda no cambió con el primer dígito")
+	}
+	if sa[50:85] != sb[50:85] {
+		t.Error("la mitad derecha cambió con el primer dígito")
+	}
+}
+
+func TestCode128Modules(t *testing.T) {
+	const start = "11010010000"
+	const stop = "1100011101011"
+	tests := []struct {
+		text    string
+		check   string
+		wantErr bool
+	}{
+		// Control: (104 + 33) % 103 = 34.
+		{"A", "10001011000", false},
+		// Control: (104 + 33 + 34*2) % 103 = 102.
+		{"AB", "11110101110", false},
+		// Control: (104 + 3 + 17*2) % 103 = 38.
+		{"#1", "10001100010", false},
+		{"", "", true},
+		{"a\tb", "", true},
+		{"ñ", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			modules, err := Code128Modules(tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("se esperaba un error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := bits(modules)
+			if want := 11*(len(tt.text)+2) + len(stop); len(s) != want {
+				t.Fatalf("%d módulos, se esperaban %d", len(s), want)
+			}
+			if !strings.HasPrefix(s, start) {
+				t.Errorf("no empieza con el inicio B: %s", s[:11])
+			}
+			if !strings.HasSuffix(s, stop) {
+				t.Errorf("no termina con la parada: %s", s[len(s)-13:])
+			}
+			check := s[len(s)-len(stop)-11 : len(s)-len(stop)]
+			if check != tt.check {
+				t.Errorf("dígito de control %s, se esperaba %s", check, tt.check)
+			}
+		})
+	}
+}
\ No newline at end of file
@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 )
 
 // ClearScreen limpia la pantalla de la consola.
@@ -18,4 +19,17 @@ func ClearScreen() {
 		cmd.Stdout = os.Stdout
 		cmd.Run()
 	}
+}
+
+// ProgressBar dibuja una barra de avance de width caracteres para un
+// porcentaje, por ejemplo "[#####-----]". Por encima de 100 la barra queda llena.
+func ProgressBar(percent float64, width int) string {
+	filled := int(percent / 100 * float64(width))
+	if filled < 0 {
+		filled = 0
+	}
+	if filled > width {
+		filled = width
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
 }
\ No newline at end of file